@@ -5,40 +5,54 @@ package admin
 
 import (
 	"context"
+	"strings"
 
 	"github.com/luxfi/sdk/admin"
 )
 
+// APIPath is the path of the admin API, which the SDK client appends to the
+// node's URL itself.
+const APIPath = "/ext/admin"
+
 var _ Client = &client{}
 
 type Client interface {
-	LoadVMs() error
-	WhitelistChain(chainID string) error
+	// LoadVMs has the node load the VMs in its plugin directory. It returns
+	// the aliases of the VMs that were newly loaded and the errors of the VMs
+	// that failed to load, by VMID.
+	LoadVMs() (map[string][]string, map[string]string, error)
 }
 
 type client struct {
 	client *admin.Client
 }
 
+// NewClient returns a client of the admin API of the node at url. The admin
+// API's path may be included in url.
 func NewClient(url string) Client {
 	return &client{
-		client: admin.NewClient(url),
+		client: admin.NewClient(strings.TrimSuffix(strings.TrimRight(url, "/"), APIPath)),
 	}
 }
 
-func (c *client) LoadVMs() error {
-	_, _, err := c.client.LoadVMs(context.Background())
-
-	return err
+// loadVMsReply is the reply of admin.loadVMs. VMIDs are decoded as strings,
+// since ids.ID can't be decoded from a map key.
+type loadVMsReply struct {
+	NewVMs    map[string][]string `json:"newVMs"`
+	FailedVMs map[string]string   `json:"failedVMs"`
 }
 
-func (c *client) WhitelistChain(_ string) error {
-	// id, err := ids.FromString(chainID)
-	// if err != nil {
-	// 	return err
-	// }
-	//
-	// _, err = c.client.WhitelistChain(context.Background(), id)
-	// return err
-	return nil
+func (c *client) LoadVMs() (map[string][]string, map[string]string, error) {
+	reply := &loadVMsReply{}
+	err := c.client.Requester.SendRequest(
+		context.Background(),
+		"admin.loadVMs",
+		struct{}{},
+		reply,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reply.NewVMs, reply.FailedVMs, nil
 }
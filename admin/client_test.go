@@ -0,0 +1,63 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadVMs(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   string
+		wantNew    map[string][]string
+		wantFailed map[string]string
+		wantErr    bool
+	}{
+		{
+			name:     "loaded and failed",
+			response: `{"jsonrpc":"2.0","result":{"newVMs":{"vmID":["vm"]},"failedVMs":{"failedVMID":"exec format error"}},"id":1}`,
+			wantNew: map[string][]string{
+				"vmID": {"vm"},
+			},
+			wantFailed: map[string]string{
+				"failedVMID": "exec format error",
+			},
+		},
+		{
+			name:     "nothing new",
+			response: `{"jsonrpc":"2.0","result":{"newVMs":{}},"id":1}`,
+			wantNew:  map[string][]string{},
+		},
+		{
+			name:     "failed",
+			response: `{"jsonrpc":"2.0","error":{"code":-32000,"message":"couldn't read plugin directory"},"id":1}`,
+			wantErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, APIPath, r.URL.Path)
+				_, _ = w.Write([]byte(test.response))
+			}))
+			defer server.Close()
+
+			// The admin API's path is only added once.
+			newVMs, failedVMs, err := NewClient(server.URL + APIPath).LoadVMs()
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.wantNew, newVMs)
+			require.Equal(t, test.wantFailed, failedVMs)
+		})
+	}
+}
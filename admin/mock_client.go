@@ -37,11 +37,13 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 }
 
 // LoadVMs mocks base method.
-func (m *MockClient) LoadVMs() error {
+func (m *MockClient) LoadVMs() (map[string][]string, map[string]string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "LoadVMs")
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(map[string][]string)
+	ret1, _ := ret[1].(map[string]string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // LoadVMs indicates an expected call of LoadVMs.
@@ -49,17 +51,3 @@ func (mr *MockClientMockRecorder) LoadVMs() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadVMs", reflect.TypeOf((*MockClient)(nil).LoadVMs))
 }
-
-// WhitelistChain mocks base method.
-func (m *MockClient) WhitelistChain(chainID string) error {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WhitelistChain", chainID)
-	ret0, _ := ret[0].(error)
-	return ret0
-}
-
-// WhitelistChain indicates an expected call of WhitelistChain.
-func (mr *MockClientMockRecorder) WhitelistChain(chainID interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WhitelistChain", reflect.TypeOf((*MockClient)(nil).WhitelistChain), chainID)
-}
@@ -0,0 +1,408 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+)
+
+type entry struct {
+	name     string
+	body     string
+	mode     int64
+	typeflag byte
+	linkname string
+}
+
+func regular(name string, body string) entry {
+	return entry{name: name, body: body, mode: 0o644, typeflag: tar.TypeReg}
+}
+
+func executable(name string, body string) entry {
+	return entry{name: name, body: body, mode: 0o755, typeflag: tar.TypeReg}
+}
+
+func symlink(name string, linkname string) entry {
+	return entry{name: name, mode: 0o777, typeflag: tar.TypeSymlink, linkname: linkname}
+}
+
+func hardlink(name string, linkname string) entry {
+	return entry{name: name, mode: 0o644, typeflag: tar.TypeLink, linkname: linkname}
+}
+
+func buildTar(t *testing.T, entries []entry) []byte {
+	buf := &bytes.Buffer{}
+	w := tar.NewWriter(buf)
+	for _, e := range entries {
+		require.NoError(t, w.WriteHeader(&tar.Header{
+			Name:     e.name,
+			Mode:     e.mode,
+			Size:     int64(len(e.body)),
+			Typeflag: e.typeflag,
+			Linkname: e.linkname,
+		}))
+		_, err := w.Write([]byte(e.body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func buildZip(t *testing.T, entries []entry) []byte {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		body := e.body
+		switch e.typeflag {
+		case tar.TypeSymlink:
+			header.SetMode(fs.ModeSymlink | 0o777)
+			body = e.linkname
+		default:
+			header.SetMode(fs.FileMode(e.mode))
+		}
+		f, err := w.CreateHeader(header)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func build(t *testing.T, format Format, entries []entry) []byte {
+	if format == Zip {
+		return buildZip(t, entries)
+	}
+
+	raw := buildTar(t, entries)
+	buf := &bytes.Buffer{}
+	var w io.WriteCloser
+	switch format {
+	case Tar:
+		return raw
+	case TarGz:
+		w = gzip.NewWriter(buf)
+	case TarXz:
+		var err error
+		w, err = xz.NewWriter(buf)
+		require.NoError(t, err)
+	case TarZst:
+		var err error
+		w, err = zstd.NewWriter(buf)
+		require.NoError(t, err)
+	}
+	_, err := w.Write(raw)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestDetect(t *testing.T) {
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "asset", build(t, format, []entry{regular("evm", "binary")}), perms.ReadWrite))
+
+			got, ok, err := Detect(fs, "asset")
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, format, got)
+
+			got, ok, err = Detect(fs, "evm-linux-amd64."+string(format))
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, format, got)
+		})
+	}
+
+	t.Run("raw binary", func(t *testing.T) {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "evm-linux-amd64", []byte("\x7fELF"), perms.ReadWrite))
+
+		_, ok, err := Detect(fs, "evm-linux-amd64")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("short names", func(t *testing.T) {
+		for name, want := range map[string]Format{"evm.tgz": TarGz, "evm.txz": TarXz, "evm.tzst": TarZst, "EVM.ZIP": Zip} {
+			got, ok := FromName(name)
+			require.True(t, ok, name)
+			require.Equal(t, want, got, name)
+		}
+	})
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name      string
+		entries   []entry
+		wantFiles map[string]string
+		wantErr   error
+	}{
+		{
+			name: "nested files",
+			entries: []entry{
+				executable("evm-v1.0.0/evm", "binary"),
+				regular("evm-v1.0.0/README.md", "readme"),
+			},
+			wantFiles: map[string]string{
+				"evm-v1.0.0/evm":       "binary",
+				"evm-v1.0.0/README.md": "readme",
+			},
+		},
+		{
+			name:    "parent directory traversal",
+			entries: []entry{regular("../evil", "evil")},
+			wantErr: ErrUnsafePath,
+		},
+		{
+			name:    "nested parent directory traversal",
+			entries: []entry{regular("evm/../../evil", "evil")},
+			wantErr: ErrUnsafePath,
+		},
+		{
+			name:    "absolute path",
+			entries: []entry{regular("/tmp/evil", "evil")},
+			wantErr: ErrUnsafePath,
+		},
+		{
+			name:    "symlink outside of the archive",
+			entries: []entry{symlink("evm/lib", "../../etc")},
+			wantErr: ErrUnsafePath,
+		},
+		{
+			name:    "absolute symlink",
+			entries: []entry{symlink("evm/lib", "/etc")},
+			wantErr: ErrUnsafePath,
+		},
+	}
+
+	for _, test := range tests {
+		for _, format := range []Format{TarGz, Zip} {
+			t.Run(test.name+" "+string(format), func(t *testing.T) {
+				fs := afero.NewMemMapFs()
+				require.NoError(t, afero.WriteFile(fs, "asset", build(t, format, test.entries), perms.ReadWrite))
+
+				err := Extract(fs, format, "asset", "out", Options{})
+				require.ErrorIs(t, err, test.wantErr)
+
+				for name, body := range test.wantFiles {
+					b, err := afero.ReadFile(fs, filepath.Join("out", name))
+					require.NoError(t, err)
+					require.Equal(t, body, string(b))
+				}
+			})
+		}
+	}
+}
+
+func TestExtractFormats(t *testing.T) {
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "asset", build(t, format, []entry{executable("evm", "binary")}), perms.ReadWrite))
+			require.NoError(t, Extract(fs, format, "asset", "out", Options{}))
+
+			info, err := fs.Stat(filepath.Join("out", "evm"))
+			require.NoError(t, err)
+			require.NotZero(t, info.Mode()&0o111)
+		})
+	}
+}
+
+func TestExtractLinks(t *testing.T) {
+	dir := t.TempDir()
+	fs := afero.NewBasePathFs(afero.NewOsFs(), dir)
+
+	tests := []struct {
+		name            string
+		entries         []entry
+		stripComponents int
+		wantErr         error
+	}{
+		{
+			name: "symlink inside of the archive",
+			entries: []entry{
+				executable("evm/bin/evm", "binary"),
+				symlink("evm/evm", "bin/evm"),
+			},
+		},
+		{
+			name: "write through a symlink",
+			entries: []entry{
+				symlink("evm/lib", "."),
+				symlink("evm/lib/up", ".."),
+				regular("evm/lib/up/evil", "evil"),
+			},
+			wantErr: ErrUnsafePath,
+		},
+		{
+			name: "hard link copies the file",
+			entries: []entry{
+				executable("evm/evm", "binary"),
+				hardlink("evm/plugin", "evm/evm"),
+			},
+		},
+		{
+			name: "symlink escapes once stripped",
+			entries: []entry{
+				executable("evm/bin/evm", "binary"),
+				symlink("evm/lib", "../bin"),
+			},
+			stripComponents: 1,
+			wantErr:         ErrUnsafePath,
+		},
+		{
+			name: "stripped",
+			entries: []entry{
+				executable("evm-v1.0.0/bin/evm", "binary"),
+				symlink("evm-v1.0.0/evm", "bin/evm"),
+			},
+			stripComponents: 1,
+		},
+		{
+			name:    "hard link outside of the archive",
+			entries: []entry{hardlink("evm/passwd", "../../etc/passwd")},
+			wantErr: ErrUnsafePath,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := filepath.Join("out", string(rune('a'+i)))
+			require.NoError(t, afero.WriteFile(fs, "asset.tar", build(t, Tar, test.entries), perms.ReadWrite))
+
+			err := Extract(fs, Tar, "asset.tar", out, Options{StripComponents: test.stripComponents})
+			require.ErrorIs(t, err, test.wantErr)
+			if test.wantErr != nil {
+				return
+			}
+
+			binary, err := FindBinary(fs, out, "", "evm")
+			require.NoError(t, err)
+			b, err := afero.ReadFile(fs, binary)
+			require.NoError(t, err)
+			require.Equal(t, "binary", string(b))
+		})
+	}
+}
+
+func TestFindBinary(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []entry
+		binary  string
+		names   []string
+		want    string
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name: "named after the vm",
+			entries: []entry{
+				executable("evm-v1.0.0/install.sh", "script"),
+				executable("evm-v1.0.0/evm", "binary"),
+			},
+			names:   []string{"evm", "vmid"},
+			want:    "evm-v1.0.0/evm",
+			wantErr: assert.NoError,
+		},
+		{
+			name: "only executable",
+			entries: []entry{
+				regular("README.md", "readme"),
+				executable("bin/plugin", "binary"),
+			},
+			names:   []string{"evm"},
+			want:    "bin/plugin",
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "only file",
+			entries: []entry{regular("plugin", "binary")},
+			names:   []string{"evm"},
+			want:    "plugin",
+			wantErr: assert.NoError,
+		},
+		{
+			name: "explicit path",
+			entries: []entry{
+				executable("evm-v1.0.0/evm", "binary"),
+				executable("evm-v1.0.0/tools/evm", "tool"),
+			},
+			binary:  "evm-v1.0.0/tools/evm",
+			want:    "evm-v1.0.0/tools/evm",
+			wantErr: assert.NoError,
+		},
+		{
+			name: "explicit path relative to the top level directory",
+			entries: []entry{
+				executable("evm-v1.0.0/bin/evm", "binary"),
+				executable("evm-v1.0.0/tools/evm", "tool"),
+			},
+			binary:  "bin/evm",
+			want:    "evm-v1.0.0/bin/evm",
+			wantErr: assert.NoError,
+		},
+		{
+			name: "explicit pattern",
+			entries: []entry{
+				executable("evm-v1.0.0/evm-plugin", "binary"),
+				executable("evm-v1.0.0/evm-cli", "cli"),
+			},
+			binary:  "*/evm-plugin",
+			want:    "evm-v1.0.0/evm-plugin",
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "explicit path doesn't exist",
+			entries: []entry{executable("evm", "binary")},
+			binary:  "bin/evm",
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrBinaryNotFound)
+			},
+		},
+		{
+			name: "ambiguous",
+			entries: []entry{
+				executable("evm-cli", "cli"),
+				executable("evm-plugin", "binary"),
+			},
+			names: []string{"evm"},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrBinaryNotFound) &&
+					assert.ErrorContains(t, err, "evm-cli, evm-plugin")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "asset", build(t, Tar, test.entries), perms.ReadWrite))
+			require.NoError(t, Extract(fs, Tar, "asset", "out", Options{}))
+
+			got, err := FindBinary(fs, "out", test.binary, test.names...)
+			test.wantErr(t, err)
+			if test.want != "" {
+				require.Equal(t, filepath.Join("out", filepath.FromSlash(test.want)), got)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+var ErrBinaryNotFound = errors.New("binary not found in archive")
+
+// FindBinary returns the path of the binary in the archive extracted to dir.
+//
+// If binary is set, it's either the path of the binary inside the archive or
+// a glob pattern matching it. Otherwise the binary is the file whose name
+// matches one of names, or failing that the only executable in the archive.
+// Symlinks are never considered.
+func FindBinary(fs afero.Fs, dir string, binary string, names ...string) (string, error) {
+	files, err := regularFiles(fs, dir)
+	if err != nil {
+		return "", err
+	}
+
+	if binary != "" {
+		return findBinaryPath(dir, files, binary)
+	}
+
+	var named []string
+	for _, file := range files {
+		base := strings.ToLower(path.Base(file.name))
+		for _, name := range names {
+			if name == "" {
+				continue
+			}
+			if ok, _ := path.Match(strings.ToLower(name), base); ok {
+				named = append(named, file.name)
+				break
+			}
+		}
+	}
+	switch len(named) {
+	case 1:
+		return filepath.Join(dir, filepath.FromSlash(named[0])), nil
+	case 0:
+	default:
+		return "", fmt.Errorf("found several binaries named %v in archive: %s (use --binary to pick one)", names, strings.Join(named, ", "))
+	}
+
+	var executables []string
+	for _, file := range files {
+		if file.mode&0o111 != 0 {
+			executables = append(executables, file.name)
+		}
+	}
+	if len(executables) == 1 {
+		return filepath.Join(dir, filepath.FromSlash(executables[0])), nil
+	}
+	if len(executables) == 0 && len(files) == 1 {
+		return filepath.Join(dir, filepath.FromSlash(files[0].name)), nil
+	}
+
+	candidates := make([]string, 0, len(files))
+	for _, file := range files {
+		candidates = append(candidates, file.name)
+	}
+	return "", fmt.Errorf("%w: none of %s is named %v (use --binary to pick one)", ErrBinaryNotFound, strings.Join(candidates, ", "), names)
+}
+
+func findBinaryPath(dir string, files []file, binary string) (string, error) {
+	pattern := path.Clean(strings.TrimPrefix(filepath.ToSlash(binary), "/"))
+
+	var matches []string
+	for _, file := range files {
+		if ok, _ := path.Match(pattern, file.name); ok {
+			matches = append(matches, file.name)
+			continue
+		}
+		// Archives commonly wrap their contents in a versioned directory, so
+		// also match paths relative to it.
+		if i := strings.Index(file.name, "/"); i >= 0 {
+			if ok, _ := path.Match(pattern, file.name[i+1:]); ok {
+				matches = append(matches, file.name)
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 1:
+		return filepath.Join(dir, filepath.FromSlash(matches[0])), nil
+	case 0:
+		return "", fmt.Errorf("%w: no regular file matches %s", ErrBinaryNotFound, binary)
+	default:
+		return "", fmt.Errorf("%s matches several files in archive: %s", binary, strings.Join(matches, ", "))
+	}
+}
+
+type file struct {
+	name string // slash separated path relative to the archive root
+	mode fs.FileMode
+}
+
+// regularFiles returns the regular files under dir, sorted by name.
+func regularFiles(afs afero.Fs, dir string) ([]file, error) {
+	var files []file
+	err := afero.Walk(afs, dir, func(p string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, file{name: filepath.ToSlash(rel), mode: info.Mode()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].name < files[j].name
+	})
+	return files, nil
+}
@@ -0,0 +1,356 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+	"github.com/ulikunitz/xz"
+)
+
+// DefaultMaxSize is the default limit on the size of an extracted archive.
+const DefaultMaxSize = 2 << 30 // 2 GiB
+
+var (
+	ErrUnsafePath = errors.New("unsafe path in archive")
+	ErrTooLarge   = errors.New("archive is too large")
+)
+
+// Options configures how an archive is extracted.
+type Options struct {
+	// StripComponents is the number of leading path elements removed from
+	// every entry, like tar's --strip-components. Entries with no elements
+	// left are skipped.
+	StripComponents int
+	// MaxSize is the maximum number of bytes extracted. Zero means no limit.
+	MaxSize int64
+}
+
+// Extract extracts the archive at source into the directory dest.
+//
+// Entries that would be written outside of dest, either directly or through a
+// symlink, are rejected. Symlinks are only created if fs supports them and
+// only if their target stays inside of dest.
+func Extract(fs afero.Fs, format Format, source string, dest string, options Options) error {
+	if err := fs.MkdirAll(dest, perms.ReadWriteExecute); err != nil {
+		return err
+	}
+
+	f, err := fs.Open(source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	x := &extractor{
+		fs:              fs,
+		dest:            dest,
+		stripComponents: options.StripComponents,
+		maxSize:         options.MaxSize,
+	}
+	switch format {
+	case Tar:
+		return x.tar(f)
+	case TarGz:
+		r, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("failed to read gzip stream: %w", err)
+		}
+		defer r.Close()
+		return x.tar(r)
+	case TarXz:
+		r, err := xz.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("failed to read xz stream: %w", err)
+		}
+		return x.tar(r)
+	case TarZst:
+		r, err := zstd.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("failed to read zstd stream: %w", err)
+		}
+		defer r.Close()
+		return x.tar(r)
+	case Zip:
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		return x.zip(f, info.Size())
+	default:
+		return fmt.Errorf("%w %s", ErrUnknownFormat, format)
+	}
+}
+
+type extractor struct {
+	fs              afero.Fs
+	dest            string
+	stripComponents int
+	maxSize         int64
+	// written is the number of bytes extracted so far
+	written int64
+}
+
+func (x *extractor) tar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar archive: %w", err)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = x.dir(header.Name)
+		case tar.TypeReg:
+			err = x.file(header.Name, header.FileInfo().Mode(), tr)
+		case tar.TypeSymlink:
+			err = x.symlink(header.Name, header.Linkname)
+		case tar.TypeLink:
+			err = x.link(header.Name, header.Linkname)
+		default:
+			// Devices, fifos and the like have no place in a release.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (x *extractor) zip(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("failed to read zip archive: %w", err)
+	}
+
+	for _, file := range zr.File {
+		name := strings.ReplaceAll(file.Name, `\`, "/")
+		mode := file.Mode()
+
+		switch {
+		case mode.IsDir():
+			err = x.dir(name)
+		case mode&fs.ModeSymlink != 0:
+			err = x.zipSymlink(name, file)
+		case mode.IsRegular():
+			err = x.zipFile(name, file)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (x *extractor) zipFile(name string, file *zip.File) error {
+	r, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return x.file(name, file.Mode(), r)
+}
+
+func (x *extractor) zipSymlink(name string, file *zip.File) error {
+	r, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	target, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	return x.symlink(name, string(target))
+}
+
+func (x *extractor) dir(name string) error {
+	target, _, err := x.resolve(name)
+	if err != nil || target == "" {
+		return err
+	}
+
+	return x.fs.MkdirAll(target, perms.ReadWriteExecute)
+}
+
+func (x *extractor) file(name string, mode os.FileMode, r io.Reader) error {
+	target, _, err := x.resolve(name)
+	if err != nil || target == "" {
+		return err
+	}
+	if err := x.fs.MkdirAll(filepath.Dir(target), perms.ReadWriteExecute); err != nil {
+		return err
+	}
+
+	// Only keep the permission bits and make sure we can still clean up.
+	mode = mode.Perm() | 0o600
+	f, err := x.fs.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if x.maxSize > 0 {
+		r = io.LimitReader(r, x.maxSize-x.written+1)
+	}
+	n, err := io.Copy(f, r)
+	x.written += n
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+	if x.maxSize > 0 && x.written > x.maxSize {
+		return fmt.Errorf("%w: more than %d bytes", ErrTooLarge, x.maxSize)
+	}
+
+	// The mode passed to OpenFile is subject to the umask.
+	return x.fs.Chmod(target, mode)
+}
+
+func (x *extractor) symlink(name string, linkname string) error {
+	target, stripped, err := x.resolve(name)
+	if err != nil || target == "" {
+		return err
+	}
+	if err := checkLink(name, stripped, linkname); err != nil {
+		return err
+	}
+
+	linker, ok := x.fs.(afero.Linker)
+	if !ok {
+		// Binaries are never taken from symlinks, so there's no need to
+		// emulate them.
+		return nil
+	}
+
+	if err := x.fs.MkdirAll(filepath.Dir(target), perms.ReadWriteExecute); err != nil {
+		return err
+	}
+	return linker.SymlinkIfPossible(filepath.FromSlash(linkname), target)
+}
+
+func (x *extractor) link(name string, linkname string) error {
+	source, _, err := x.resolve(linkname)
+	if err != nil {
+		return err
+	}
+	if source == "" {
+		return fmt.Errorf("%w: %s links to %s which is stripped", ErrUnsafePath, name, linkname)
+	}
+
+	info, err := lstat(x.fs, source)
+	if err != nil {
+		return fmt.Errorf("%w: %s links to missing %s", ErrUnsafePath, name, linkname)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%w: %s links to %s which isn't a regular file", ErrUnsafePath, name, linkname)
+	}
+
+	f, err := x.fs.Open(source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Hard links are extracted as copies so the extracted tree works on
+	// every filesystem.
+	return x.file(name, info.Mode(), f)
+}
+
+// checkLink returns an error if the symlink called name, extracted to stripped,
+// pointing at linkname would point outside of the destination.
+func checkLink(name string, stripped string, linkname string) error {
+	if linkname == "" || path.IsAbs(linkname) || filepath.IsAbs(linkname) {
+		return fmt.Errorf("%w: %s links to absolute path %s", ErrUnsafePath, name, linkname)
+	}
+
+	resolved := path.Join(path.Dir(stripped), linkname)
+	if escapes(resolved) {
+		return fmt.Errorf("%w: %s links to %s outside of the archive", ErrUnsafePath, name, linkname)
+	}
+
+	return nil
+}
+
+// resolve returns where the entry called name is extracted to and its path
+// relative to the destination once leading components are stripped. An empty
+// target is returned if nothing is left of name. An error is returned if it's
+// outside of the destination or if it would be written through a symlink.
+func (x *extractor) resolve(name string) (string, string, error) {
+	if name == "" || path.IsAbs(name) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", "", fmt.Errorf("%w: %q is absolute", ErrUnsafePath, name)
+	}
+
+	cleaned := cleanName(name)
+	if escapes(cleaned) {
+		return "", "", fmt.Errorf("%w: %q is outside of the archive", ErrUnsafePath, name)
+	}
+
+	if x.stripComponents > 0 {
+		elements := strings.Split(cleaned, "/")
+		if len(elements) <= x.stripComponents {
+			return "", "", nil
+		}
+		cleaned = path.Join(elements[x.stripComponents:]...)
+	}
+
+	// Symlinks are only validated lexically, so never follow one that was
+	// extracted earlier.
+	target := x.dest
+	for _, element := range strings.Split(cleaned, "/") {
+		if element == "." {
+			continue
+		}
+
+		target = filepath.Join(target, element)
+		info, err := lstat(x.fs, target)
+		if err != nil {
+			break
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return "", "", fmt.Errorf("%w: %q is written through a symlink", ErrUnsafePath, name)
+		}
+	}
+
+	return filepath.Join(x.dest, filepath.FromSlash(cleaned)), cleaned, nil
+}
+
+func cleanName(name string) string {
+	return path.Clean(strings.ReplaceAll(name, `\`, "/"))
+}
+
+func escapes(cleaned string) bool {
+	return cleaned == ".." || strings.HasPrefix(cleaned, "../")
+}
+
+func lstat(fs afero.Fs, name string) (os.FileInfo, error) {
+	if lstater, ok := fs.(afero.Lstater); ok {
+		info, _, err := lstater.LstatIfPossible(name)
+		return info, err
+	}
+
+	return fs.Stat(name)
+}
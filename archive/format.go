@@ -0,0 +1,127 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// Format is an archive format.
+type Format string
+
+const (
+	Tar    Format = "tar"
+	TarGz  Format = "tar.gz"
+	TarXz  Format = "tar.xz"
+	TarZst Format = "tar.zst"
+	Zip    Format = "zip"
+)
+
+// Formats are all supported archive formats.
+var Formats = []Format{Tar, TarGz, TarXz, TarZst, Zip}
+
+var ErrUnknownFormat = errors.New("unknown archive format")
+
+// extensions maps file extensions to the format they're used for, longest
+// first so .tar.gz wins over .gz.
+var extensions = []struct {
+	extension string
+	format    Format
+}{
+	{".tar.gz", TarGz},
+	{".tar.xz", TarXz},
+	{".tar.zst", TarZst},
+	{".tgz", TarGz},
+	{".txz", TarXz},
+	{".tzst", TarZst},
+	{".tar", Tar},
+	{".zip", Zip},
+}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	xzMagic   = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	zipMagic  = []byte{'P', 'K', 0x03, 0x04}
+	tarMagic  = []byte("ustar")
+)
+
+const (
+	tarMagicOffset = 257
+	// headerSize is enough of a file to recognize every format.
+	headerSize = tarMagicOffset + 8
+)
+
+// ParseFormat returns the format called s.
+func ParseFormat(s string) (Format, error) {
+	for _, format := range Formats {
+		if string(format) == s {
+			return format, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w %s (must be one of %v)", ErrUnknownFormat, s, Formats)
+}
+
+// FromName returns the format of an archive from its file name.
+func FromName(name string) (Format, bool) {
+	name = strings.ToLower(name)
+	for _, e := range extensions {
+		if strings.HasSuffix(name, e.extension) {
+			return e.format, true
+		}
+	}
+
+	return "", false
+}
+
+// FromHeader returns the format of an archive from the first bytes of its
+// contents. Compressed streams are assumed to hold a tar archive.
+func FromHeader(header []byte) (Format, bool) {
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return TarGz, true
+	case bytes.HasPrefix(header, xzMagic):
+		return TarXz, true
+	case bytes.HasPrefix(header, zstdMagic):
+		return TarZst, true
+	case bytes.HasPrefix(header, zipMagic):
+		return Zip, true
+	case len(header) >= tarMagicOffset+len(tarMagic) &&
+		bytes.Equal(header[tarMagicOffset:tarMagicOffset+len(tarMagic)], tarMagic):
+		return Tar, true
+	default:
+		return "", false
+	}
+}
+
+// Detect returns the format of the archive at path from its name or, failing
+// that, its contents. Files that aren't archives are reported as such rather
+// than as an error.
+func Detect(fs afero.Fs, path string) (Format, bool, error) {
+	if format, ok := FromName(path); ok {
+		return format, true, nil
+	}
+
+	f, err := fs.Open(path)
+	if err != nil {
+		return "", false, err
+	}
+	defer f.Close()
+
+	header := make([]byte, headerSize)
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", false, err
+	}
+
+	format, ok := FromHeader(header[:n])
+	return format, ok, nil
+}
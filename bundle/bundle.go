@@ -0,0 +1,120 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package bundle reads and writes bundles, which package plugin repositories
+// and virtual machine artifacts into a single tarball so they can be carried
+// to hosts without network access.
+package bundle
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/luxfi/lpm/constant"
+	"github.com/luxfi/lpm/signature"
+)
+
+const (
+	// Version of the bundle format written by this version of lpm.
+	Version = 1
+
+	// ManifestFile describes the contents of a bundle.
+	ManifestFile = "bundle.yaml"
+	// RepositoriesDir holds a copy of every repository in a bundle, including
+	// its git history.
+	RepositoriesDir = "repositories"
+	// ArtifactsDir holds the artifacts in a bundle by their SHA256 checksum.
+	ArtifactsDir = "artifacts"
+)
+
+var ErrUnsupportedVersion = errors.New("unsupported bundle version")
+
+// Manifest describes the contents of a bundle.
+type Manifest struct {
+	Version      int          `yaml:"version" json:"version"`
+	CreatedAt    time.Time    `yaml:"created-at" json:"created-at"`
+	Repositories []Repository `yaml:"repositories" json:"repositories"`
+	Artifacts    []Artifact   `yaml:"artifacts" json:"artifacts"`
+}
+
+// Repository is a plugin repository in a bundle.
+type Repository struct {
+	Alias  string                 `yaml:"alias" json:"alias"`
+	URL    string                 `yaml:"url" json:"url"`
+	Branch plumbing.ReferenceName `yaml:"branch" json:"branch"`
+	Commit string                 `yaml:"commit" json:"commit"`
+}
+
+// Artifact is a virtual machine artifact in a bundle.
+type Artifact struct {
+	// VM is the fully qualified name of the virtual machine
+	VM       string `yaml:"vm" json:"vm"`
+	Version  string `yaml:"version,omitempty" json:"version,omitempty"`
+	Platform string `yaml:"platform" json:"platform"`
+	// URL the artifact was downloaded from
+	URL    string `yaml:"url" json:"url"`
+	SHA256 string `yaml:"sha256" json:"sha256"`
+	Size   int64  `yaml:"size" json:"size"`
+	// Signed is set if the artifact's detached signature is in the bundle
+	Signed bool `yaml:"signed,omitempty" json:"signed,omitempty"`
+}
+
+// RepositoryPath returns where the repository alias is in a bundle.
+func RepositoryPath(alias string) string {
+	return path.Join(RepositoriesDir, alias)
+}
+
+// ArtifactPath returns where the artifact with checksum sha256 is in a bundle.
+func ArtifactPath(sha256 string) string {
+	return path.Join(ArtifactsDir, sha256)
+}
+
+// SignaturePath returns where the signature of the artifact with checksum
+// sha256 is in a bundle.
+func SignaturePath(sha256 string) string {
+	return ArtifactPath(sha256) + signature.Extension
+}
+
+// Verify checks that m can be read by this version of lpm and only refers to
+// paths inside of the bundle.
+func (m Manifest) Verify() error {
+	if m.Version != Version {
+		return fmt.Errorf("%w %d (expected %d)", ErrUnsupportedVersion, m.Version, Version)
+	}
+
+	for _, repository := range m.Repositories {
+		if !validAlias(repository.Alias) {
+			return fmt.Errorf("bundle has a repository with invalid alias %q", repository.Alias)
+		}
+	}
+
+	for _, artifact := range m.Artifacts {
+		if b, err := hex.DecodeString(artifact.SHA256); err != nil || len(b) != 32 {
+			return fmt.Errorf("artifact %s in the bundle has an invalid checksum %q", artifact.VM, artifact.SHA256)
+		}
+	}
+
+	return nil
+}
+
+// validAlias returns whether alias is in the form of organization/repository,
+// which also makes it safe to use as a path.
+func validAlias(alias string) bool {
+	parts := strings.Split(alias, constant.AliasDelimiter)
+	if len(parts) != 2 {
+		return false
+	}
+
+	for _, part := range parts {
+		if part == "" || part == "." || part == ".." || strings.ContainsAny(part, `\:`) {
+			return false
+		}
+	}
+	return true
+}
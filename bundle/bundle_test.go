@@ -0,0 +1,125 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package bundle
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+const sha = "95adb23ed84246a173f85161b309601c3412e1407630c49a8fd871c25b3f0e9d"
+
+func TestRoundTrip(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "repo/vms/spacesvm.yaml", []byte("vm"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "repo/.git/HEAD", []byte("ref: refs/heads/main"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "artifact", []byte("archive"), 0o644))
+
+	manifest := Manifest{
+		Version:   Version,
+		CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Repositories: []Repository{
+			{
+				Alias:  "luxfi/plugins-core",
+				URL:    "https://github.com/luxfi/plugins-core",
+				Branch: plumbing.NewBranchReferenceName("main"),
+				Commit: "commit",
+			},
+		},
+		Artifacts: []Artifact{
+			{
+				VM:       "luxfi/plugins-core:spacesvm",
+				Version:  "1.0.0",
+				Platform: "linux/amd64",
+				URL:      "https://example.com/spacesvm.tar.gz",
+				SHA256:   sha,
+				Size:     7,
+			},
+		},
+	}
+
+	w, err := Create(fs, "bundle.tar.gz")
+	require.NoError(t, err)
+	require.NoError(t, w.WriteManifest(manifest))
+	require.NoError(t, w.AddDir("repo", RepositoryPath("luxfi/plugins-core")))
+	require.NoError(t, w.AddFile("artifact", ArtifactPath(sha)))
+	require.NoError(t, w.Close())
+
+	got, err := Open(fs, "bundle.tar.gz", "out")
+	require.NoError(t, err)
+	require.Equal(t, manifest, *got)
+
+	for path, want := range map[string]string{
+		filepath.Join("out", "repositories", "luxfi", "plugins-core", "vms", "spacesvm.yaml"): "vm",
+		filepath.Join("out", "repositories", "luxfi", "plugins-core", ".git", "HEAD"):         "ref: refs/heads/main",
+		filepath.Join("out", "artifacts", sha):                                                "archive",
+	} {
+		b, err := afero.ReadFile(fs, path)
+		require.NoError(t, err)
+		require.Equal(t, want, string(b))
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest *Manifest
+		wantErr  string
+	}{
+		{
+			name:    "missing manifest",
+			wantErr: "missing bundle.yaml",
+		},
+		{
+			name:     "unsupported version",
+			manifest: &Manifest{Version: Version + 1},
+			wantErr:  ErrUnsupportedVersion.Error(),
+		},
+		{
+			name: "unsafe repository alias",
+			manifest: &Manifest{
+				Version:      Version,
+				Repositories: []Repository{{Alias: "../.."}},
+			},
+			wantErr: "invalid alias",
+		},
+		{
+			name: "repository alias without organization",
+			manifest: &Manifest{
+				Version:      Version,
+				Repositories: []Repository{{Alias: "plugins-core"}},
+			},
+			wantErr: "invalid alias",
+		},
+		{
+			name: "invalid checksum",
+			manifest: &Manifest{
+				Version:   Version,
+				Artifacts: []Artifact{{VM: "vm", SHA256: strings.Repeat("../", 22)}},
+			},
+			wantErr: "invalid checksum",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			w, err := Create(fs, "bundle.tar.gz")
+			require.NoError(t, err)
+			if test.manifest != nil {
+				require.NoError(t, w.WriteManifest(*test.manifest))
+			}
+			require.NoError(t, w.Close())
+
+			_, err = Open(fs, "bundle.tar.gz", "out")
+			require.ErrorContains(t, err, test.wantErr)
+		})
+	}
+}
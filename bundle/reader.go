@@ -0,0 +1,42 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/luxfi/lpm/archive"
+)
+
+// Open extracts the bundle at path into the directory dest and returns its
+// manifest. Paths in the bundle are relative to dest.
+func Open(fs afero.Fs, path string, dest string) (*Manifest, error) {
+	if err := archive.Extract(fs, archive.TarGz, path, dest, archive.Options{}); err != nil {
+		return nil, fmt.Errorf("failed to extract bundle %s: %w", path, err)
+	}
+
+	b, err := afero.ReadFile(fs, filepath.Join(dest, ManifestFile))
+	if errors.Is(err, iofs.ErrNotExist) {
+		return nil, fmt.Errorf("%s is not a bundle, it's missing %s", path, ManifestFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	if err := yaml.Unmarshal(b, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ManifestFile, err)
+	}
+	if err := manifest.Verify(); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
@@ -0,0 +1,159 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// Writer writes a bundle as a gzipped tarball.
+type Writer struct {
+	fs   afero.Fs
+	file afero.File
+	gzip *gzip.Writer
+	tar  *tar.Writer
+}
+
+// Create creates a bundle at path.
+func Create(fs afero.Fs, path string) (*Writer, error) {
+	file, err := fs.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perms.ReadWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(file)
+	return &Writer{
+		fs:   fs,
+		file: file,
+		gzip: gz,
+		tar:  tar.NewWriter(gz),
+	}, nil
+}
+
+// WriteManifest writes the manifest describing the bundle.
+func (w *Writer) WriteManifest(manifest Manifest) error {
+	b, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	if err := w.tar.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     ManifestFile,
+		Mode:     int64(perms.ReadWrite),
+		Size:     int64(len(b)),
+		ModTime:  manifest.CreatedAt,
+	}); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w.tar, bytes.NewReader(b))
+	return err
+}
+
+// AddFile adds the file at src to the bundle as name.
+func (w *Writer) AddFile(src string, name string) error {
+	info, err := w.fs.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	return w.add(src, name, info)
+}
+
+// AddDir adds the directory at src and everything in it to the bundle as
+// name.
+func (w *Writer) AddDir(src string, name string) error {
+	return afero.Walk(w.fs, src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		return w.add(path, joinName(name, rel), info)
+	})
+}
+
+// Close finishes writing the bundle.
+func (w *Writer) Close() error {
+	if err := w.tar.Close(); err != nil {
+		_ = w.file.Close()
+		return err
+	}
+	if err := w.gzip.Close(); err != nil {
+		_ = w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+func (w *Writer) add(src string, name string, info os.FileInfo) error {
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		reader, ok := w.fs.(afero.LinkReader)
+		if !ok {
+			return fmt.Errorf("can't read symlink %s", src)
+		}
+
+		var err error
+		link, err = reader.ReadlinkIfPossible(src)
+		if err != nil {
+			return err
+		}
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	// Don't leak who created the bundle.
+	header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+	if header.ModTime.IsZero() {
+		header.ModTime = time.Now()
+	}
+
+	if err := w.tar.WriteHeader(header); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := w.fs.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w.tar, f)
+	return err
+}
+
+// joinName joins the bundle path name with the OS path rel.
+func joinName(name string, rel string) string {
+	if rel == "." {
+		return name
+	}
+	return path.Join(name, filepath.ToSlash(rel))
+}
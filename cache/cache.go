@@ -0,0 +1,375 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cache
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/luxfi/lpm/checksum"
+	"github.com/luxfi/lpm/signature"
+)
+
+const (
+	// DefaultMaxSize is the default limit on the total size of the cache.
+	DefaultMaxSize = 5 << 30 // 5 GiB
+	// DefaultMaxAge is how long an artifact is kept by default after it was
+	// last used.
+	DefaultMaxAge = 30 * 24 * time.Hour
+
+	metadataExtension = ".yaml"
+	tmpExtension      = ".tmp"
+)
+
+// Entry is an artifact in the cache.
+type Entry struct {
+	SHA256 string `yaml:"sha256" json:"sha256"`
+	// Name is where the artifact was downloaded from
+	Name     string    `yaml:"name" json:"name"`
+	Size     int64     `yaml:"size" json:"size"`
+	AddedAt  time.Time `yaml:"added-at" json:"added-at"`
+	LastUsed time.Time `yaml:"last-used" json:"last-used"`
+}
+
+// Policy decides which artifacts are evicted from the cache. Zero values
+// don't limit the cache.
+type Policy struct {
+	// MaxSize is the maximum total size of the cache in bytes. The least
+	// recently used artifacts are evicted first.
+	MaxSize int64
+	// MaxAge is how long an artifact is kept after it was last used.
+	MaxAge time.Duration
+}
+
+type Config struct {
+	Fs  afero.Fs
+	Dir string
+	// Policy is applied every time an artifact is added
+	Policy Policy
+}
+
+// Cache stores downloaded artifacts by their SHA256 checksum, so installing
+// the same artifact again doesn't download it again.
+type Cache struct {
+	fs          afero.Fs
+	dir         string
+	policy      Policy
+	checksummer checksum.Checksummer
+	now         func() time.Time
+}
+
+func New(config Config) *Cache {
+	return &Cache{
+		fs:          config.Fs,
+		dir:         config.Dir,
+		policy:      config.Policy,
+		checksummer: checksum.NewSHA256(config.Fs),
+		now:         time.Now,
+	}
+}
+
+// Fetch copies the artifact with checksum sha256 to dest. Returns false if it
+// isn't cached.
+func (c *Cache) Fetch(sha256 string, dest string) (bool, error) {
+	sha256 = strings.ToLower(sha256)
+	if !valid(sha256) {
+		return false, nil
+	}
+
+	entry, err := c.entry(sha256)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	blob := c.blobPath(sha256)
+	if hash := hex.EncodeToString(c.checksummer.Checksum(blob)); hash != sha256 {
+		// The artifact was modified or is missing, so it's useless.
+		return false, c.remove(sha256)
+	}
+
+	if err := c.fs.MkdirAll(filepath.Dir(dest), perms.ReadWriteExecute); err != nil {
+		return false, err
+	}
+	if err := c.copy(blob, dest); err != nil {
+		return false, err
+	}
+
+	entry.LastUsed = c.now()
+	return true, c.writeEntry(entry)
+}
+
+// Add copies the file at path into the cache and returns its entry. name
+// records where it came from.
+func (c *Cache) Add(path string, name string) (Entry, error) {
+	if err := c.fs.MkdirAll(c.dir, perms.ReadWriteExecute); err != nil {
+		return Entry{}, err
+	}
+
+	sha256 := hex.EncodeToString(c.checksummer.Checksum(path))
+	if !valid(sha256) {
+		return Entry{}, fmt.Errorf("failed to checksum %s", path)
+	}
+
+	now := c.now()
+	entry, err := c.entry(sha256)
+	switch {
+	case err == nil:
+		// Already cached.
+		entry.LastUsed = now
+		return entry, c.writeEntry(entry)
+	case !errors.Is(err, fs.ErrNotExist):
+		return Entry{}, err
+	}
+
+	info, err := c.fs.Stat(path)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	// Copy to a temporary file first, so a partial copy is never used.
+	tmp := c.blobPath(sha256) + tmpExtension
+	if err := c.copy(path, tmp); err != nil {
+		_ = c.fs.Remove(tmp)
+		return Entry{}, err
+	}
+	if err := c.fs.Rename(tmp, c.blobPath(sha256)); err != nil {
+		_ = c.fs.Remove(tmp)
+		return Entry{}, err
+	}
+
+	entry = Entry{
+		SHA256:   sha256,
+		Name:     name,
+		Size:     info.Size(),
+		AddedAt:  now,
+		LastUsed: now,
+	}
+	if err := c.writeEntry(entry); err != nil {
+		return Entry{}, err
+	}
+
+	if _, err := c.evict(c.policy, sha256); err != nil {
+		return Entry{}, err
+	}
+	return entry, nil
+}
+
+// AddSignature copies the detached signature at path into the cache, next to
+// the cached artifact with checksum sha256.
+func (c *Cache) AddSignature(sha256 string, path string) error {
+	sha256 = strings.ToLower(sha256)
+	if !valid(sha256) {
+		return fmt.Errorf("invalid checksum %q", sha256)
+	}
+	if _, err := c.entry(sha256); err != nil {
+		return fmt.Errorf("failed to find cached artifact %s: %w", sha256, err)
+	}
+
+	tmp := c.signaturePath(sha256) + tmpExtension
+	if err := c.copy(path, tmp); err != nil {
+		_ = c.fs.Remove(tmp)
+		return err
+	}
+	if err := c.fs.Rename(tmp, c.signaturePath(sha256)); err != nil {
+		_ = c.fs.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// FetchSignature copies the signature of the cached artifact with checksum
+// sha256 to dest. Returns false if it isn't cached.
+func (c *Cache) FetchSignature(sha256 string, dest string) (bool, error) {
+	sha256 = strings.ToLower(sha256)
+	if !valid(sha256) {
+		return false, nil
+	}
+
+	if _, err := c.fs.Stat(c.signaturePath(sha256)); errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if err := c.fs.MkdirAll(filepath.Dir(dest), perms.ReadWriteExecute); err != nil {
+		return false, err
+	}
+	if err := c.copy(c.signaturePath(sha256), dest); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// List returns the cached artifacts, most recently used first.
+func (c *Cache) List() ([]Entry, error) {
+	files, err := afero.ReadDir(c.fs, c.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(files))
+	for _, file := range files {
+		sha256, ok := strings.CutSuffix(file.Name(), metadataExtension)
+		if !ok || !valid(sha256) {
+			continue
+		}
+
+		entry, err := c.entry(sha256)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+// Prune evicts the artifacts that policy doesn't allow and returns them.
+func (c *Cache) Prune(policy Policy) ([]Entry, error) {
+	return c.evict(policy, "")
+}
+
+// Clear evicts every artifact and returns them.
+func (c *Cache) Clear() ([]Entry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	// Removing the whole directory also removes anything left behind by
+	// interrupted writes.
+	if err := c.fs.RemoveAll(c.dir); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// evict removes the artifacts policy doesn't allow, except for keep.
+func (c *Cache) evict(policy Policy, keep string) ([]Entry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		evicted []Entry
+		size    int64
+		now     = c.now()
+	)
+	// Entries are sorted by most recently used, so anything over the size
+	// limit is the least recently used.
+	for _, entry := range entries {
+		expired := policy.MaxAge > 0 && now.Sub(entry.LastUsed) > policy.MaxAge
+		full := policy.MaxSize > 0 && size+entry.Size > policy.MaxSize
+		if entry.SHA256 == keep || !(expired || full) {
+			size += entry.Size
+			continue
+		}
+
+		if err := c.remove(entry.SHA256); err != nil {
+			return evicted, err
+		}
+		evicted = append(evicted, entry)
+	}
+
+	return evicted, nil
+}
+
+func (c *Cache) entry(sha256 string) (Entry, error) {
+	b, err := afero.ReadFile(c.fs, c.metadataPath(sha256))
+	if err != nil {
+		return Entry{}, err
+	}
+
+	entry := Entry{}
+	if err := yaml.Unmarshal(b, &entry); err != nil {
+		return Entry{}, fmt.Errorf("failed to parse cache entry %s: %w", sha256, err)
+	}
+	if _, err := c.fs.Stat(c.blobPath(sha256)); err != nil {
+		return Entry{}, err
+	}
+	return entry, nil
+}
+
+func (c *Cache) writeEntry(entry Entry) error {
+	b, err := yaml.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return afero.WriteFile(c.fs, c.metadataPath(entry.SHA256), b, perms.ReadWrite)
+}
+
+func (c *Cache) remove(sha256 string) error {
+	for _, path := range []string{c.metadataPath(sha256), c.blobPath(sha256), c.signaturePath(sha256)} {
+		if err := c.fs.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Cache) copy(src string, dest string) error {
+	in, err := c.fs.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := c.fs.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perms.ReadWrite)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+func (c *Cache) blobPath(sha256 string) string {
+	return filepath.Join(c.dir, sha256)
+}
+
+func (c *Cache) signaturePath(sha256 string) string {
+	return filepath.Join(c.dir, sha256+signature.Extension)
+}
+
+func (c *Cache) metadataPath(sha256 string) string {
+	return filepath.Join(c.dir, sha256+metadataExtension)
+}
+
+// valid returns whether s is a hex encoded SHA256 checksum, which also makes
+// it safe to use as a file name.
+func valid(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
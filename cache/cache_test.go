@@ -0,0 +1,258 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cache
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// clock is a fake time source for cache entries.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newCache(t *testing.T, policy Policy) (*Cache, afero.Fs, *clock) {
+	fs := afero.NewMemMapFs()
+	c := New(Config{
+		Fs:     fs,
+		Dir:    "/cache",
+		Policy: policy,
+	})
+	clk := &clock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c.now = clk.Now
+	return c, fs, clk
+}
+
+// download writes content to path as if it was downloaded and returns its
+// checksum.
+func download(t *testing.T, fs afero.Fs, path string, content string) string {
+	require.NoError(t, afero.WriteFile(fs, path, []byte(content), 0o644))
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+}
+
+func TestFetch(t *testing.T) {
+	c, fs, clk := newCache(t, Policy{})
+	hash := download(t, fs, "/tmp/vm.tar.gz", "vm archive")
+
+	ok, err := c.Fetch(hash, "/tmp/fetched")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	added, err := c.Add("/tmp/vm.tar.gz", "https://example.com/vm.tar.gz")
+	require.NoError(t, err)
+	require.Equal(t, Entry{
+		SHA256:   hash,
+		Name:     "https://example.com/vm.tar.gz",
+		Size:     int64(len("vm archive")),
+		AddedAt:  clk.now,
+		LastUsed: clk.now,
+	}, added)
+
+	clk.now = clk.now.Add(time.Hour)
+	ok, err = c.Fetch(hash, "/tmp/fetched/vm.tar.gz")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	b, err := afero.ReadFile(fs, "/tmp/fetched/vm.tar.gz")
+	require.NoError(t, err)
+	require.Equal(t, "vm archive", string(b))
+
+	entries, err := c.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, clk.now, entries[0].LastUsed)
+	require.Equal(t, added.AddedAt, entries[0].AddedAt)
+}
+
+func TestFetchCorrupt(t *testing.T) {
+	c, fs, _ := newCache(t, Policy{})
+	hash := download(t, fs, "/tmp/vm.tar.gz", "vm archive")
+
+	_, err := c.Add("/tmp/vm.tar.gz", "vm")
+	require.NoError(t, err)
+	require.NoError(t, afero.WriteFile(fs, c.blobPath(hash), []byte("tampered"), 0o644))
+
+	ok, err := c.Fetch(hash, "/tmp/fetched")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	exists, err := afero.Exists(fs, "/tmp/fetched")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	entries, err := c.List()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestSignature(t *testing.T) {
+	c, fs, _ := newCache(t, Policy{})
+	hash := download(t, fs, "/tmp/vm.tar.gz", "vm archive")
+	require.NoError(t, afero.WriteFile(fs, "/tmp/vm.tar.gz.minisig", []byte("signature"), 0o644))
+
+	// Signatures are only kept for cached artifacts.
+	require.Error(t, c.AddSignature(hash, "/tmp/vm.tar.gz.minisig"))
+
+	_, err := c.Add("/tmp/vm.tar.gz", "vm")
+	require.NoError(t, err)
+
+	ok, err := c.FetchSignature(hash, "/tmp/fetched.minisig")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, c.AddSignature(hash, "/tmp/vm.tar.gz.minisig"))
+	ok, err = c.FetchSignature(hash, "/tmp/fetched.minisig")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	b, err := afero.ReadFile(fs, "/tmp/fetched.minisig")
+	require.NoError(t, err)
+	require.Equal(t, "signature", string(b))
+
+	// Evicting the artifact evicts its signature.
+	_, err = c.Prune(Policy{MaxSize: 1})
+	require.NoError(t, err)
+	ok, err = c.FetchSignature(hash, "/tmp/fetched.minisig")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestFetchInvalidChecksum(t *testing.T) {
+	c, _, _ := newCache(t, Policy{})
+
+	for _, sha := range []string{"", "../../etc/passwd", "zz"} {
+		ok, err := c.Fetch(sha, "/tmp/fetched")
+		require.NoError(t, err)
+		require.False(t, ok)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		// want are the contents left in the cache, most recently used first
+		want []string
+	}{
+		{
+			name: "no limits",
+			want: []string{"c", "bb", "aaa"},
+		},
+		{
+			name:   "max age",
+			policy: Policy{MaxAge: 90 * time.Minute},
+			want:   []string{"c", "bb"},
+		},
+		{
+			name:   "max size evicts least recently used",
+			policy: Policy{MaxSize: 3},
+			want:   []string{"c", "bb"},
+		},
+		{
+			name:   "max age and size",
+			policy: Policy{MaxSize: 1, MaxAge: 90 * time.Minute},
+			want:   []string{"c"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, fs, clk := newCache(t, Policy{})
+			start := clk.now
+			// Added an hour apart, so aaa is the oldest.
+			for _, content := range []string{"aaa", "bb", "c"} {
+				download(t, fs, "/tmp/artifact", content)
+				_, err := c.Add("/tmp/artifact", content)
+				require.NoError(t, err)
+				clk.now = clk.now.Add(time.Hour)
+			}
+			clk.now = start.Add(2 * time.Hour)
+
+			_, err := c.Prune(test.policy)
+			require.NoError(t, err)
+
+			entries, err := c.List()
+			require.NoError(t, err)
+			got := make([]string, 0, len(entries))
+			for _, entry := range entries {
+				got = append(got, entry.Name)
+			}
+			require.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestAddEvicts(t *testing.T) {
+	c, fs, clk := newCache(t, Policy{MaxSize: 4})
+
+	for _, content := range []string{"aaa", "bbbb"} {
+		download(t, fs, "/tmp/artifact", content)
+		_, err := c.Add("/tmp/artifact", content)
+		require.NoError(t, err)
+		clk.now = clk.now.Add(time.Hour)
+	}
+
+	// The new artifact is kept even though it doesn't fit with anything else.
+	entries, err := c.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "bbbb", entries[0].Name)
+}
+
+func TestClear(t *testing.T) {
+	c, fs, _ := newCache(t, Policy{})
+	download(t, fs, "/tmp/artifact", "artifact")
+	_, err := c.Add("/tmp/artifact", "artifact")
+	require.NoError(t, err)
+
+	cleared, err := c.Clear()
+	require.NoError(t, err)
+	require.Len(t, cleared, 1)
+
+	entries, err := c.List()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size    string
+		want    int64
+		wantErr bool
+	}{
+		{size: "0", want: 0},
+		{size: "1024", want: 1024},
+		{size: "500MB", want: 500_000_000},
+		{size: "5GiB", want: 5 << 30},
+		{size: "1.5 kib", want: 1536},
+		{size: "5.0 GiB", want: 5 << 30},
+		{size: "-1GB", wantErr: true},
+		{size: "lots", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.size, func(t *testing.T) {
+			got, err := ParseSize(test.size)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, got)
+		})
+	}
+
+	require.Equal(t, "5.0 GiB", FormatSize(DefaultMaxSize))
+	require.Equal(t, "512 B", FormatSize(512))
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var units = []struct {
+	suffix string
+	bytes  int64
+}{
+	// Longest suffixes first so "MiB" isn't parsed as "B".
+	{"KiB", 1 << 10},
+	{"MiB", 1 << 20},
+	{"GiB", 1 << 30},
+	{"TiB", 1 << 40},
+	{"KB", 1e3},
+	{"MB", 1e6},
+	{"GB", 1e9},
+	{"TB", 1e12},
+	{"B", 1},
+}
+
+// ParseSize parses a size like 500MB or 5GiB into bytes. Sizes without a unit
+// are in bytes.
+func ParseSize(s string) (int64, error) {
+	number := strings.TrimSpace(s)
+	multiplier := int64(1)
+	for _, unit := range units {
+		if n, ok := cutSuffixFold(number, unit.suffix); ok {
+			number = strings.TrimSpace(n)
+			multiplier = unit.bytes
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// FormatSize formats bytes for humans.
+func FormatSize(bytes int64) string {
+	// The binary units are listed first, from KiB to TiB.
+	for i := 3; i >= 0; i-- {
+		if bytes >= units[i].bytes {
+			return fmt.Sprintf("%.1f %s", float64(bytes)/float64(units[i].bytes), units[i].suffix)
+		}
+	}
+	return fmt.Sprintf("%d B", bytes)
+}
+
+func cutSuffixFold(s string, suffix string) (string, bool) {
+	if len(s) < len(suffix) || !strings.EqualFold(s[len(s)-len(suffix):], suffix) {
+		return s, false
+	}
+	return s[:len(s)-len(suffix)], true
+}
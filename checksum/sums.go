@@ -0,0 +1,74 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package checksum
+
+import (
+	"encoding/hex"
+	"path"
+	"strings"
+)
+
+const sha256HexSize = 64
+
+// Sums are SHA256 checksums keyed by file name, as published in a release's
+// checksums file.
+type Sums map[string]string
+
+// ParseSums parses a checksums file. Both the format written by sha256sum
+// ("<hash>  <file>") and the BSD format ("SHA256 (<file>) = <hash>") are
+// supported. A file that only contains a hash, like the <file>.sha256 files
+// some releases publish, is recorded under the empty name. Lines that aren't
+// SHA256 checksums are skipped.
+func ParseSums(b []byte) Sums {
+	sums := make(Sums)
+
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if rest, ok := strings.CutPrefix(line, "SHA256 ("); ok {
+			name, hash, ok := strings.Cut(rest, ") = ")
+			if ok && validHash(hash) {
+				sums[normalize(name)] = strings.ToLower(hash)
+			}
+			continue
+		}
+
+		hash, name, _ := strings.Cut(line, " ")
+		if !validHash(hash) {
+			continue
+		}
+
+		// sha256sum marks files hashed in binary mode with a leading '*'.
+		name = strings.TrimPrefix(strings.TrimSpace(name), "*")
+		sums[normalize(name)] = strings.ToLower(hash)
+	}
+
+	return sums
+}
+
+// Lookup returns the checksum of the file called name.
+func (s Sums) Lookup(name string) (string, bool) {
+	hash, ok := s[normalize(name)]
+	return hash, ok
+}
+
+func normalize(name string) string {
+	if name == "" {
+		return ""
+	}
+
+	return path.Base(strings.ReplaceAll(name, "\\", "/"))
+}
+
+func validHash(hash string) bool {
+	if len(hash) != sha256HexSize {
+		return false
+	}
+
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
@@ -0,0 +1,57 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package checksum
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSums(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	other := strings.Repeat("CD", 32)
+
+	tests := []struct {
+		name string
+		file string
+		want Sums
+	}{
+		{
+			name: "sha256sum",
+			file: hash + "  evm-linux-amd64\n" + other + " *evm-darwin-arm64\n",
+			want: Sums{
+				"evm-linux-amd64":  hash,
+				"evm-darwin-arm64": strings.ToLower(other),
+			},
+		},
+		{
+			name: "paths",
+			file: hash + "  ./dist/evm-linux-amd64\n",
+			want: Sums{"evm-linux-amd64": hash},
+		},
+		{
+			name: "bsd",
+			file: "SHA256 (evm-linux-amd64) = " + hash + "\n",
+			want: Sums{"evm-linux-amd64": hash},
+		},
+		{
+			name: "bare hash",
+			file: hash + "\n",
+			want: Sums{"": hash},
+		},
+		{
+			name: "skips comments and other hashes",
+			file: "# checksums\n\n" + strings.Repeat("ab", 64) + "  evm-linux-amd64\nSHA512 (evm) = abc\n" + hash + "  evm\n",
+			want: Sums{"evm": hash},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.want, ParseSums([]byte(test.file)))
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func bundle(fs afero.Fs) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "bundle",
+		Short: "Moves repositories and virtual machines to hosts without network access.",
+	}
+
+	command.AddCommand(
+		exportBundle(fs),
+		importBundle(fs),
+	)
+
+	return command
+}
+
+func exportBundle(fs afero.Fs) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "export <path>",
+		Short: "Packages the tracked repositories and virtual machine artifacts into a bundle.",
+		Long: "Packages the tracked repositories and the artifacts of the virtual machines given with --vm into a " +
+			"bundle. Without --vm, the virtual machines installed from repositories are exported at their installed " +
+			"versions. Artifacts are exported for --platform.",
+		Args: cobra.ExactArgs(1),
+	}
+
+	vms := command.Flags().StringSlice("vm", nil, "virtual machine to export, optionally followed by @ and a version constraint")
+
+	command.RunE = func(_ *cobra.Command, args []string) error {
+		lpm, err := initLPM(fs)
+		if err != nil {
+			return err
+		}
+
+		return lpm.ExportBundle(args[0], *vms)
+	}
+
+	return command
+}
+
+func importBundle(fs afero.Fs) *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <path>",
+		Short: "Loads the repositories and virtual machine artifacts in a bundle.",
+		Long: "Loads the repositories and virtual machine artifacts in a bundle, replacing the tracked repositories " +
+			"it contains. Importing never touches the network. Install virtual machines from the bundle with --offline.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			// A host being set up from a bundle can't bootstrap over the
+			// network.
+			viper.Set(offlineKey, true)
+
+			lpm, err := initLPM(fs)
+			if err != nil {
+				return err
+			}
+
+			return lpm.ImportBundle(args[0])
+		},
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/luxfi/lpm/cache"
+	"github.com/luxfi/lpm/lpm"
+)
+
+func cacheCmd(fs afero.Fs) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "cache",
+		Short: "Manages the cache of downloaded virtual machine artifacts.",
+	}
+
+	command.AddCommand(
+		listCache(fs),
+		pruneCache(fs),
+		clearCache(fs),
+	)
+
+	return command
+}
+
+func listCache(fs afero.Fs) *cobra.Command {
+	format := ""
+	command := &cobra.Command{
+		Use:   "list",
+		Short: "Lists the cached artifacts, most recently used first.",
+		Args:  cobra.NoArgs,
+	}
+	addOutputFlag(command, &format)
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		outputFormat, err := lpm.ParseOutputFormat(format)
+		if err != nil {
+			return err
+		}
+
+		lpm, err := initLPM(fs)
+		if err != nil {
+			return err
+		}
+
+		return lpm.ListCache(outputFormat)
+	}
+
+	return command
+}
+
+func pruneCache(fs afero.Fs) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "prune",
+		Short: "Evicts cached artifacts that are too old or don't fit in the cache.",
+		Long: "Evicts cached artifacts that haven't been used for longer than --max-age, then the least recently " +
+			"used artifacts until the cache fits in --max-size. Both default to --cache-max-age and --cache-max-size.",
+		Args: cobra.NoArgs,
+	}
+
+	maxSize := ""
+	maxAge := command.Flags().Duration("max-age", 0, "evict artifacts that haven't been used for this long")
+	command.Flags().StringVar(&maxSize, "max-size", "", "evict the least recently used artifacts until the cache fits in this size (e.g. 500MB or 5GiB)")
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		policy, err := initCachePolicy()
+		if err != nil {
+			return err
+		}
+		if *maxAge != 0 {
+			policy.MaxAge = *maxAge
+		}
+		if maxSize != "" {
+			policy.MaxSize, err = cache.ParseSize(maxSize)
+			if err != nil {
+				return err
+			}
+		}
+
+		lpm, err := initLPM(fs)
+		if err != nil {
+			return err
+		}
+
+		return lpm.PruneCache(policy)
+	}
+
+	return command
+}
+
+func clearCache(fs afero.Fs) *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear",
+		Short: "Evicts every cached artifact.",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			lpm, err := initLPM(fs)
+			if err != nil {
+				return err
+			}
+
+			return lpm.ClearCache()
+		},
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/luxfi/lpm/lpm"
+)
+
+func info(fs afero.Fs) *cobra.Command {
+	format := ""
+	command := &cobra.Command{
+		Use:   "info <alias>",
+		Short: "Shows the definition of a virtual machine or chain and whether it's installed.",
+		Args:  cobra.ExactArgs(1),
+	}
+	addOutputFlag(command, &format)
+
+	command.RunE = func(_ *cobra.Command, args []string) error {
+		outputFormat, err := lpm.ParseOutputFormat(format)
+		if err != nil {
+			return err
+		}
+
+		lpm, err := initLPM(fs)
+		if err != nil {
+			return err
+		}
+
+		return lpm.Info(args[0], outputFormat)
+	}
+
+	return command
+}
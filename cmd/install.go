@@ -9,24 +9,31 @@ import (
 )
 
 func install(fs afero.Fs) *cobra.Command {
+	dryRun := false
 	vm := ""
 	command := &cobra.Command{
 		Use:   "install-vm",
 		Short: "Installs a virtual machine by its alias",
 	}
-	command.PersistentFlags().StringVar(&vm, "vm", "", "vm alias to install")
+	command.PersistentFlags().StringVar(&vm, "vm", "", "vm alias to install, optionally with a version constraint (e.g spacesvm@1.2.3 or spacesvm@^1.2)")
 	err := command.MarkPersistentFlagRequired("vm")
 	if err != nil {
 		panic(err)
 	}
 
+	addDryRunFlag(command, &dryRun)
+
 	command.RunE = func(_ *cobra.Command, _ []string) error {
-		lpm, err := initLPM(fs)
+		lpm, err := initPlannedLPM(fs, dryRun)
 		if err != nil {
 			return err
 		}
 
-		return lpm.Install(vm)
+		if err := lpm.Install(vm); err != nil {
+			return err
+		}
+
+		return lpm.PrintPlan()
 	}
 
 	return command
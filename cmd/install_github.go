@@ -5,12 +5,10 @@ package cmd
 
 import (
 	"fmt"
-	"runtime"
 	"strings"
 
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/luxfi/lpm/workflow"
 )
@@ -20,6 +18,10 @@ func installGithub(fs afero.Fs) *cobra.Command {
 		vmid    string
 		tag     string
 		pattern string
+		binary  string
+
+		requireChecksum bool
+		dryRun          bool
 	)
 
 	cmd := &cobra.Command{
@@ -27,7 +29,7 @@ func installGithub(fs afero.Fs) *cobra.Command {
 		Short: "Install a VM plugin binary from a GitHub release",
 		Long: `Download and install a pre-compiled VM plugin binary from GitHub releases.
 
-Automatically detects the correct binary for your platform (OS/arch).
+Automatically detects the correct binary for your platform (OS/arch), or the one set with --platform.
 
 Examples:
   # Install latest release, auto-detect binary
@@ -40,7 +42,14 @@ Examples:
   lpm install-github luxfi/evm --vmid mgj786NP7uDwBCcq6YwThhaN8FLyybkCa4zBWTQbNgmK6k9A6
 
   # Custom binary name pattern
-  lpm install-github myorg/myvm --pattern "myvm-plugin-{os}-{arch}"`,
+  lpm install-github myorg/myvm --pattern "myvm-plugin-{os}-{arch}"
+
+  # Pick the binary out of an archive asset
+  lpm install-github myorg/myvm --binary "myvm-*/bin/myvm"
+
+The binary is verified against the checksums file (e.g checksums.txt or <binary>.sha256)
+published in the same release, if there is one. Archive assets (.tar, .tar.gz, .tar.xz,
+.tar.zst and .zip) are extracted and the binary named after the repository or the VMID is installed.`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(_ *cobra.Command, args []string) error {
 			repo := args[0]
@@ -49,27 +58,42 @@ Examples:
 				return fmt.Errorf("invalid repo: %s (expected owner/repo)", repo)
 			}
 
-			pluginDir := viper.GetString(pluginPathKey)
-
-			wf := workflow.NewInstallGitHub(workflow.InstallGitHubConfig{
-				Owner:     parts[0],
-				Repo:      parts[1],
-				Tag:       tag,
-				VMID:      vmid,
-				Pattern:   pattern,
-				OS:        runtime.GOOS,
-				Arch:      runtime.GOARCH,
-				PluginDir: pluginDir,
-				Fs:        fs,
-			})
-
-			return wf.Execute()
+			platform, err := initPlatform()
+			if err != nil {
+				return err
+			}
+
+			lpm, err := initPlannedLPM(fs, dryRun)
+			if err != nil {
+				return err
+			}
+
+			if err := lpm.InstallGitHub(workflow.InstallGitHubConfig{
+				Owner:   parts[0],
+				Repo:    parts[1],
+				Tag:     tag,
+				VMID:    vmid,
+				Pattern: pattern,
+				Binary:  binary,
+				OS:      platform.OS,
+				Arch:    platform.Arch,
+
+				RequireChecksum: requireChecksum,
+			}); err != nil {
+				return err
+			}
+
+			return lpm.PrintPlan()
 		},
 	}
 
 	cmd.Flags().StringVar(&vmid, "vmid", "", "VM ID (auto-detected from repo name if not set)")
 	cmd.Flags().StringVar(&tag, "tag", "", "Release tag (default: latest)")
 	cmd.Flags().StringVar(&pattern, "pattern", "", "Binary name pattern (default: auto-detect)")
+	cmd.Flags().StringVar(&binary, "binary", "", "Path or glob pattern of the binary inside of an archive asset (default: auto-detect)")
+	cmd.Flags().BoolVar(&requireChecksum, "require-checksum", false, "Fail if the release doesn't publish a checksum of the binary")
+
+	addDryRunFlag(cmd, &dryRun)
 
 	return cmd
 }
@@ -5,12 +5,10 @@ package cmd
 
 import (
 	"fmt"
-	"runtime"
 	"strings"
 
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/luxfi/lpm/workflow"
 )
@@ -21,6 +19,7 @@ func installSource(fs afero.Fs) *cobra.Command {
 		tag    string
 		script string
 		binary string
+		dryRun bool
 	)
 
 	cmd := &cobra.Command{
@@ -47,22 +46,30 @@ Examples:
 				return fmt.Errorf("invalid repo: %s (expected owner/repo)", repo)
 			}
 
-			pluginDir := viper.GetString(pluginPathKey)
+			platform, err := initPlatform()
+			if err != nil {
+				return err
+			}
+
+			lpm, err := initPlannedLPM(fs, dryRun)
+			if err != nil {
+				return err
+			}
 
-			wf := workflow.NewInstallSource(workflow.InstallSourceConfig{
+			if err := lpm.InstallSource(workflow.InstallSourceConfig{
 				Owner:       parts[0],
 				Repo:        parts[1],
 				Tag:         tag,
 				VMID:        vmid,
 				BuildScript: script,
 				BinaryPath:  binary,
-				OS:          runtime.GOOS,
-				Arch:        runtime.GOARCH,
-				PluginDir:   pluginDir,
-				Fs:          fs,
-			})
+				OS:          platform.OS,
+				Arch:        platform.Arch,
+			}); err != nil {
+				return err
+			}
 
-			return wf.Execute()
+			return lpm.PrintPlan()
 		},
 	}
 
@@ -71,5 +78,7 @@ Examples:
 	cmd.Flags().StringVar(&script, "script", "", "Build script/command (default: auto-detect)")
 	cmd.Flags().StringVar(&binary, "binary", "", "Path to built binary relative to repo root")
 
+	addDryRunFlag(cmd, &dryRun)
+
 	return cmd
 }
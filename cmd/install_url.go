@@ -8,15 +8,17 @@ import (
 
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/luxfi/lpm/workflow"
 )
 
 func installURL(fs afero.Fs) *cobra.Command {
 	var (
-		vmid   string
-		sha256 string
+		vmid      string
+		sha256    string
+		signature string
+		binary    string
+		dryRun    bool
 	)
 
 	cmd := &cobra.Command{
@@ -24,7 +26,9 @@ func installURL(fs afero.Fs) *cobra.Command {
 		Short: "Install a VM plugin binary from a direct URL",
 		Long: `Download and install a pre-compiled VM plugin binary from any URL.
 
-The URL should point to a raw executable binary (not an archive).
+The URL can point to a raw executable binary or to an archive (.tar, .tar.gz, .tar.xz, .tar.zst
+or .zip) containing it. The binary is found in the archive by the VMID, by --binary, or as the
+only executable in it.
 
 Examples:
   # Install binary with explicit VMID
@@ -34,30 +38,49 @@ Examples:
   # Install with SHA256 verification
   lpm install-url https://example.com/myvm-linux-amd64 \
     --vmid rWhpmtaWqaYAPFMUa4gJSXrGATiuGQVje51kkAFag1sQqK3Kn \
-    --sha256 abc123...`,
+    --sha256 abc123...
+
+  # Install with a detached minisign signature
+  lpm install-url https://example.com/myvm-linux-amd64 \
+    --vmid rWhpmtaWqaYAPFMUa4gJSXrGATiuGQVje51kkAFag1sQqK3Kn \
+    --signature https://example.com/myvm-linux-amd64.minisig
+
+  # Install the binary from a release archive
+  lpm install-url https://example.com/myvm-v1.0.0-linux-amd64.tar.gz \
+    --vmid rWhpmtaWqaYAPFMUa4gJSXrGATiuGQVje51kkAFag1sQqK3Kn \
+    --binary myvm`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(_ *cobra.Command, args []string) error {
 			if vmid == "" {
 				return fmt.Errorf("--vmid is required for direct URL installs")
 			}
 
-			pluginDir := viper.GetString(pluginPathKey)
+			lpm, err := initPlannedLPM(fs, dryRun)
+			if err != nil {
+				return err
+			}
 
-			wf := workflow.NewInstallURL(workflow.InstallURLConfig{
+			if err := lpm.InstallURL(workflow.InstallURLConfig{
 				URL:       args[0],
 				VMID:      vmid,
 				SHA256:    sha256,
-				PluginDir: pluginDir,
-				Fs:        fs,
-			})
+				Signature: signature,
+				Binary:    binary,
+			}); err != nil {
+				return err
+			}
 
-			return wf.Execute()
+			return lpm.PrintPlan()
 		},
 	}
 
 	cmd.Flags().StringVar(&vmid, "vmid", "", "VM ID (required)")
 	cmd.Flags().StringVar(&sha256, "sha256", "", "Expected SHA256 checksum (optional)")
+	cmd.Flags().StringVar(&signature, "signature", "", "URL of a detached minisign signature of the binary")
+	cmd.Flags().StringVar(&binary, "binary", "", "Path or glob pattern of the binary inside of an archive (default: auto-detect)")
 	_ = cmd.MarkFlagRequired("vmid")
 
+	addDryRunFlag(cmd, &dryRun)
+
 	return cmd
 }
@@ -9,6 +9,8 @@ import (
 )
 
 func joinChain(fs afero.Fs) *cobra.Command {
+	dryRun := false
+	noTrack := false
 	chain := ""
 
 	command := &cobra.Command{
@@ -22,13 +24,20 @@ func joinChain(fs afero.Fs) *cobra.Command {
 		panic(err)
 	}
 
+	command.Flags().BoolVar(&noTrack, "no-track", false, "join the chain without adding it to the node's tracked chains")
+	addDryRunFlag(command, &dryRun)
+
 	command.RunE = func(_ *cobra.Command, _ []string) error {
-		lpm, err := initLPM(fs)
+		lpm, err := initPlannedLPM(fs, dryRun)
 		if err != nil {
 			return err
 		}
 
-		return lpm.JoinChain(chain)
+		if err := lpm.JoinChain(chain, noTrack); err != nil {
+			return err
+		}
+
+		return lpm.PrintPlan()
 	}
 
 	return command
@@ -0,0 +1,93 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/luxfi/lpm/lpm"
+)
+
+func key(fs afero.Fs) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "key",
+		Short: "Manages the public keys trusted to sign virtual machines.",
+	}
+
+	command.AddCommand(
+		addKey(fs),
+		listKeys(fs),
+		removeKey(fs),
+	)
+
+	return command
+}
+
+func addKey(fs afero.Fs) *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <name> <public key | path>",
+		Short: "Trusts a minisign public key to sign virtual machines.",
+		Long: "Trusts a minisign public key to sign virtual machines. The key can either be given as the base64 " +
+			"encoded key or as the path to a minisign .pub file.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			publicKey := []byte(args[1])
+			if b, err := os.ReadFile(args[1]); err == nil {
+				publicKey = b
+			}
+
+			lpm, err := initLPM(fs)
+			if err != nil {
+				return err
+			}
+
+			return lpm.AddKey(args[0], publicKey)
+		},
+	}
+}
+
+func listKeys(fs afero.Fs) *cobra.Command {
+	format := ""
+	command := &cobra.Command{
+		Use:   "list",
+		Short: "Lists the public keys trusted to sign virtual machines.",
+		Args:  cobra.NoArgs,
+	}
+	addOutputFlag(command, &format)
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		outputFormat, err := lpm.ParseOutputFormat(format)
+		if err != nil {
+			return err
+		}
+
+		lpm, err := initLPM(fs)
+		if err != nil {
+			return err
+		}
+
+		return lpm.ListKeys(outputFormat)
+	}
+
+	return command
+}
+
+func removeKey(fs afero.Fs) *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Stops trusting a public key.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			lpm, err := initLPM(fs)
+			if err != nil {
+				return err
+			}
+
+			return lpm.RemoveKey(args[0])
+		},
+	}
+}
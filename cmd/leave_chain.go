@@ -0,0 +1,42 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func leaveChain(fs afero.Fs) *cobra.Command {
+	dryRun := false
+	chain := ""
+
+	command := &cobra.Command{
+		Use:   "leave-chain",
+		Short: "Stops tracking a chain and uninstalls the virtual machines no other joined chain needs.",
+	}
+
+	command.PersistentFlags().StringVar(&chain, "chain", "", "chain alias to leave")
+	err := command.MarkPersistentFlagRequired("chain")
+	if err != nil {
+		panic(err)
+	}
+
+	addDryRunFlag(command, &dryRun)
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		lpm, err := initPlannedLPM(fs, dryRun)
+		if err != nil {
+			return err
+		}
+
+		if err := lpm.LeaveChain(chain); err != nil {
+			return err
+		}
+
+		return lpm.PrintPlan()
+	}
+
+	return command
+}
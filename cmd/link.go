@@ -11,7 +11,6 @@ import (
 
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/luxfi/lpm/workflow"
 )
@@ -67,19 +66,17 @@ Examples:
 				version = "v0.0.0-local"
 			}
 
-			// Get plugin directory
-			pluginDir := viper.GetString(pluginPathKey)
+			lpm, err := initLPM(fs)
+			if err != nil {
+				return err
+			}
 
-			linkWorkflow := workflow.NewLink(workflow.LinkConfig{
+			return lpm.Link(workflow.LinkConfig{
 				Org:        org,
 				Name:       name,
 				Version:    version,
 				BinaryPath: absPath,
-				PluginDir:  pluginDir,
-				Fs:         fs,
 			})
-
-			return linkWorkflow.Execute()
 		},
 	}
 
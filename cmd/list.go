@@ -0,0 +1,36 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/luxfi/lpm/lpm"
+)
+
+func list(fs afero.Fs) *cobra.Command {
+	format := ""
+	command := &cobra.Command{
+		Use:   "list",
+		Short: "Lists installed virtual machines and any other binaries in the plugin directory.",
+	}
+	addOutputFlag(command, &format)
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		outputFormat, err := lpm.ParseOutputFormat(format)
+		if err != nil {
+			return err
+		}
+
+		lpm, err := initLPM(fs)
+		if err != nil {
+			return err
+		}
+
+		return lpm.List(outputFormat)
+	}
+
+	return command
+}
@@ -0,0 +1,31 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/luxfi/lpm/manifest"
+)
+
+func lock(fs afero.Fs) *cobra.Command {
+	file := ""
+	command := &cobra.Command{
+		Use:   "lock",
+		Short: "Writes a lockfile pinning every installed virtual machine.",
+	}
+	command.Flags().StringVar(&file, "file", manifest.DefaultFile, "path to the lockfile")
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		lpm, err := initLPM(fs)
+		if err != nil {
+			return err
+		}
+
+		return lpm.WriteLockfile(file)
+	}
+
+	return command
+}
@@ -0,0 +1,33 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func rollback(fs afero.Fs) *cobra.Command {
+	to := ""
+	command := &cobra.Command{
+		Use:   "rollback <vm>",
+		Short: "Restores a virtual machine binary replaced by an upgrade",
+		Long: "Restores a virtual machine binary kept when it was upgraded and " +
+			"tells the node to reload its virtual machines. Rolls back to the " +
+			"most recently replaced binary unless --to is given.",
+		Args: cobra.ExactArgs(1),
+	}
+	command.Flags().StringVar(&to, "to", "", "version or commit to roll back to")
+
+	command.RunE = func(_ *cobra.Command, args []string) error {
+		lpm, err := initLPM(fs)
+		if err != nil {
+			return err
+		}
+
+		return lpm.Rollback(args[0], to)
+	}
+
+	return command
+}
@@ -15,9 +15,13 @@ import (
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 
+	"github.com/luxfi/lpm/cache"
 	"github.com/luxfi/lpm/config"
 	"github.com/luxfi/lpm/constant"
 	"github.com/luxfi/lpm/lpm"
+	"github.com/luxfi/lpm/node"
+	"github.com/luxfi/lpm/types"
+	"github.com/luxfi/lpm/url"
 )
 
 var (
@@ -38,6 +42,23 @@ const (
 	pluginPathKey       = "plugin-path"
 	credentialsFileKey  = "credentials-file"
 	adminAPIEndpointKey = "admin-api-endpoint"
+	repoPriorityKey     = "repository-priority"
+	platformKey         = "platform"
+	keysPathKey         = "keys-path"
+	allowUnsignedKey    = "allow-unsigned"
+	downloadTimeoutKey  = "download-timeout"
+	downloadRetriesKey  = "download-retries"
+	downloadBackoffKey  = "download-backoff"
+	proxyKey            = "proxy"
+	caFileKey           = "ca-file"
+	cachePathKey        = "cache-path"
+	cacheMaxSizeKey     = "cache-max-size"
+	cacheMaxAgeKey      = "cache-max-age"
+	offlineKey          = "offline"
+	keepVersionsKey     = "keep-versions"
+	nodeConfigKey       = "node-config"
+	networkKey          = "network"
+	dryRunKey           = "dry-run"
 )
 
 func New(fs afero.Fs) (*cobra.Command, error) {
@@ -55,22 +76,55 @@ func New(fs afero.Fs) (*cobra.Command, error) {
 	rootCmd.PersistentFlags().String(configFileKey, "", "path to configuration file for the lpm")
 	rootCmd.PersistentFlags().String(lpmPathKey, lpmDir, "path to the directory lpm creates its artifacts")
 	rootCmd.PersistentFlags().String(pluginPathKey, filepath.Join(homeDir, ".lpm", "plugins"), "path to plugin directory (~/.lpm/plugins)")
+	rootCmd.PersistentFlags().String(keysPathKey, filepath.Join(homeDir, ".lpm", "keys"), "path to the directory of public keys trusted to sign virtual machines (~/.lpm/keys)")
+	rootCmd.PersistentFlags().Bool(allowUnsignedKey, false, "install virtual machines that aren't signed by a trusted key")
 	rootCmd.PersistentFlags().String(credentialsFileKey, "", "path to credentials file")
 	rootCmd.PersistentFlags().String(adminAPIEndpointKey, "127.0.0.1:9650/ext/admin", "endpoint for node admin api")
+	rootCmd.PersistentFlags().String(platformKey, types.CurrentPlatform().String(), "platform to install virtual machines for, in the form of os/arch")
+	rootCmd.PersistentFlags().StringSlice(repoPriorityKey, []string{constant.CoreAlias}, "repositories to prefer when an alias is defined in more than one, highest priority first")
+	rootCmd.PersistentFlags().Duration(downloadTimeoutKey, url.DefaultTimeout, "how long downloads wait to connect or for more data before failing")
+	rootCmd.PersistentFlags().Int(downloadRetriesKey, url.DefaultRetries, "how many times failed downloads are retried")
+	rootCmd.PersistentFlags().Duration(downloadBackoffKey, url.DefaultBackoff, "delay before retrying a failed download, doubled after every retry")
+	rootCmd.PersistentFlags().String(proxyKey, "", "proxy to download through (defaults to the HTTP_PROXY and HTTPS_PROXY environment variables)")
+	rootCmd.PersistentFlags().String(caFileKey, "", "path to a PEM bundle of certificate authorities to trust for downloads")
+	rootCmd.PersistentFlags().String(cachePathKey, filepath.Join(homeDir, ".lpm", "cache"), "path to the cache of downloaded artifacts (~/.lpm/cache)")
+	rootCmd.PersistentFlags().String(cacheMaxSizeKey, cache.FormatSize(cache.DefaultMaxSize), "size the cache is kept under by evicting the least recently used artifacts, 0 for no limit")
+	rootCmd.PersistentFlags().Duration(cacheMaxAgeKey, cache.DefaultMaxAge, "how long unused artifacts are cached, 0 for no limit")
+	rootCmd.PersistentFlags().Int(keepVersionsKey, lpm.DefaultKeepVersions, "how many replaced binaries are kept per virtual machine to roll back to, 0 to keep none")
+	rootCmd.PersistentFlags().String(nodeConfigKey, "", fmt.Sprintf("path to the node's JSON config file to set the plugin directory, tracked chains and chain configs in (defaults to the first of %v that exists)", node.DefaultConfigPaths(homeDir)))
+	rootCmd.PersistentFlags().String(networkKey, constant.DefaultNetwork, "network to join chains on, which selects their chain IDs and configs")
+	rootCmd.PersistentFlags().Bool(offlineKey, false, "never touch the network, only use repositories on disk and cached artifacts")
 
 	errs := wrappers.Errs{}
 	errs.Add(
 		viper.BindPFlag(configFileKey, rootCmd.PersistentFlags().Lookup(configFileKey)),
 		viper.BindPFlag(lpmPathKey, rootCmd.PersistentFlags().Lookup(lpmPathKey)),
 		viper.BindPFlag(pluginPathKey, rootCmd.PersistentFlags().Lookup(pluginPathKey)),
+		viper.BindPFlag(keysPathKey, rootCmd.PersistentFlags().Lookup(keysPathKey)),
+		viper.BindPFlag(allowUnsignedKey, rootCmd.PersistentFlags().Lookup(allowUnsignedKey)),
 		viper.BindPFlag(credentialsFileKey, rootCmd.PersistentFlags().Lookup(credentialsFileKey)),
 		viper.BindPFlag(adminAPIEndpointKey, rootCmd.PersistentFlags().Lookup(adminAPIEndpointKey)),
+		viper.BindPFlag(repoPriorityKey, rootCmd.PersistentFlags().Lookup(repoPriorityKey)),
+		viper.BindPFlag(platformKey, rootCmd.PersistentFlags().Lookup(platformKey)),
+		viper.BindPFlag(downloadTimeoutKey, rootCmd.PersistentFlags().Lookup(downloadTimeoutKey)),
+		viper.BindPFlag(downloadRetriesKey, rootCmd.PersistentFlags().Lookup(downloadRetriesKey)),
+		viper.BindPFlag(downloadBackoffKey, rootCmd.PersistentFlags().Lookup(downloadBackoffKey)),
+		viper.BindPFlag(proxyKey, rootCmd.PersistentFlags().Lookup(proxyKey)),
+		viper.BindPFlag(caFileKey, rootCmd.PersistentFlags().Lookup(caFileKey)),
+		viper.BindPFlag(cachePathKey, rootCmd.PersistentFlags().Lookup(cachePathKey)),
+		viper.BindPFlag(cacheMaxSizeKey, rootCmd.PersistentFlags().Lookup(cacheMaxSizeKey)),
+		viper.BindPFlag(cacheMaxAgeKey, rootCmd.PersistentFlags().Lookup(cacheMaxAgeKey)),
+		viper.BindPFlag(offlineKey, rootCmd.PersistentFlags().Lookup(offlineKey)),
+		viper.BindPFlag(keepVersionsKey, rootCmd.PersistentFlags().Lookup(keepVersionsKey)),
+		viper.BindPFlag(nodeConfigKey, rootCmd.PersistentFlags().Lookup(nodeConfigKey)),
+		viper.BindPFlag(networkKey, rootCmd.PersistentFlags().Lookup(networkKey)),
 	)
 	if errs.Errored() {
 		return nil, errs.Err
 	}
 
 	rootCmd.AddCommand(
+		info(fs),
 		install(fs),
 		installGithub(fs),
 		installGitlab(fs),
@@ -79,9 +133,18 @@ func New(fs afero.Fs) (*cobra.Command, error) {
 		uninstall(fs),
 		update(fs),
 		upgrade(fs),
+		rollback(fs),
 		link(fs),
+		list(fs),
 		listRepositories(fs),
+		search(fs),
+		lock(fs),
+		sync(fs),
+		key(fs),
+		cacheCmd(fs),
+		bundle(fs),
 		joinChain(fs),
+		leaveChain(fs),
 		addRepository(fs),
 		removeRepository(fs),
 	)
@@ -89,6 +152,16 @@ func New(fs afero.Fs) (*cobra.Command, error) {
 	return rootCmd, nil
 }
 
+// addOutputFlag adds the --output flag for commands that support
+// machine-readable output.
+func addOutputFlag(command *cobra.Command, format *string) {
+	command.PersistentFlags().StringVarP(format, "output", "o", string(lpm.TableOutput), fmt.Sprintf("output format (one of %v)", lpm.OutputFormats))
+}
+
+func addDryRunFlag(command *cobra.Command, dryRun *bool) {
+	command.Flags().BoolVar(dryRun, dryRunKey, false, "print a plan of what would be done without changing anything")
+}
+
 // initializes config from file, if available.
 func initializeConfig() error {
 	if viper.IsSet(configFileKey) {
@@ -124,17 +197,92 @@ func initCredentials() (http.BasicAuth, error) {
 	return result, nil
 }
 
+// initPlatform returns the platform virtual machines are installed for.
+func initPlatform() (types.Platform, error) {
+	return types.ParsePlatform(viper.GetString(platformKey))
+}
+
+// initDownload returns how files are downloaded.
+func initDownload() url.Config {
+	retries := viper.GetInt(downloadRetriesKey)
+	if retries == 0 {
+		// url.Config treats zero as the default, so disable retries explicitly.
+		retries = -1
+	}
+
+	return url.Config{
+		Timeout: viper.GetDuration(downloadTimeoutKey),
+		Retries: retries,
+		Backoff: viper.GetDuration(downloadBackoffKey),
+		Proxy:   viper.GetString(proxyKey),
+		CAFile:  os.ExpandEnv(viper.GetString(caFileKey)),
+	}
+}
+
+// initCachePolicy returns when cached artifacts are evicted.
+func initCachePolicy() (cache.Policy, error) {
+	maxSize, err := cache.ParseSize(viper.GetString(cacheMaxSizeKey))
+	if err != nil {
+		return cache.Policy{}, fmt.Errorf("invalid --%s: %w", cacheMaxSizeKey, err)
+	}
+
+	return cache.Policy{
+		MaxSize: maxSize,
+		MaxAge:  viper.GetDuration(cacheMaxAgeKey),
+	}, nil
+}
+
 func initLPM(fs afero.Fs) (*lpm.LPM, error) {
 	credentials, err := initCredentials()
 	if err != nil {
 		return nil, err
 	}
 
+	cachePolicy, err := initCachePolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	platform, err := initPlatform()
+	if err != nil {
+		return nil, err
+	}
+
 	return lpm.New(lpm.Config{
-		Directory:        viper.GetString(lpmPathKey),
-		Auth:             credentials,
-		AdminAPIEndpoint: viper.GetString(adminAPIEndpointKey),
-		PluginDir:        viper.GetString(pluginPathKey),
-		Fs:               fs,
+		Directory:          viper.GetString(lpmPathKey),
+		Auth:               credentials,
+		AdminAPIEndpoint:   viper.GetString(adminAPIEndpointKey),
+		PluginDir:          viper.GetString(pluginPathKey),
+		KeysDir:            viper.GetString(keysPathKey),
+		AllowUnsigned:      viper.GetBool(allowUnsignedKey),
+		KeepVersions:       viper.GetInt(keepVersionsKey),
+		RepositoryPriority: viper.GetStringSlice(repoPriorityKey),
+		Platform:           platform,
+		Download:           initDownload(),
+		CacheDir:           os.ExpandEnv(viper.GetString(cachePathKey)),
+		CachePolicy:        cachePolicy,
+		Offline:            viper.GetBool(offlineKey),
+		NodeConfigFile:     nodeConfigFile(fs),
+		Network:            viper.GetString(networkKey),
+		DryRun:             viper.GetBool(dryRunKey),
+		Fs:                 fs,
 	})
 }
+
+// initPlannedLPM initializes the lpm like initLPM. If dryRun is set, the lpm
+// records a plan of what commands would do instead of doing it.
+func initPlannedLPM(fs afero.Fs, dryRun bool) (*lpm.LPM, error) {
+	viper.Set(dryRunKey, dryRun)
+	return initLPM(fs)
+}
+
+// nodeConfigFile returns the node config file to edit, looking for one in the
+// default locations if it isn't set.
+func nodeConfigFile(fs afero.Fs) string {
+	if path := viper.GetString(nodeConfigKey); path != "" {
+		return os.ExpandEnv(path)
+	}
+
+	path, _ := node.FindConfigFile(fs, homeDir)
+	return path
+}
@@ -0,0 +1,41 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/luxfi/lpm/lpm"
+)
+
+func search(fs afero.Fs) *cobra.Command {
+	format := ""
+	command := &cobra.Command{
+		Use:   "search <query>...",
+		Short: "Searches the virtual machines and chains in all tracked repositories.",
+		Long: "Searches the virtual machines and chains in all tracked repositories by name, alias, description, " +
+			"maintainer and ID. Results have to match every term in the query. The search index is rebuilt by update.",
+		Args: cobra.MinimumNArgs(1),
+	}
+	addOutputFlag(command, &format)
+
+	command.RunE = func(_ *cobra.Command, args []string) error {
+		outputFormat, err := lpm.ParseOutputFormat(format)
+		if err != nil {
+			return err
+		}
+
+		lpm, err := initLPM(fs)
+		if err != nil {
+			return err
+		}
+
+		return lpm.Search(strings.Join(args, " "), outputFormat)
+	}
+
+	return command
+}
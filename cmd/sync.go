@@ -0,0 +1,32 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package cmd
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/luxfi/lpm/manifest"
+)
+
+func sync(fs afero.Fs) *cobra.Command {
+	file := ""
+	command := &cobra.Command{
+		Use: "sync",
+		Short: "Installs, upgrades, downgrades and uninstalls virtual machines until they match " +
+			"the lockfile.",
+	}
+	command.Flags().StringVar(&file, "file", manifest.DefaultFile, "path to the lockfile")
+
+	command.RunE = func(_ *cobra.Command, _ []string) error {
+		lpm, err := initLPM(fs)
+		if err != nil {
+			return err
+		}
+
+		return lpm.Sync(file)
+	}
+
+	return command
+}
@@ -9,6 +9,8 @@ import (
 )
 
 func uninstall(fs afero.Fs) *cobra.Command {
+	dryRun := false
+	force := false
 	vm := ""
 	command := &cobra.Command{
 		Use:   "uninstall-vm",
@@ -20,13 +22,20 @@ func uninstall(fs afero.Fs) *cobra.Command {
 		panic(err)
 	}
 
+	command.Flags().BoolVar(&force, "force", false, "uninstall the vm even if joined chains still need it")
+	addDryRunFlag(command, &dryRun)
+
 	command.RunE = func(_ *cobra.Command, _ []string) error {
-		lpm, err := initLPM(fs)
+		lpm, err := initPlannedLPM(fs, dryRun)
 		if err != nil {
 			return err
 		}
 
-		return lpm.Uninstall(vm)
+		if err := lpm.Uninstall(vm, force); err != nil {
+			return err
+		}
+
+		return lpm.PrintPlan()
 	}
 
 	return command
@@ -9,17 +9,24 @@ import (
 )
 
 func update(fs afero.Fs) *cobra.Command {
+	dryRun := false
 	command := &cobra.Command{
 		Use:   "update",
 		Short: "Updates plugin definitions for all tracked repositories.",
 	}
+	addDryRunFlag(command, &dryRun)
+
 	command.RunE = func(_ *cobra.Command, _ []string) error {
-		lpm, err := initLPM(fs)
+		lpm, err := initPlannedLPM(fs, dryRun)
 		if err != nil {
 			return err
 		}
 
-		return lpm.Update()
+		if err := lpm.Update(); err != nil {
+			return err
+		}
+
+		return lpm.PrintPlan()
 	}
 
 	return command
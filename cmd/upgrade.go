@@ -9,6 +9,7 @@ import (
 )
 
 func upgrade(fs afero.Fs) *cobra.Command {
+	dryRun := false
 	// this flag is optional
 	vm := ""
 	command := &cobra.Command{
@@ -16,14 +17,20 @@ func upgrade(fs afero.Fs) *cobra.Command {
 		Short: "Upgrades a virtual machine. If none is specified, all " +
 			"installed virtual machines are upgraded.",
 	}
-	command.PersistentFlags().StringVar(&vm, "vm", "", "vm alias to install")
+	command.PersistentFlags().StringVar(&vm, "vm", "", "vm alias to upgrade, optionally with a new version constraint (e.g spacesvm@^1.3)")
+	addDryRunFlag(command, &dryRun)
+
 	command.RunE = func(_ *cobra.Command, _ []string) error {
-		lpm, err := initLPM(fs)
+		lpm, err := initPlannedLPM(fs, dryRun)
 		if err != nil {
 			return err
 		}
 
-		return lpm.Upgrade(vm)
+		if err := lpm.Upgrade(vm); err != nil {
+			return err
+		}
+
+		return lpm.PrintPlan()
 	}
 
 	return command
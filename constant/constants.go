@@ -10,5 +10,6 @@ const (
 	CoreBranch             = "master"
 	QualifiedNameDelimiter = ":"
 	AliasDelimiter         = "/"
+	VersionDelimiter       = "@"
 	DefaultNetwork         = "testnet"
 )
@@ -6,28 +6,96 @@ package engine
 import (
 	"fmt"
 
+	"github.com/spf13/afero"
+
 	"github.com/luxfi/lpm/state"
 	"github.com/luxfi/lpm/workflow"
 )
 
 var _ workflow.Executor = &WorkflowEngine{}
 
-func NewWorkflowEngine(stateFile state.File) *WorkflowEngine {
+type Config struct {
+	StateFile state.File
+	Fs        afero.Fs
+	// TmpPath is where snapshots of tracked files are kept while a
+	// workflow is running.
+	TmpPath string
+	// DryRun undoes every workflow once it succeeds, so nothing it changed
+	// is kept.
+	DryRun bool
+}
+
+func NewWorkflowEngine(config Config) *WorkflowEngine {
 	return &WorkflowEngine{
-		stateFile: stateFile,
+		stateFile: config.StateFile,
+		fs:        config.Fs,
+		tmpPath:   config.TmpPath,
+		dryRun:    config.DryRun,
 	}
 }
 
+// WorkflowEngine runs each workflow in a transaction. If a workflow fails,
+// the state file and every file it tracked are restored to how they were
+// before it started. Workflows executed by another workflow join their
+// parent's transaction once they succeed, so a failure in the parent also
+// undoes them.
 type WorkflowEngine struct {
 	stateFile state.File
+	fs        afero.Fs
+	tmpPath   string
+	dryRun    bool
+
+	// transaction of the workflow currently being executed
+	current *transaction
 }
 
-func (w *WorkflowEngine) Execute(workflow workflow.Workflow) error {
+func (w *WorkflowEngine) Execute(wf workflow.Workflow) error {
+	snapshot, err := w.stateFile.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	tx := &transaction{
+		fs:      w.fs,
+		tmpPath: w.tmpPath,
+		parent:  w.current,
+	}
+	tx.OnRollback(func() error {
+		return w.stateFile.Restore(snapshot)
+	})
+
+	w.current = tx
 	defer func() {
-		if err := w.stateFile.Commit(); err != nil {
-			fmt.Printf("failed to commit the statefile")
-		}
+		w.current = tx.parent
 	}()
 
-	return workflow.Execute()
+	if t, ok := wf.(workflow.Transactional); ok {
+		t.Begin(tx)
+	}
+
+	if err := wf.Execute(); err != nil {
+		if rollbackErr := tx.rollback(); rollbackErr != nil {
+			fmt.Printf("Failed to roll back changes: %s\n", rollbackErr)
+		}
+		return err
+	}
+
+	if tx.parent != nil {
+		tx.parent.join(tx)
+		return nil
+	}
+
+	if w.dryRun {
+		if err := tx.rollback(); err != nil {
+			return fmt.Errorf("failed to undo the dry run: %w", err)
+		}
+		return nil
+	}
+
+	tx.close()
+	if err := w.stateFile.Commit(); err != nil {
+		return fmt.Errorf("failed to commit the statefile: %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,202 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package engine
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/workflow"
+)
+
+var _ workflow.Transactional = &testWorkflow{}
+
+type testWorkflow struct {
+	tx      workflow.Transaction
+	execute func(tx workflow.Transaction) error
+}
+
+func (t *testWorkflow) Begin(tx workflow.Transaction) {
+	t.tx = tx
+}
+
+func (t *testWorkflow) Execute() error {
+	return t.execute(t.tx)
+}
+
+func TestWorkflowEngineExecute(t *testing.T) {
+	const (
+		name    = "organization/repository:vm"
+		binary  = "plugins/vmid"
+		created = "plugins/created"
+	)
+
+	errWrong := fmt.Errorf("something went wrong")
+
+	tests := []struct {
+		name    string
+		execute func(e *WorkflowEngine, stateFile state.File, fs afero.Fs) func(workflow.Transaction) error
+		dryRun  bool
+		wantErr error
+		// expected contents of binary, or nil if it should be missing
+		wantBinary   []byte
+		wantCreated  bool
+		wantRegistry bool
+	}{
+		{
+			name: "success keeps changes",
+			execute: func(_ *WorkflowEngine, stateFile state.File, fs afero.Fs) func(workflow.Transaction) error {
+				return func(tx workflow.Transaction) error {
+					require.NoError(t, tx.Track(binary))
+					require.NoError(t, afero.WriteFile(fs, binary, []byte("new"), perms.ReadWrite))
+					stateFile.InstallationRegistry[name] = &state.InstallInfo{ID: "vmid"}
+					return nil
+				}
+			},
+			wantBinary:   []byte("new"),
+			wantRegistry: true,
+		},
+		{
+			name: "dry run undoes changes",
+			execute: func(e *WorkflowEngine, stateFile state.File, fs afero.Fs) func(workflow.Transaction) error {
+				return func(workflow.Transaction) error {
+					child := &testWorkflow{
+						execute: func(tx workflow.Transaction) error {
+							require.NoError(t, tx.Track(binary))
+							require.NoError(t, tx.Track(created))
+							require.NoError(t, afero.WriteFile(fs, created, []byte("new"), perms.ReadWrite))
+							return afero.WriteFile(fs, binary, []byte("new"), perms.ReadWrite)
+						},
+					}
+					stateFile.InstallationRegistry[name] = &state.InstallInfo{ID: "vmid"}
+					return e.Execute(child)
+				}
+			},
+			dryRun:     true,
+			wantBinary: []byte("old"),
+		},
+		{
+			name: "failure restores tracked files and state",
+			execute: func(_ *WorkflowEngine, stateFile state.File, fs afero.Fs) func(workflow.Transaction) error {
+				return func(tx workflow.Transaction) error {
+					require.NoError(t, tx.Track(binary))
+					require.NoError(t, tx.Track(created))
+					require.NoError(t, afero.WriteFile(fs, binary, []byte("new"), perms.ReadWrite))
+					require.NoError(t, afero.WriteFile(fs, created, []byte("new"), perms.ReadWrite))
+					stateFile.InstallationRegistry[name] = &state.InstallInfo{ID: "vmid"}
+					return errWrong
+				}
+			},
+			wantErr:    errWrong,
+			wantBinary: []byte("old"),
+		},
+		{
+			name: "failure runs compensating actions",
+			execute: func(_ *WorkflowEngine, _ state.File, fs afero.Fs) func(workflow.Transaction) error {
+				return func(tx workflow.Transaction) error {
+					tx.OnRollback(func() error {
+						return fs.Remove(binary)
+					})
+					return errWrong
+				}
+			},
+			wantErr: errWrong,
+		},
+		{
+			name: "failed parent undoes successful child",
+			execute: func(e *WorkflowEngine, stateFile state.File, fs afero.Fs) func(workflow.Transaction) error {
+				return func(workflow.Transaction) error {
+					child := &testWorkflow{
+						execute: func(tx workflow.Transaction) error {
+							require.NoError(t, tx.Track(binary))
+							stateFile.InstallationRegistry[name] = &state.InstallInfo{ID: "vmid"}
+							return afero.WriteFile(fs, binary, []byte("new"), perms.ReadWrite)
+						},
+					}
+					require.NoError(t, e.Execute(child))
+					return errWrong
+				}
+			},
+			wantErr:    errWrong,
+			wantBinary: []byte("old"),
+		},
+		{
+			name: "failed child doesn't undo parent",
+			execute: func(e *WorkflowEngine, stateFile state.File, fs afero.Fs) func(workflow.Transaction) error {
+				return func(tx workflow.Transaction) error {
+					require.NoError(t, tx.Track(binary))
+					require.NoError(t, afero.WriteFile(fs, binary, []byte("new"), perms.ReadWrite))
+					stateFile.InstallationRegistry[name] = &state.InstallInfo{ID: "vmid"}
+
+					child := &testWorkflow{
+						execute: func(tx workflow.Transaction) error {
+							require.NoError(t, tx.Track(created))
+							return afero.WriteFile(fs, created, []byte("new"), perms.ReadWrite)
+						},
+					}
+					require.NoError(t, e.Execute(child))
+
+					failed := &testWorkflow{
+						execute: func(tx workflow.Transaction) error {
+							require.NoError(t, tx.Track(binary))
+							require.NoError(t, afero.WriteFile(fs, binary, []byte("newer"), perms.ReadWrite))
+							return errWrong
+						},
+					}
+					require.ErrorIs(t, e.Execute(failed), errWrong)
+					return nil
+				}
+			},
+			wantBinary:   []byte("new"),
+			wantCreated:  true,
+			wantRegistry: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, binary, []byte("old"), perms.ReadWrite))
+
+			stateFile, err := state.New(t.TempDir())
+			require.NoError(t, err)
+
+			e := NewWorkflowEngine(Config{
+				StateFile: stateFile,
+				Fs:        fs,
+				TmpPath:   "tmp",
+				DryRun:    test.dryRun,
+			})
+
+			wf := &testWorkflow{execute: test.execute(e, stateFile, fs)}
+			require.ErrorIs(t, e.Execute(wf), test.wantErr)
+
+			got, err := afero.ReadFile(fs, binary)
+			if test.wantBinary == nil {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.wantBinary, got)
+			}
+
+			exists, err := afero.Exists(fs, created)
+			require.NoError(t, err)
+			require.Equal(t, test.wantCreated, exists)
+
+			_, ok := stateFile.InstallationRegistry[name]
+			require.Equal(t, test.wantRegistry, ok)
+
+			// snapshots are always cleaned up
+			entries, err := afero.ReadDir(fs, "tmp")
+			if err == nil {
+				require.Empty(t, entries)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+
+	"github.com/luxfi/lpm/workflow"
+)
+
+var _ workflow.Transaction = &transaction{}
+
+type transaction struct {
+	fs      afero.Fs
+	tmpPath string
+	parent  *transaction
+
+	// directory holding snapshots of tracked files, created on first use
+	snapshotDir string
+	// snapshot directories of child transactions that joined this one
+	childDirs []string
+	rollbacks []func() error
+}
+
+func (t *transaction) Track(path string) error {
+	info, err := lstat(t.fs, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.OnRollback(func() error {
+			return removeIfExists(t.fs, path)
+		})
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	switch mode := info.Mode(); {
+	case mode&os.ModeSymlink != 0:
+		symlinker, ok := t.fs.(afero.Symlinker)
+		if !ok {
+			return fmt.Errorf("can't track symlink %s: filesystem doesn't support symlinks", path)
+		}
+		target, err := symlinker.ReadlinkIfPossible(path)
+		if err != nil {
+			return err
+		}
+		t.OnRollback(func() error {
+			if err := removeIfExists(t.fs, path); err != nil {
+				return err
+			}
+			return symlinker.SymlinkIfPossible(target, path)
+		})
+	case mode.IsRegular():
+		snapshot, err := t.snapshot(path)
+		if err != nil {
+			return err
+		}
+		t.OnRollback(func() error {
+			return copyFile(t.fs, snapshot, path, mode.Perm())
+		})
+	default:
+		return fmt.Errorf("can't track %s: not a regular file or symlink", path)
+	}
+
+	return nil
+}
+
+func (t *transaction) OnRollback(action func() error) {
+	t.rollbacks = append(t.rollbacks, action)
+}
+
+// join merges a successful child transaction into this one, so that the
+// child's changes are undone if this transaction rolls back.
+func (t *transaction) join(child *transaction) {
+	t.rollbacks = append(t.rollbacks, child.rollbacks...)
+	t.childDirs = append(t.childDirs, child.childDirs...)
+	if child.snapshotDir != "" {
+		t.childDirs = append(t.childDirs, child.snapshotDir)
+	}
+}
+
+// rollback runs every compensating action in reverse order. It keeps going
+// if an action fails so that as much as possible is restored.
+func (t *transaction) rollback() error {
+	errs := make([]error, 0)
+	for i := len(t.rollbacks) - 1; i >= 0; i-- {
+		if err := t.rollbacks[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	t.close()
+	return errors.Join(errs...)
+}
+
+// close discards all snapshots taken by this transaction.
+func (t *transaction) close() {
+	dirs := t.childDirs
+	if t.snapshotDir != "" {
+		dirs = append(dirs, t.snapshotDir)
+	}
+
+	for _, dir := range dirs {
+		if err := t.fs.RemoveAll(dir); err != nil {
+			fmt.Printf("Failed to clean up %s: %s\n", dir, err)
+		}
+	}
+}
+
+// snapshot copies the file at path into this transaction's snapshot directory
+// and returns the location of the copy.
+func (t *transaction) snapshot(path string) (string, error) {
+	if t.snapshotDir == "" {
+		if err := t.fs.MkdirAll(t.tmpPath, perms.ReadWriteExecute); err != nil {
+			return "", err
+		}
+		dir, err := afero.TempDir(t.fs, t.tmpPath, "tx-")
+		if err != nil {
+			return "", err
+		}
+		t.snapshotDir = dir
+	}
+
+	snapshot := filepath.Join(t.snapshotDir, fmt.Sprint(len(t.rollbacks)))
+	if err := copyFile(t.fs, path, snapshot, perms.ReadWrite); err != nil {
+		return "", err
+	}
+
+	return snapshot, nil
+}
+
+func lstat(fs afero.Fs, path string) (os.FileInfo, error) {
+	if lstater, ok := fs.(afero.Lstater); ok {
+		info, _, err := lstater.LstatIfPossible(path)
+		return info, err
+	}
+
+	return fs.Stat(path)
+}
+
+func removeIfExists(fs afero.Fs, path string) error {
+	if err := fs.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func copyFile(fs afero.Fs, src string, dst string, mode os.FileMode) error {
+	in, err := fs.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	// remove whatever is at dst first so we never write through a symlink
+	if err := removeIfExists(fs, dst); err != nil {
+		return err
+	}
+
+	out, err := fs.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return fs.Chmod(dst, mode)
+}
@@ -3,18 +3,21 @@ module github.com/luxfi/lpm
 go 1.26.1
 
 require (
-	github.com/cavaliergopher/grab/v3 v3.0.1
 	github.com/go-git/go-git/v5 v5.17.2
 	github.com/golang/mock v1.7.0-rc.1
 	github.com/juju/fslock v0.0.0-20160525022230-4d5c94c67b4b
+	github.com/klauspost/compress v1.18.3
 	github.com/luxfi/codec v1.1.4
 	github.com/luxfi/filesystem v0.0.1
 	github.com/luxfi/ids v1.2.9
+	github.com/luxfi/rpc v1.0.2
 	github.com/luxfi/sdk v1.16.48
 	github.com/spf13/afero v1.15.0
 	github.com/spf13/cobra v1.10.2
 	github.com/spf13/viper v1.21.0
 	github.com/stretchr/testify v1.11.1
+	github.com/ulikunitz/xz v0.5.15
+	golang.org/x/crypto v0.49.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -41,7 +44,6 @@ require (
 	github.com/luxfi/crypto v1.17.45 // indirect
 	github.com/luxfi/formatting v1.0.1 // indirect
 	github.com/luxfi/mock v0.1.1 // indirect
-	github.com/luxfi/rpc v1.0.2 // indirect
 	github.com/mr-tron/base58 v1.2.0 // indirect
 	github.com/onsi/gomega v1.39.1 // indirect
 	github.com/pelletier/go-toml/v2 v2.3.0 // indirect
@@ -56,7 +58,6 @@ require (
 	github.com/xanzy/ssh-agent v0.3.3 // indirect
 	go.uber.org/mock v0.6.0 // indirect
 	go.yaml.in/yaml/v3 v3.0.4 // indirect
-	golang.org/x/crypto v0.49.0 // indirect
 	golang.org/x/net v0.52.0 // indirect
 	golang.org/x/sys v0.42.0 // indirect
 	golang.org/x/text v0.35.0 // indirect
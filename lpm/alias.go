@@ -0,0 +1,218 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package lpm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/luxfi/lpm/constant"
+)
+
+// maximum number of suggestions shown when an alias isn't found
+const maxSuggestions = 5
+
+// definitionKind is the type of definition an alias is resolved to.
+type definitionKind int
+
+const (
+	vmDefinition definitionKind = 1 << iota
+	chainDefinition
+
+	anyDefinition = vmDefinition | chainDefinition
+)
+
+func (k definitionKind) String() string {
+	switch k {
+	case vmDefinition:
+		return "vm"
+	case chainDefinition:
+		return "chain"
+	default:
+		return "vm or chain"
+	}
+}
+
+// AmbiguousAliasError is returned when more than one tracked repository
+// defines an alias and none of them has priority over the others.
+type AmbiguousAliasError struct {
+	Alias string
+	// Fully qualified names of every matching definition
+	Candidates []string
+}
+
+func (e *AmbiguousAliasError) Error() string {
+	return fmt.Sprintf(
+		"more than one match found for %s. Please specify the fully qualified name or set a repository priority. Matches: %s",
+		e.Alias,
+		strings.Join(e.Candidates, ", "),
+	)
+}
+
+// AliasNotFoundError is returned when no tracked repository defines an alias.
+type AliasNotFoundError struct {
+	Alias string
+	Kind  string
+	// Fully qualified names of similarly named definitions
+	Suggestions []string
+}
+
+func (e *AliasNotFoundError) Error() string {
+	msg := fmt.Sprintf("no %s named %s found in any tracked repository", e.Kind, e.Alias)
+	if len(e.Suggestions) == 0 {
+		return msg
+	}
+	return fmt.Sprintf("%s. Did you mean: %s?", msg, strings.Join(e.Suggestions, ", "))
+}
+
+func qualifiedName(name string) bool {
+	parsed := strings.Split(name, ":")
+	return len(parsed) > 1
+}
+
+// getFullNameForAlias resolves alias to the fully qualified name of the
+// definition of the given kind in the tracked repositories. If several
+// repositories define it, the one first in the repository priority order is
+// used. Repositories that aren't on disk yet, e.g. because they were added
+// but not updated, are skipped.
+func (a *LPM) getFullNameForAlias(alias string, kind definitionKind) (string, error) {
+	repositories := make([]string, 0, len(a.stateFile.Sources))
+	for repository := range a.stateFile.Sources {
+		repositories = append(repositories, repository)
+	}
+	sort.Strings(repositories)
+
+	matches := make([]string, 0)
+	known := make([]string, 0)
+	for _, repoAlias := range repositories {
+		names, err := a.definitions(repoAlias, kind)
+		if errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("Warning - skipping repository %s, which isn't on disk. Use update to fetch it.\n", repoAlias)
+			continue
+		} else if err != nil {
+			return "", err
+		}
+
+		for _, name := range names {
+			fullName := strings.Join([]string{repoAlias, name}, constant.QualifiedNameDelimiter)
+			if name == alias {
+				matches = append(matches, fullName)
+				// chains and vms may share a name
+				break
+			}
+			known = append(known, fullName)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", &AliasNotFoundError{
+			Alias:       alias,
+			Kind:        kind.String(),
+			Suggestions: suggest(alias, known),
+		}
+	case 1:
+		return matches[0], nil
+	}
+
+	for _, repoAlias := range a.repositoryPriority {
+		for _, match := range matches {
+			if strings.HasPrefix(match, repoAlias+constant.QualifiedNameDelimiter) {
+				return match, nil
+			}
+		}
+	}
+
+	return "", &AmbiguousAliasError{
+		Alias:      alias,
+		Candidates: matches,
+	}
+}
+
+// definitions returns the name of every definition of kind in a repository.
+func (a *LPM) definitions(repoAlias string, kind definitionKind) ([]string, error) {
+	repository, err := a.repoFactory.GetRepository(repoAlias)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0)
+	if kind&vmDefinition != 0 {
+		vms, err := repository.ListVMs()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, vms...)
+	}
+	if kind&chainDefinition != 0 {
+		chains, err := repository.ListChains()
+		if err != nil {
+			return nil, err
+		}
+		for _, chain := range chains {
+			if !slices.Contains(names, chain) {
+				names = append(names, chain)
+			}
+		}
+	}
+
+	return names, nil
+}
+
+// suggest returns the fully qualified names in known whose definition name is
+// close to alias, closest first.
+func suggest(alias string, known []string) []string {
+	type suggestion struct {
+		name     string
+		distance int
+	}
+
+	threshold := max(2, len(alias)/3)
+	suggestions := make([]suggestion, 0)
+	for _, fullName := range known {
+		_, name, _ := strings.Cut(fullName, constant.QualifiedNameDelimiter)
+		distance := levenshtein(strings.ToLower(alias), strings.ToLower(name))
+		if distance <= threshold || strings.Contains(name, alias) {
+			suggestions = append(suggestions, suggestion{name: fullName, distance: distance})
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].distance < suggestions[j].distance
+	})
+
+	result := make([]string, 0, maxSuggestions)
+	for i := 0; i < len(suggestions) && i < maxSuggestions; i++ {
+		result = append(result, suggestions[i].name)
+	}
+	return result
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	previous := make([]int, len(t)+1)
+	current := make([]int, len(t)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		current[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(t)]
+}
@@ -0,0 +1,171 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package lpm
+
+import (
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/lpm/state"
+)
+
+func TestGetFullNameForAlias(t *testing.T) {
+	const (
+		core = "luxfi/plugins-core"
+		fork = "acme/plugins"
+	)
+
+	type repository struct {
+		vms    []string
+		chains []string
+	}
+
+	tests := []struct {
+		name         string
+		repositories map[string]repository
+		missing      []string
+		priority     []string
+		alias        string
+		kind         definitionKind
+		want         string
+		wantErr      error
+	}{
+		{
+			name: "only the repository defining the alias matches",
+			repositories: map[string]repository{
+				core: {vms: []string{"spacesvm"}},
+				fork: {vms: []string{"timestampvm"}},
+			},
+			alias: "timestampvm",
+			kind:  vmDefinition,
+			want:  fork + ":timestampvm",
+		},
+		{
+			name: "kind is respected",
+			repositories: map[string]repository{
+				core: {chains: []string{"spaces"}},
+				fork: {vms: []string{"spaces"}},
+			},
+			alias: "spaces",
+			kind:  chainDefinition,
+			want:  core + ":spaces",
+		},
+		{
+			name: "vm and chain with the same name in one repository",
+			repositories: map[string]repository{
+				core: {vms: []string{"spaces"}, chains: []string{"spaces"}},
+			},
+			alias: "spaces",
+			kind:  anyDefinition,
+			want:  core + ":spaces",
+		},
+		{
+			name: "priority breaks ties",
+			repositories: map[string]repository{
+				core: {vms: []string{"spacesvm"}},
+				fork: {vms: []string{"spacesvm"}},
+			},
+			priority: []string{fork, core},
+			alias:    "spacesvm",
+			kind:     vmDefinition,
+			want:     fork + ":spacesvm",
+		},
+		{
+			name: "ambiguous without priority",
+			repositories: map[string]repository{
+				core: {vms: []string{"spacesvm"}},
+				fork: {vms: []string{"spacesvm"}},
+			},
+			alias: "spacesvm",
+			kind:  vmDefinition,
+			wantErr: &AmbiguousAliasError{
+				Alias:      "spacesvm",
+				Candidates: []string{fork + ":spacesvm", core + ":spacesvm"},
+			},
+		},
+		{
+			name: "not found suggests near misses",
+			repositories: map[string]repository{
+				core: {vms: []string{"spacesvm", "timestampvm"}},
+				fork: {vms: []string{"spacevm"}},
+			},
+			alias: "spcesvm",
+			kind:  vmDefinition,
+			wantErr: &AliasNotFoundError{
+				Alias:       "spcesvm",
+				Kind:        "vm",
+				Suggestions: []string{core + ":spacesvm", fork + ":spacevm"},
+			},
+		},
+		{
+			name: "repositories that aren't on disk are skipped",
+			repositories: map[string]repository{
+				core: {vms: []string{"spacesvm"}},
+			},
+			missing: []string{fork},
+			alias:   "spacesvm",
+			kind:    vmDefinition,
+			want:    core + ":spacesvm",
+		},
+		{
+			name:    "not found if only missing repositories",
+			missing: []string{fork},
+			alias:   "spacesvm",
+			kind:    vmDefinition,
+			wantErr: &AliasNotFoundError{
+				Alias:       "spacesvm",
+				Kind:        "vm",
+				Suggestions: []string{},
+			},
+		},
+		{
+			name:         "not found without repositories",
+			repositories: map[string]repository{},
+			alias:        "spacesvm",
+			kind:         vmDefinition,
+			wantErr: &AliasNotFoundError{
+				Alias:       "spacesvm",
+				Kind:        "vm",
+				Suggestions: []string{},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			stateFile, err := state.New(t.TempDir())
+			require.NoError(t, err)
+
+			repoFactory := state.NewMockRepositoryFactory(ctrl)
+			for alias, definitions := range test.repositories {
+				stateFile.Sources[alias] = &state.SourceInfo{}
+
+				repository := state.NewMockRepository(ctrl)
+				repository.EXPECT().ListVMs().Return(definitions.vms, nil).AnyTimes()
+				repository.EXPECT().ListChains().Return(definitions.chains, nil).AnyTimes()
+				repoFactory.EXPECT().GetRepository(alias).Return(repository, nil)
+			}
+			// Missing repositories are tracked but not on disk.
+			for _, alias := range test.missing {
+				stateFile.Sources[alias] = &state.SourceInfo{}
+				repoFactory.EXPECT().GetRepository(alias).Return(nil, &os.PathError{Op: "stat", Path: alias, Err: os.ErrNotExist})
+			}
+
+			a := &LPM{
+				repoFactory:        repoFactory,
+				stateFile:          stateFile,
+				repositoryPriority: test.priority,
+			}
+
+			got, err := a.getFullNameForAlias(test.alias, test.kind)
+			require.Equal(t, test.wantErr, err)
+			require.Equal(t, test.want, got)
+		})
+	}
+}
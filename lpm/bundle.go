@@ -0,0 +1,95 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package lpm
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/util"
+	"github.com/luxfi/lpm/workflow"
+)
+
+// ExportBundle writes every tracked repository and the artifacts of vms to a
+// bundle at path. Each VM alias may be followed by a version constraint like
+// spacesvm@1.2.3. If no VMs are given, the VMs installed from repositories
+// are exported at their installed versions.
+func (a *LPM) ExportBundle(path string, vms []string) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	selected := make([]workflow.BundleVM, 0, len(vms))
+	for _, vm := range vms {
+		alias, constraint := util.ParseVersionedName(vm)
+		name := alias
+		if !qualifiedName(alias) {
+			var err error
+			name, err = a.getFullNameForAlias(alias, vmDefinition)
+			if err != nil {
+				return err
+			}
+		}
+
+		selected = append(selected, workflow.BundleVM{Name: name, Constraint: constraint})
+	}
+
+	if len(vms) == 0 {
+		for name, info := range a.stateFile.InstallationRegistry {
+			if info.Origin.Type != state.RepositoryOrigin {
+				continue
+			}
+
+			constraint := ""
+			if info.Version != "" {
+				constraint = "=" + info.Version
+			}
+			selected = append(selected, workflow.BundleVM{Name: name, Constraint: constraint})
+		}
+		sort.Slice(selected, func(i, j int) bool {
+			return selected[i].Name < selected[j].Name
+		})
+
+		if len(selected) == 0 {
+			fmt.Printf("No VMs are installed from a repository, so only definitions are exported.\n")
+		}
+	}
+
+	return a.executor.Execute(workflow.NewExportBundle(workflow.ExportBundleConfig{
+		Path:             path,
+		VMs:              selected,
+		Platform:         a.platform,
+		TmpPath:          a.tmpPath,
+		RepositoriesPath: a.repositoriesPath,
+		StateFile:        a.stateFile,
+		RepoFactory:      a.repoFactory,
+		Installer:        a.installer,
+		Cache:            a.cache,
+		Fs:               a.fs,
+	}))
+}
+
+// ImportBundle loads the repositories and artifacts in the bundle at path.
+func (a *LPM) ImportBundle(path string) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	return a.executor.Execute(workflow.NewImportBundle(workflow.ImportBundleConfig{
+		Path:             path,
+		TmpPath:          a.tmpPath,
+		RepositoriesPath: a.repositoriesPath,
+		IndexPath:        a.indexPath,
+		StateFile:        a.stateFile,
+		Cache:            a.cache,
+		Fs:               a.fs,
+	}))
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package lpm
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/luxfi/lpm/cache"
+)
+
+// ListCache prints the artifacts in the download cache, most recently used
+// first.
+func (a *LPM) ListCache(format OutputFormat) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	entries, err := a.cache.List()
+	if err != nil {
+		return err
+	}
+
+	return output(format, entries, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "sha256\tsize\tlast used\tname")
+		for _, entry := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+				entry.SHA256,
+				cache.FormatSize(entry.Size),
+				entry.LastUsed.Local().Format("2006-01-02 15:04:05"),
+				entry.Name,
+			)
+		}
+	})
+}
+
+// PruneCache evicts the artifacts in the download cache that policy doesn't
+// allow.
+func (a *LPM) PruneCache(policy cache.Policy) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	evicted, err := a.cache.Prune(policy)
+	if err != nil {
+		return err
+	}
+
+	printEvicted(evicted)
+	return nil
+}
+
+// ClearCache evicts every artifact in the download cache.
+func (a *LPM) ClearCache() error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	evicted, err := a.cache.Clear()
+	if err != nil {
+		return err
+	}
+
+	printEvicted(evicted)
+	return nil
+}
+
+func printEvicted(evicted []cache.Entry) {
+	size := int64(0)
+	for _, entry := range evicted {
+		fmt.Printf("Removed %s (%s)\n", entry.SHA256, entry.Name)
+		size += entry.Size
+	}
+	fmt.Printf("Removed %d cached artifacts, freeing %s.\n", len(evicted), cache.FormatSize(size))
+}
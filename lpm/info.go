@@ -0,0 +1,197 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package lpm
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/luxfi/lpm/constant"
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/types"
+	"github.com/luxfi/lpm/util"
+)
+
+// Info describes the VM and/or chain definition with a given name.
+type Info struct {
+	Name  string     `yaml:"name" json:"name"`
+	VM    *VMInfo    `yaml:"vm,omitempty" json:"vm,omitempty"`
+	Chain *ChainInfo `yaml:"chain,omitempty" json:"chain,omitempty"`
+}
+
+// VMInfo is a VM definition and its local install status.
+type VMInfo struct {
+	Definition types.VM `yaml:"definition" json:"definition"`
+	// Commit the definition was last modified in
+	Commit string `yaml:"commit" json:"commit"`
+	// Installed is nil if the VM isn't installed.
+	Installed *state.InstallInfo `yaml:"installed" json:"installed"`
+}
+
+// ChainInfo is a chain definition and the local install status of the VMs
+// it needs.
+type ChainInfo struct {
+	Definition types.Chain `yaml:"definition" json:"definition"`
+	// Commit the definition was last modified in
+	Commit string `yaml:"commit" json:"commit"`
+	// Install status of each of the chain's VMs keyed by their qualified
+	// name. Entries are nil if the VM isn't installed.
+	VMs map[string]*state.InstallInfo `yaml:"vms" json:"vms"`
+}
+
+// Info prints the VM and/or chain definition for alias.
+func (a *LPM) Info(alias string, format OutputFormat) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	return a.parseAndRun(alias, anyDefinition, func(name string) error {
+		return a.info(name, format)
+	})
+}
+
+func (a *LPM) info(name string, format OutputFormat) error {
+	repoAlias, plugin := util.ParseQualifiedName(name)
+	repository, err := a.repoFactory.GetRepository(repoAlias)
+	if err != nil {
+		return err
+	}
+
+	result := Info{Name: name}
+
+	vm, err := repository.GetVM(plugin)
+	switch {
+	case err == nil:
+		result.VM = &VMInfo{
+			Definition: vm.Definition,
+			Commit:     vm.Commit,
+			Installed:  a.stateFile.InstallationRegistry[name],
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	chain, err := repository.GetChain(plugin)
+	switch {
+	case err == nil:
+		vms := make(map[string]*state.InstallInfo, len(chain.Definition.VMs))
+		for _, vm := range chain.Definition.VMs {
+			vmName := strings.Join([]string{repoAlias, vm}, constant.QualifiedNameDelimiter)
+			vms[vmName] = a.stateFile.InstallationRegistry[vmName]
+		}
+
+		result.Chain = &ChainInfo{
+			Definition: chain.Definition,
+			Commit:     chain.Commit,
+			VMs:        vms,
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	if result.VM == nil && result.Chain == nil {
+		return fmt.Errorf("%s doesn't define a vm or chain named %s", repoAlias, plugin)
+	}
+
+	return output(format, result, func(w *tabwriter.Writer) {
+		if result.VM != nil {
+			printVMInfo(w, name, result.VM)
+		}
+		if result.VM != nil && result.Chain != nil {
+			fmt.Fprintln(w)
+		}
+		if result.Chain != nil {
+			printChainInfo(w, name, result.Chain)
+		}
+	})
+}
+
+func printVMInfo(w *tabwriter.Writer, name string, info *VMInfo) {
+	vm := info.Definition
+	fmt.Fprintf(w, "vm:\t%s\n", name)
+	fmt.Fprintf(w, "id:\t%s\n", vm.ID)
+	fmt.Fprintf(w, "homepage:\t%s\n", vm.Homepage)
+	fmt.Fprintf(w, "description:\t%s\n", vm.Description)
+	fmt.Fprintf(w, "maintainers:\t%s\n", strings.Join(vm.Maintainers, ", "))
+	if len(vm.Versions) == 0 {
+		fmt.Fprintf(w, "url:\t%s\n", vm.URL)
+		fmt.Fprintf(w, "sha256:\t%s\n", vm.SHA256)
+		if vm.Signature != "" {
+			fmt.Fprintf(w, "signature:\t%s\n", vm.Signature)
+		}
+	} else {
+		versions := make([]string, 0, len(vm.Versions))
+		for _, version := range vm.Versions {
+			versions = append(versions, version.Version)
+		}
+		fmt.Fprintf(w, "versions:\t%s\n", strings.Join(versions, ", "))
+	}
+	if len(vm.Artifacts) > 0 {
+		platforms := make([]string, 0, len(vm.Artifacts))
+		for platform := range vm.Artifacts {
+			platforms = append(platforms, platform)
+		}
+		sort.Strings(platforms)
+		fmt.Fprintf(w, "platforms:\t%s\n", strings.Join(platforms, ", "))
+	}
+	fmt.Fprintf(w, "install script:\t%s\n", vm.InstallScript)
+	fmt.Fprintf(w, "binary path:\t%s\n", vm.BinaryPath)
+	fmt.Fprintf(w, "commit:\t%s\n", info.Commit)
+	fmt.Fprintf(w, "installed:\t%s\n", installStatus(info.Installed, info.Commit))
+	if info.Installed != nil && info.Installed.Signer != "" {
+		fmt.Fprintf(w, "signed by:\t%s\n", info.Installed.Signer)
+	}
+}
+
+func printChainInfo(w *tabwriter.Writer, name string, info *ChainInfo) {
+	chain := info.Definition
+	fmt.Fprintf(w, "chain:\t%s\n", name)
+
+	networks := make([]string, 0, len(chain.ID))
+	for network := range chain.ID {
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+	for _, network := range networks {
+		fmt.Fprintf(w, "id (%s):\t%s\n", network, chain.ID[network])
+	}
+
+	fmt.Fprintf(w, "homepage:\t%s\n", chain.Homepage)
+	fmt.Fprintf(w, "description:\t%s\n", chain.Description)
+	fmt.Fprintf(w, "maintainers:\t%s\n", strings.Join(chain.Maintainers, ", "))
+	fmt.Fprintf(w, "commit:\t%s\n", info.Commit)
+
+	vms := make([]string, 0, len(info.VMs))
+	for vm := range info.VMs {
+		vms = append(vms, vm)
+	}
+	sort.Strings(vms)
+	for _, vm := range vms {
+		fmt.Fprintf(w, "vm %s:\t%s\n", vm, installStatus(info.VMs[vm], ""))
+	}
+}
+
+// installStatus summarizes whether a VM is installed. If latest is
+// provided, installs of an older definition are flagged.
+func installStatus(installed *state.InstallInfo, latest string) string {
+	switch {
+	case installed == nil:
+		return "no"
+	case installed.Version != "" && installed.Constraint != "":
+		return fmt.Sprintf("yes (%s, pinned to %s)", installed.Version, installed.Constraint)
+	case installed.Version != "":
+		return fmt.Sprintf("yes (%s)", installed.Version)
+	case latest != "" && installed.Commit != latest:
+		return fmt.Sprintf("yes (%s, upgrade available)", installed.Commit)
+	default:
+		return fmt.Sprintf("yes (%s)", installed.Commit)
+	}
+}
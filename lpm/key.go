@@ -0,0 +1,82 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package lpm
+
+import (
+	"fmt"
+	"text/tabwriter"
+)
+
+// KeyInfo describes a key trusted to sign artifacts.
+type KeyInfo struct {
+	Name      string `yaml:"name" json:"name"`
+	ID        string `yaml:"id" json:"id"`
+	PublicKey string `yaml:"public-key" json:"public-key"`
+}
+
+// AddKey trusts a minisign public key under name.
+func (a *LPM) AddKey(name string, key []byte) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	added, err := a.keyring.Add(name, key)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Trusting key %s as %s.\n", added.PublicKey.ID(), added.Name)
+	return nil
+}
+
+// RemoveKey stops trusting the key called name.
+func (a *LPM) RemoveKey(name string) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	if err := a.keyring.Remove(name); err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed key %s.\n", name)
+	return nil
+}
+
+// ListKeys prints the keys trusted to sign artifacts.
+func (a *LPM) ListKeys(format OutputFormat) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	keys, err := a.keyring.List()
+	if err != nil {
+		return err
+	}
+
+	infos := make([]KeyInfo, 0, len(keys))
+	for _, key := range keys {
+		infos = append(infos, KeyInfo{
+			Name:      key.Name,
+			ID:        key.PublicKey.ID(),
+			PublicKey: key.PublicKey.String(),
+		})
+	}
+
+	return output(format, infos, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "name\tid\tpublic key")
+		for _, info := range infos {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", info.Name, info.ID, info.PublicKey)
+		}
+	})
+}
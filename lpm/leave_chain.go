@@ -0,0 +1,70 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package lpm
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/luxfi/lpm/util"
+	"github.com/luxfi/lpm/workflow"
+)
+
+// LeaveChain reverses JoinChain. The node stops tracking the chain, its
+// configs are removed and the VMs installed for it are uninstalled unless
+// another joined chain still needs them.
+func (a *LPM) LeaveChain(alias string) error {
+	name, err := a.joinedChainName(alias)
+	if err != nil {
+		return err
+	}
+
+	return a.leaveChain(name)
+}
+
+// joinedChainName returns the fully qualified name of the joined chain with
+// alias. Joined chains are looked up in the chain registry, so chains can be
+// left after their definitions are gone.
+func (a *LPM) joinedChainName(alias string) (string, error) {
+	if qualifiedName(alias) {
+		return alias, nil
+	}
+
+	matches := make([]string, 0, 1)
+	for _, name := range slices.Sorted(maps.Keys(a.stateFile.ChainRegistry)) {
+		if _, plugin := util.ParseQualifiedName(name); plugin == alias {
+			matches = append(matches, name)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("chain %s isn't joined", alias)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("more than one joined chain is named %s. Use one of %s", alias, strings.Join(matches, ", "))
+	}
+}
+
+func (a *LPM) leaveChain(name string) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	return a.executor.Execute(workflow.NewLeaveChain(workflow.LeaveChainConfig{
+		Executor:   a.executor,
+		Name:       name,
+		NodeConfig: a.nodeConfig,
+		StateFile:  a.stateFile,
+		Fs:         a.fs,
+		PluginPath: a.pluginPath,
+		Plan:       a.plan,
+	}))
+}
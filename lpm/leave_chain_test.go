@@ -0,0 +1,138 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package lpm
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/juju/fslock"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/lpm/engine"
+	"github.com/luxfi/lpm/node"
+	"github.com/luxfi/lpm/state"
+)
+
+const (
+	testChain    = "organization/repository:chain"
+	testVM       = "organization/repository:vm"
+	testChainID  = "chainID"
+	testVMID     = "vmID"
+	testPlugins  = "plugins"
+	testNodePath = "node/node.json"
+)
+
+// newTestLPM returns an LPM that runs workflows in a real engine on fs, with
+// the state file in a temporary directory.
+func newTestLPM(t *testing.T, fs afero.Fs) *LPM {
+	dir := t.TempDir()
+	stateFile, err := state.New(dir)
+	require.NoError(t, err)
+
+	return &LPM{
+		executor: engine.NewWorkflowEngine(engine.Config{
+			StateFile: stateFile,
+			Fs:        fs,
+			TmpPath:   filepath.Join(dir, tmpDir),
+		}),
+		nodeConfig: node.NewConfigFile(fs, testNodePath),
+		network:    "testnet",
+		pluginPath: testPlugins,
+		fs:         fs,
+		stateFile:  stateFile,
+		lock:       fslock.New(filepath.Join(dir, lockFile)),
+	}
+}
+
+// joinTestChain records testChain as joined and tracked, with testVM
+// installed for it.
+func joinTestChain(t *testing.T, a *LPM) {
+	require.NoError(t, afero.WriteFile(a.fs, testNodePath, []byte(`{"track-chains": "chainID"}`), 0o600))
+	require.NoError(t, afero.WriteFile(a.fs, filepath.Join(testPlugins, testVMID), []byte("binary"), 0o700))
+
+	a.stateFile.ChainRegistry[testChain] = &state.ChainInfo{
+		ID:  testChainID,
+		VMs: []string{testVM},
+	}
+	a.stateFile.InstallationRegistry[testVM] = &state.InstallInfo{
+		ID:         testVMID,
+		Chains:     []string{testChain},
+		Dependency: true,
+	}
+}
+
+func TestInstallAfterJoinChain(t *testing.T) {
+	tests := []struct {
+		name       string
+		install    bool
+		wantVMKept bool
+	}{
+		{
+			name: "dependency is uninstalled",
+		},
+		{
+			name:       "explicit install is kept",
+			install:    true,
+			wantVMKept: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := newTestLPM(t, afero.NewMemMapFs())
+			joinTestChain(t, a)
+
+			if test.install {
+				require.NoError(t, a.install(testVM, ""))
+				require.False(t, a.stateFile.InstallationRegistry[testVM].Dependency)
+			}
+
+			require.NoError(t, a.leaveChain(testChain))
+			require.NotContains(t, a.stateFile.ChainRegistry, testChain)
+
+			exists, err := afero.Exists(a.fs, filepath.Join(testPlugins, testVMID))
+			require.NoError(t, err)
+			require.Equal(t, test.wantVMKept, exists)
+			require.Equal(t, test.wantVMKept, a.stateFile.InstallationRegistry[testVM] != nil)
+
+			chains, err := a.nodeConfig.TrackedChains()
+			require.NoError(t, err)
+			require.Empty(t, chains)
+		})
+	}
+}
+
+// failingRemoveFs fails to remove the file at path.
+type failingRemoveFs struct {
+	afero.Fs
+	path string
+}
+
+func (f failingRemoveFs) Remove(name string) error {
+	if name == f.path {
+		return errors.New("permission denied")
+	}
+	return f.Fs.Remove(name)
+}
+
+func TestLeaveChainRollback(t *testing.T) {
+	fs := failingRemoveFs{
+		Fs:   afero.NewMemMapFs(),
+		path: filepath.Join(testPlugins, testVMID),
+	}
+	a := newTestLPM(t, fs)
+	joinTestChain(t, a)
+
+	require.ErrorContains(t, a.leaveChain(testChain), "permission denied")
+
+	// The chain is still joined, and still tracked.
+	require.Contains(t, a.stateFile.ChainRegistry, testChain)
+	require.Contains(t, a.stateFile.InstallationRegistry, testVM)
+	chains, err := a.nodeConfig.TrackedChains()
+	require.NoError(t, err)
+	require.Equal(t, []string{testChainID}, chains)
+}
@@ -0,0 +1,161 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package lpm
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/luxfi/lpm/checksum"
+	"github.com/luxfi/lpm/state"
+)
+
+// BinaryStatus describes whether an installed binary is what lpm installed.
+type BinaryStatus string
+
+const (
+	// BinaryOK means the binary matches the recorded checksum.
+	BinaryOK BinaryStatus = "ok"
+	// BinaryModified means the binary no longer matches the recorded
+	// checksum.
+	BinaryModified BinaryStatus = "modified"
+	// BinaryMissing means a recorded install has no binary on disk.
+	BinaryMissing BinaryStatus = "missing"
+	// BinaryUnverified means no checksum was recorded for the install.
+	BinaryUnverified BinaryStatus = "unverified"
+	// BinaryUnmanaged means the binary wasn't installed by lpm.
+	BinaryUnmanaged BinaryStatus = "unmanaged"
+)
+
+// InstalledVM is a VM binary in the plugin directory.
+type InstalledVM struct {
+	// Name the VM is recorded under in the installation registry. Empty for
+	// unmanaged binaries.
+	Name        string        `yaml:"name" json:"name"`
+	ID          string        `yaml:"id" json:"id"`
+	Version     string        `yaml:"version,omitempty" json:"version,omitempty"`
+	Commit      string        `yaml:"commit,omitempty" json:"commit,omitempty"`
+	Origin      *state.Origin `yaml:"origin,omitempty" json:"origin,omitempty"`
+	Path        string        `yaml:"path" json:"path"`
+	SHA256      string        `yaml:"sha256,omitempty" json:"sha256,omitempty"`
+	InstalledAt time.Time     `yaml:"installed-at,omitempty" json:"installed-at,omitzero"`
+	Status      BinaryStatus  `yaml:"status" json:"status"`
+}
+
+// List prints every VM binary in the plugin directory, including ones that
+// weren't installed by lpm.
+func (a *LPM) List(format OutputFormat) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	installed, err := a.listInstalled()
+	if err != nil {
+		return err
+	}
+
+	return output(format, installed, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "name\tid\tversion\torigin\tpath\tstatus")
+		for _, vm := range installed {
+			name := vm.Name
+			if name == "" {
+				name = "-"
+			}
+			version := vm.Version
+			if version == "" {
+				version = vm.Commit
+			}
+			if version == "" {
+				version = "-"
+			}
+			origin := "-"
+			if vm.Origin != nil {
+				origin = vm.Origin.String()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", name, vm.ID, version, origin, vm.Path, vm.Status)
+		}
+	})
+}
+
+// listInstalled returns every install in the registry alongside any other
+// binaries found in the plugin directory.
+func (a *LPM) listInstalled() ([]InstalledVM, error) {
+	checksummer := checksum.NewSHA256(a.fs)
+
+	result := make([]InstalledVM, 0, len(a.stateFile.InstallationRegistry))
+	managed := make(map[string]struct{}, len(a.stateFile.InstallationRegistry))
+	for name, info := range a.stateFile.InstallationRegistry {
+		path := info.BinaryPath(a.pluginPath)
+		managed[path] = struct{}{}
+
+		status := BinaryOK
+		switch hash := checksummer.Checksum(path); {
+		case hash == nil:
+			status = BinaryMissing
+		case info.SHA256 == "":
+			status = BinaryUnverified
+		case fmt.Sprintf("%x", hash) != info.SHA256:
+			status = BinaryModified
+		}
+
+		result = append(result, InstalledVM{
+			Name:        name,
+			ID:          info.ID,
+			Version:     info.Version,
+			Commit:      info.Commit,
+			Origin:      &info.Origin,
+			Path:        path,
+			SHA256:      info.SHA256,
+			InstalledAt: info.InstalledAt,
+			Status:      status,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	unmanaged := make([]InstalledVM, 0)
+	for _, dir := range []string{a.pluginPath, filepath.Join(a.pluginPath, state.LinkDir)} {
+		entries, err := afero.ReadDir(a.fs, dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			if _, ok := managed[path]; ok {
+				continue
+			}
+
+			vm := InstalledVM{
+				ID:     entry.Name(),
+				Path:   path,
+				Status: BinaryUnmanaged,
+			}
+			if hash := checksummer.Checksum(path); hash != nil {
+				vm.SHA256 = fmt.Sprintf("%x", hash)
+			}
+			unmanaged = append(unmanaged, vm)
+		}
+	}
+
+	return append(result, unmanaged...), nil
+}
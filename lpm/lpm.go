@@ -6,8 +6,10 @@ package lpm
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"syscall"
 	"text/tabwriter"
@@ -19,10 +21,14 @@ import (
 	"github.com/spf13/afero"
 
 	"github.com/luxfi/lpm/admin"
+	"github.com/luxfi/lpm/cache"
 	"github.com/luxfi/lpm/constant"
 	"github.com/luxfi/lpm/engine"
 	"github.com/luxfi/lpm/git"
+	"github.com/luxfi/lpm/node"
+	"github.com/luxfi/lpm/signature"
 	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/types"
 	"github.com/luxfi/lpm/url"
 	"github.com/luxfi/lpm/util"
 	"github.com/luxfi/lpm/workflow"
@@ -32,15 +38,53 @@ const (
 	repositoryDir = "repositories"
 	tmpDir        = "tmp"
 	lockFile      = "lpm.lock"
+	indexFile     = "search-index"
+	cacheDir      = "cache"
 )
 
+// DefaultKeepVersions is how many replaced binaries are kept per VM to roll
+// back to.
+const DefaultKeepVersions = 3
+
 type Config struct {
 	Directory        string
 	Auth             http.BasicAuth
 	AdminAPIEndpoint string
 	PluginDir        string
-	Fs               afero.Fs
-	StateFile        state.File
+	// KeysDir holds the public keys trusted to sign artifacts
+	KeysDir string
+	// AllowUnsigned installs artifacts that aren't signed
+	AllowUnsigned bool
+	// KeepVersions is how many replaced binaries are kept per VM to roll
+	// back to. Zero doesn't keep any.
+	KeepVersions int
+	// Repositories that take precedence when an alias is defined in more
+	// than one of them, highest priority first.
+	RepositoryPriority []string
+	// Platform to install artifacts for
+	Platform types.Platform
+	// Download configures timeouts, retries, the proxy and trusted CAs for
+	// every download
+	Download url.Config
+	// CacheDir holds downloaded artifacts by checksum. Defaults to a
+	// directory in Directory.
+	CacheDir string
+	// CachePolicy decides when cached artifacts are evicted
+	CachePolicy cache.Policy
+	// Offline never touches the network. Repositories are only read from
+	// disk and artifacts are only installed from the cache.
+	Offline bool
+	// NodeConfigFile is the node's JSON config file. Its plugin directory is
+	// set to PluginDir, joined chains are added to its tracked chains and
+	// chain configs are written next to it.
+	NodeConfigFile string
+	// Network chains are joined on, which selects their IDs and configs.
+	// Defaults to constant.DefaultNetwork.
+	Network string
+	// DryRun records what commands would do in a plan instead of doing it
+	DryRun    bool
+	Fs        afero.Fs
+	StateFile state.File
 }
 
 type LPM struct {
@@ -52,9 +96,23 @@ type LPM struct {
 	auth http.BasicAuth
 
 	adminClient admin.Client
+	nodeClient  node.Client
+	urlClient   url.Client
 	installer   workflow.Installer
+	keyring     *signature.Keyring
+	cache       *cache.Cache
+	nodeConfig  *node.ConfigFile
+	plan        *workflow.Plan
+
+	repositoryPriority []string
+	platform           types.Platform
+	allowUnsigned      bool
+	keepVersions       int
+	offline            bool
+	network            string
 
 	repositoriesPath string
+	indexPath        string
 	tmpPath          string
 	pluginPath       string
 	adminAPIEndpoint string
@@ -64,35 +122,99 @@ type LPM struct {
 }
 
 func New(config Config) (*LPM, error) {
-	if err := os.MkdirAll(config.Directory, perms.ReadWriteExecute); err != nil {
-		return nil, err
+	// A dry run doesn't change the filesystem, so it doesn't create lpm's
+	// directories either.
+	if !config.DryRun {
+		if err := os.MkdirAll(config.Directory, perms.ReadWriteExecute); err != nil {
+			return nil, err
+		}
 	}
 	stateFile, err := state.New(config.Directory)
 	if err != nil {
 		return nil, err
 	}
 
+	downloadConfig := config.Download
+	downloadConfig.Fs = config.Fs
+	downloadConfig.Offline = config.Offline
+	urlClient, err := url.NewClient(downloadConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cachePath := config.CacheDir
+	if cachePath == "" {
+		cachePath = filepath.Join(config.Directory, cacheDir)
+	}
+
+	var nodeConfig *node.ConfigFile
+	if config.NodeConfigFile != "" {
+		nodeConfig = node.NewConfigFile(config.Fs, config.NodeConfigFile)
+	}
+
+	network := config.Network
+	if network == "" {
+		network = constant.DefaultNetwork
+	}
+
+	var plan *workflow.Plan
+	if config.DryRun {
+		plan = &workflow.Plan{}
+	}
+
+	// The node's other APIs are served next to its admin API.
+	nodeURL := strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("http://%s", config.AdminAPIEndpoint), "/"), admin.APIPath)
+
 	repositoriesPath := filepath.Join(config.Directory, repositoryDir)
 	a := &LPM{
 		repoFactory: state.NewRepositoryFactory(repositoriesPath),
 		git:         git.RepositoryFactory{},
-		executor:    engine.NewWorkflowEngine(stateFile),
+		executor: engine.NewWorkflowEngine(engine.Config{
+			StateFile: stateFile,
+			Fs:        config.Fs,
+			TmpPath:   filepath.Join(config.Directory, tmpDir),
+			DryRun:    config.DryRun,
+		}),
 		auth:        config.Auth,
 		adminClient: admin.NewClient(fmt.Sprintf("http://%s", config.AdminAPIEndpoint)),
+		nodeClient:  node.NewClient(nodeURL),
+		urlClient:   urlClient,
 		installer: workflow.NewVMInstaller(
 			workflow.VMInstallerConfig{
-				Fs:        config.Fs,
-				URLClient: url.NewClient(),
+				Fs:              config.Fs,
+				URLClient:       urlClient,
+				StripComponents: workflow.DefaultStripComponents,
 			},
 		),
-		repositoriesPath: repositoriesPath,
-		tmpPath:          filepath.Join(config.Directory, tmpDir),
-		pluginPath:       config.PluginDir,
-		adminAPIEndpoint: config.AdminAPIEndpoint,
-		fs:               config.Fs,
-		stateFile:        stateFile,
-		lock:             fslock.New(filepath.Join(config.Directory, lockFile)),
+		keyring: signature.NewKeyring(config.Fs, config.KeysDir),
+		cache: cache.New(cache.Config{
+			Fs:     config.Fs,
+			Dir:    cachePath,
+			Policy: config.CachePolicy,
+		}),
+		nodeConfig:         nodeConfig,
+		plan:               plan,
+		repositoryPriority: config.RepositoryPriority,
+		platform:           config.Platform,
+		allowUnsigned:      config.AllowUnsigned,
+		keepVersions:       config.KeepVersions,
+		offline:            config.Offline,
+		network:            network,
+		repositoriesPath:   repositoriesPath,
+		indexPath:          filepath.Join(config.Directory, indexFile),
+		tmpPath:            filepath.Join(config.Directory, tmpDir),
+		pluginPath:         config.PluginDir,
+		adminAPIEndpoint:   config.AdminAPIEndpoint,
+		fs:                 config.Fs,
+		stateFile:          stateFile,
+		lock:               fslock.New(filepath.Join(config.Directory, lockFile)),
 	}
+
+	// A dry run can't bootstrap, since that would change state.
+	if config.DryRun {
+		return a, nil
+	}
+
 	if err := os.MkdirAll(a.repositoriesPath, perms.ReadWriteExecute); err != nil {
 		return nil, err
 	}
@@ -109,6 +231,11 @@ func New(config Config) (*LPM, error) {
 	repoMetadata := a.stateFile.Sources[constant.CoreAlias]
 
 	if repoMetadata.Commit == plumbing.ZeroHash.String() {
+		if config.Offline {
+			fmt.Println("Bootstrap not detected. Skipping it in offline mode, import a bundle to add definitions.")
+			return a, nil
+		}
+
 		fmt.Println("Bootstrap not detected. Bootstrapping...")
 		err := a.Update()
 		if err != nil {
@@ -122,13 +249,14 @@ func New(config Config) (*LPM, error) {
 
 func (a *LPM) parseAndRun(
 	alias string,
+	kind definitionKind,
 	command func(string) error,
 ) error {
 	if qualifiedName(alias) {
 		return command(alias)
 	}
 
-	fullName, err := a.getFullNameForAlias(alias)
+	fullName, err := a.getFullNameForAlias(alias, kind)
 	if err != nil {
 		return err
 	}
@@ -136,11 +264,20 @@ func (a *LPM) parseAndRun(
 	return command(fullName)
 }
 
+// Install installs a VM. The alias may be followed by a version constraint
+// like spacesvm@1.2.3 or spacesvm@^1.2.
 func (a *LPM) Install(alias string) error {
-	return a.parseAndRun(alias, a.install)
+	alias, constraint := util.ParseVersionedName(alias)
+	return a.loadInstalled(func() error {
+		return a.parseAndRun(alias, vmDefinition, func(name string) error {
+			return a.install(name, constraint)
+		})
+	})
 }
 
-func (a *LPM) install(name string) error {
+// install explicitly installs the VM name, so it's kept once no joined chain
+// needs it.
+func (a *LPM) install(name string, constraint string) error {
 	if err := a.lock.TryLock(); err != nil {
 		return err
 	}
@@ -148,8 +285,16 @@ func (a *LPM) install(name string) error {
 		_ = a.lock.Unlock()
 	}()
 
-	_, ok := a.stateFile.InstallationRegistry[name]
+	installInfo, ok := a.stateFile.InstallationRegistry[name]
+	if ok && installInfo.Dependency {
+		return a.keepInstalled(name, installInfo)
+	}
 	if ok {
+		if constraint != "" && constraint != installInfo.Constraint {
+			fmt.Printf("VM %s is already installed. Use upgrade --vm %s%s%s to change its version. Skipping.\n",
+				name, name, constant.VersionDelimiter, constraint)
+			return nil
+		}
 		fmt.Printf("VM %s is already installed. Skipping.\n", name)
 		return nil
 	}
@@ -163,26 +308,160 @@ func (a *LPM) install(name string) error {
 	}
 
 	workflow := workflow.NewInstall(workflow.InstallConfig{
-		Name:         name,
-		Plugin:       plugin,
-		Organization: organization,
-		Repo:         repo,
-		TmpPath:      a.tmpPath,
-		PluginPath:   a.pluginPath,
-		StateFile:    a.stateFile,
-		Repository:   repository,
-		Fs:           a.fs,
-		Installer:    a.installer,
+		Name:          name,
+		Plugin:        plugin,
+		Organization:  organization,
+		Repo:          repo,
+		TmpPath:       a.tmpPath,
+		PluginPath:    a.pluginPath,
+		Constraint:    constraint,
+		Platform:      a.platform,
+		AllowUnsigned: a.allowUnsigned,
+		KeepVersions:  a.keepVersions,
+		StateFile:     a.stateFile,
+		Repository:    repository,
+		Fs:            a.fs,
+		Installer:     a.installer,
+		Verifier:      a.keyring,
+		Cache:         a.cache,
+		Plan:          a.plan,
+	})
+
+	return a.installVM(workflow)
+}
+
+// keepInstalled marks a VM that was installed because a chain needed it as
+// installed explicitly, so it's kept once no joined chain needs it.
+func (a *LPM) keepInstalled(name string, installInfo *state.InstallInfo) error {
+	if a.plan != nil {
+		a.plan.Register(name, "installed explicitly")
+		return nil
+	}
+
+	installInfo.Dependency = false
+	if err := a.stateFile.Commit(); err != nil {
+		return fmt.Errorf("failed to commit the statefile: %w", err)
+	}
+
+	fmt.Printf("VM %s is already installed. It's now kept when the chains that need it are left.\n", name)
+	return nil
+}
+
+// InstallGitHub installs a VM binary from a GitHub release.
+func (a *LPM) InstallGitHub(config workflow.InstallGitHubConfig) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	config.PluginDir = a.pluginPath
+	config.AllowUnsigned = a.allowUnsigned
+	config.StateFile = a.stateFile
+	config.Fs = a.fs
+	config.Verifier = a.keyring
+	config.Client = a.urlClient
+	config.Cache = a.cache
+	config.Plan = a.plan
+
+	return a.loadInstalled(func() error {
+		return a.installVM(workflow.NewInstallGitHub(config))
 	})
+}
 
-	return a.executor.Execute(workflow)
+// InstallGitLab installs a VM binary from a GitLab release.
+func (a *LPM) InstallGitLab(config workflow.InstallGitLabConfig) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	config.PluginDir = a.pluginPath
+	config.AllowUnsigned = a.allowUnsigned
+	config.StateFile = a.stateFile
+	config.Fs = a.fs
+	config.Verifier = a.keyring
+	config.Client = a.urlClient
+	config.Cache = a.cache
+	config.Plan = a.plan
+
+	return a.loadInstalled(func() error {
+		return a.installVM(workflow.NewInstallGitLab(config))
+	})
 }
 
-func (a *LPM) Uninstall(alias string) error {
-	return a.parseAndRun(alias, a.uninstall)
+// InstallURL installs a VM binary from a direct download URL.
+func (a *LPM) InstallURL(config workflow.InstallURLConfig) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	config.PluginDir = a.pluginPath
+	config.AllowUnsigned = a.allowUnsigned
+	config.StateFile = a.stateFile
+	config.Fs = a.fs
+	config.Verifier = a.keyring
+	config.Client = a.urlClient
+	config.Cache = a.cache
+	config.Plan = a.plan
+
+	return a.loadInstalled(func() error {
+		return a.installVM(workflow.NewInstallURL(config))
+	})
 }
 
-func (a *LPM) uninstall(name string) error {
+// InstallSource builds a VM from source and installs it.
+func (a *LPM) InstallSource(config workflow.InstallSourceConfig) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	config.PluginDir = a.pluginPath
+	config.StateFile = a.stateFile
+	config.Fs = a.fs
+	config.Plan = a.plan
+
+	return a.loadInstalled(func() error {
+		return a.installVM(workflow.NewInstallSource(config))
+	})
+}
+
+// Link links a locally built VM binary into the plugin directory.
+func (a *LPM) Link(config workflow.LinkConfig) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	config.PluginDir = a.pluginPath
+	config.StateFile = a.stateFile
+	config.Fs = a.fs
+
+	return a.loadInstalled(func() error {
+		return a.installVM(workflow.NewLink(config))
+	})
+}
+
+// Uninstall uninstalls the VM alias. Unless force is set, VMs that joined
+// chains still need aren't uninstalled.
+func (a *LPM) Uninstall(alias string, force bool) error {
+	return a.parseAndRun(alias, vmDefinition, func(name string) error {
+		return a.uninstall(name, force)
+	})
+}
+
+func (a *LPM) uninstall(name string, force bool) error {
 	if err := a.lock.TryLock(); err != nil {
 		return err
 	}
@@ -199,17 +478,23 @@ func (a *LPM) uninstall(name string) error {
 			StateFile:  a.stateFile,
 			Fs:         a.fs,
 			PluginPath: a.pluginPath,
+			Force:      force,
+			Plan:       a.plan,
 		},
 	)
 
 	return a.executor.Execute(wf)
 }
 
-func (a *LPM) JoinChain(alias string) error {
-	return a.parseAndRun(alias, a.joinChain)
+// JoinChain joins the chain alias. Unless noTrack is set, the chain is added
+// to the tracked chains in the node's config file.
+func (a *LPM) JoinChain(alias string, noTrack bool) error {
+	return a.parseAndRun(alias, chainDefinition, func(name string) error {
+		return a.joinChain(name, noTrack)
+	})
 }
 
-func (a *LPM) joinChain(fullName string) error {
+func (a *LPM) joinChain(fullName string, noTrack bool) error {
 	alias, plugin := util.ParseQualifiedName(fullName)
 	repo, err := a.repoFactory.GetRepository(alias)
 	if err != nil {
@@ -221,50 +506,88 @@ func (a *LPM) joinChain(fullName string) error {
 		return err
 	}
 
-	chain := definition.Definition
-	chainID, _ := chain.GetID(constant.DefaultNetwork)
+	chainID, err := networkChainID(fullName, definition.Definition, a.network)
+	if err != nil {
+		return err
+	}
 
-	// TODO prompt user, add force flag
-	fmt.Printf("Installing virtual machines for chain %s.\n", chainID)
-	for _, vm := range chain.VMs {
-		if err := a.Install(strings.Join([]string{alias, vm}, constant.QualifiedNameDelimiter)); err != nil {
-			return err
-		}
+	if err := a.lock.TryLock(); err != nil {
+		return err
 	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
 
-	fmt.Printf("Updating virtual machines...\n")
-	if err := a.adminClient.LoadVMs(); errors.Is(err, syscall.ECONNREFUSED) {
-		fmt.Printf("Node at %s was offline. Virtual machines will be available upon node startup.\n", a.adminAPIEndpoint)
-	} else if err != nil {
+	wf := workflow.NewJoinChain(workflow.JoinChainConfig{
+		Executor:      a.executor,
+		Name:          fullName,
+		Network:       a.network,
+		ChainID:       chainID,
+		Definition:    definition,
+		RepoFactory:   a.repoFactory,
+		TmpPath:       a.tmpPath,
+		PluginPath:    a.pluginPath,
+		Platform:      a.platform,
+		AllowUnsigned: a.allowUnsigned,
+		KeepVersions:  a.keepVersions,
+		Installer:     a.installer,
+		Verifier:      a.keyring,
+		Cache:         a.cache,
+		NodeConfig:    a.nodeConfig,
+		NoTrack:       noTrack,
+		StateFile:     a.stateFile,
+		Fs:            a.fs,
+		Plan:          a.plan,
+	})
+	if err := a.executor.Execute(wf); err != nil {
 		return err
 	}
 
-	fmt.Printf("Whitelisting chain %s...\n", chainID)
-	if err := a.adminClient.WhitelistChain(chainID); errors.Is(err, syscall.ECONNREFUSED) {
-		fmt.Printf("Node at %s was offline. You'll need to whitelist the chain upon node restart.\n", a.adminAPIEndpoint)
-	} else if err != nil {
+	// The chain is joined at this point, so it can be left if the node
+	// can't load its VMs.
+	if err := a.configurePluginDir(); err != nil {
 		return err
 	}
 
-	fmt.Printf("Finished installing virtual machines for chain %s.\n", chain.ID)
-	return nil
+	var vms []string
+	if info, ok := a.stateFile.ChainRegistry[fullName]; ok {
+		vms = info.VMs
+	}
+	return a.loadVMs(a.vmIDs(vms))
 }
 
-func (a *LPM) Info(alias string) error {
-	if qualifiedName(alias) {
-		return a.install(alias)
+// networkChainID returns the ID of the chain named name on network.
+func networkChainID(name string, chain types.Chain, network string) (string, error) {
+	if chainID, ok := chain.GetID(network); ok && chainID != "" {
+		return chainID, nil
 	}
 
-	fullName, err := a.getFullNameForAlias(alias)
-	if err != nil {
-		return err
+	if len(chain.ID) == 0 {
+		return "", fmt.Errorf("chain %s has no ID on network %s", name, network)
 	}
-
-	return a.info(fullName)
+	networks := slices.Sorted(maps.Keys(chain.ID))
+	return "", fmt.Errorf("chain %s has no ID on network %s. Use --network with one of %s",
+		name, network, strings.Join(networks, ", "))
 }
 
-func (a *LPM) info(_ string) error {
-	return nil
+// loadVMs tells the node to load the virtual machines in the plugin
+// directory, and confirms it loaded the VMs with vmIDs.
+func (a *LPM) loadVMs(vmIDs []string) error {
+	if a.plan != nil {
+		a.plan.Call("admin.loadVMs", a.adminAPIEndpoint)
+		return nil
+	}
+
+	fmt.Printf("Updating virtual machines...\n")
+	newVMs, failedVMs, err := a.adminClient.LoadVMs()
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		fmt.Printf("Node at %s was offline. Virtual machines will be available upon node startup.\n", a.adminAPIEndpoint)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return a.verifyVMs(vmIDs, newVMs, failedVMs)
 }
 
 func (a *LPM) Update() error {
@@ -282,10 +605,13 @@ func (a *LPM) Update() error {
 		PluginPath:       a.pluginPath,
 		Installer:        a.installer,
 		RepositoriesPath: a.repositoriesPath,
+		IndexPath:        a.indexPath,
 		Auth:             a.auth,
 		RepoFactory:      a.repoFactory,
 		Fs:               a.fs,
 		Git:              a.git,
+		Offline:          a.offline,
+		Plan:             a.plan,
 	})
 
 	if err := a.executor.Execute(workflow); err != nil {
@@ -305,36 +631,57 @@ func (a *LPM) Upgrade(alias string) error {
 
 	// If we have an alias specified, upgrade the specified VM.
 	if alias != "" {
-		return a.parseAndRun(alias, a.upgradeVM)
+		alias, constraint := util.ParseVersionedName(alias)
+		return a.loadInstalled(func() error {
+			return a.parseAndRun(alias, vmDefinition, func(name string) error {
+				return a.upgradeVM(name, constraint)
+			})
+		})
 	}
 
 	// Otherwise, just upgrade everything.
 	wf := workflow.NewUpgrade(workflow.UpgradeConfig{
-		Executor:    a.executor,
-		RepoFactory: a.repoFactory,
-		StateFile:   a.stateFile,
-		TmpPath:     a.tmpPath,
-		PluginPath:  a.pluginPath,
-		Installer:   a.installer,
-		Fs:          a.fs,
-		Git:         a.git,
+		Executor:      a.executor,
+		RepoFactory:   a.repoFactory,
+		StateFile:     a.stateFile,
+		TmpPath:       a.tmpPath,
+		PluginPath:    a.pluginPath,
+		Platform:      a.platform,
+		AllowUnsigned: a.allowUnsigned,
+		KeepVersions:  a.keepVersions,
+		Installer:     a.installer,
+		Verifier:      a.keyring,
+		Cache:         a.cache,
+		NodeConfig:    a.nodeConfig,
+		Plan:          a.plan,
+		Fs:            a.fs,
+		Git:           a.git,
 	})
 
-	return a.executor.Execute(wf)
+	return a.loadInstalled(func() error {
+		return a.executor.Execute(wf)
+	})
 }
 
-func (a *LPM) upgradeVM(name string) error {
+func (a *LPM) upgradeVM(name string, constraint string) error {
 	return a.executor.Execute(workflow.NewUpgradeVM(
 		workflow.UpgradeVMConfig{
-			Executor:    a.executor,
-			FullVMName:  name,
-			RepoFactory: a.repoFactory,
-			StateFile:   a.stateFile,
-			TmpPath:     a.tmpPath,
-			PluginPath:  a.pluginPath,
-			Installer:   a.installer,
-			Fs:          a.fs,
-			Git:         a.git,
+			Executor:      a.executor,
+			FullVMName:    name,
+			Constraint:    constraint,
+			RepoFactory:   a.repoFactory,
+			StateFile:     a.stateFile,
+			TmpPath:       a.tmpPath,
+			PluginPath:    a.pluginPath,
+			Platform:      a.platform,
+			AllowUnsigned: a.allowUnsigned,
+			KeepVersions:  a.keepVersions,
+			Installer:     a.installer,
+			Verifier:      a.keyring,
+			Cache:         a.cache,
+			Plan:          a.plan,
+			Fs:            a.fs,
+			Git:           a.git,
 		},
 	))
 }
@@ -396,27 +743,3 @@ func (a *LPM) ListRepositories() error {
 	w.Flush()
 	return nil
 }
-
-func qualifiedName(name string) bool {
-	parsed := strings.Split(name, ":")
-	return len(parsed) > 1
-}
-
-func (a *LPM) getFullNameForAlias(alias string) (string, error) {
-	matches := make([]string, 0)
-
-	for alias := range a.stateFile.Sources {
-		// See if this repo exists
-		_, err := a.repoFactory.GetRepository(alias)
-		if err != nil {
-			return "", err
-		}
-
-		matches = append(matches, alias)
-	}
-	if len(matches) > 1 {
-		return "", fmt.Errorf("more than one match found for %s. Please specify the fully qualified name. Matches: %s", alias, matches)
-	}
-
-	return fmt.Sprintf("%s:%s", matches[0], alias), nil
-}
@@ -0,0 +1,108 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package lpm
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/types"
+)
+
+func TestNewDryRun(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".lpm")
+
+	_, err := New(Config{
+		Directory: dir,
+		PluginDir: filepath.Join(dir, "plugins"),
+		DryRun:    true,
+		Fs:        afero.NewOsFs(),
+	})
+	require.NoError(t, err)
+
+	exists, err := afero.DirExists(afero.NewOsFs(), dir)
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestNetworkChainID(t *testing.T) {
+	tests := []struct {
+		name    string
+		ids     map[string]string
+		network string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "selected network",
+			ids:     map[string]string{"mainnet": "mainnetID", "testnet": "testnetID"},
+			network: "mainnet",
+			want:    "mainnetID",
+		},
+		{
+			name:    "missing network",
+			ids:     map[string]string{"testnet": "testnetID", "devnet": "devnetID"},
+			network: "mainnet",
+			wantErr: "chain organization/repository:chain has no ID on network mainnet. Use --network with one of devnet, testnet",
+		},
+		{
+			name:    "empty ID",
+			ids:     map[string]string{"mainnet": ""},
+			network: "mainnet",
+			wantErr: "has no ID on network mainnet",
+		},
+		{
+			name:    "no IDs",
+			network: "mainnet",
+			wantErr: "chain organization/repository:chain has no ID on network mainnet",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chainID, err := networkChainID("organization/repository:chain", types.Chain{ID: test.ids}, test.network)
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, chainID)
+		})
+	}
+}
+
+func TestJoinChainRollback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	a := newTestLPM(t, afero.NewMemMapFs())
+	require.NoError(t, afero.WriteFile(a.fs, testNodePath, []byte("{}"), 0o600))
+	a.stateFile.InstallationRegistry[testVM] = &state.InstallInfo{ID: testVMID}
+
+	repository := state.NewMockRepository(ctrl)
+	repository.EXPECT().GetChain("chain").Return(state.Definition[types.Chain]{
+		Definition: types.Chain{
+			ID:  map[string]string{"testnet": testChainID},
+			VMs: []string{"vm"},
+			Configs: map[string]types.ChainConfig{
+				"testnet": {Config: &types.ChainFile{Data: "{}", SHA256: "bad"}},
+			},
+		},
+	}, nil)
+	repoFactory := state.NewMockRepositoryFactory(ctrl)
+	repoFactory.EXPECT().GetRepository("organization/repository").Return(repository, nil)
+	a.repoFactory = repoFactory
+
+	require.ErrorContains(t, a.joinChain(testChain, false), "checksum of config.json")
+
+	// Nothing the join did before it failed is kept.
+	require.NotContains(t, a.stateFile.ChainRegistry, testChain)
+	require.Empty(t, a.stateFile.InstallationRegistry[testVM].Chains)
+	chains, err := a.nodeConfig.TrackedChains()
+	require.NoError(t, err)
+	require.Empty(t, chains)
+}
@@ -0,0 +1,205 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package lpm
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/luxfi/lpm/node"
+	"github.com/luxfi/lpm/workflow"
+)
+
+// installVM runs an install workflow and points the node at the plugin
+// directory it installed to.
+func (a *LPM) installVM(wf workflow.Workflow) error {
+	if err := a.executor.Execute(wf); err != nil {
+		return err
+	}
+
+	return a.configurePluginDir()
+}
+
+// loadInstalled runs install and has the node load the VMs it installed,
+// upgraded or rolled back.
+func (a *LPM) loadInstalled(install func() error) error {
+	if a.plan != nil {
+		steps := len(a.plan.Steps())
+		if err := install(); err != nil {
+			return err
+		}
+		if len(a.plan.Steps()) == steps {
+			return nil
+		}
+		return a.loadVMs(nil)
+	}
+
+	before := a.installedAt()
+	if err := install(); err != nil {
+		return err
+	}
+
+	vmIDs := a.changedVMs(before)
+	if len(vmIDs) == 0 {
+		return nil
+	}
+	return a.loadVMs(vmIDs)
+}
+
+// installedAt returns when each VM in the installation registry was
+// installed.
+func (a *LPM) installedAt() map[string]time.Time {
+	installedAt := make(map[string]time.Time, len(a.stateFile.InstallationRegistry))
+	for name, info := range a.stateFile.InstallationRegistry {
+		installedAt[name] = info.InstalledAt
+	}
+
+	return installedAt
+}
+
+// changedVMs returns the IDs of the VMs that were installed since before was
+// taken.
+func (a *LPM) changedVMs(before map[string]time.Time) []string {
+	vmIDs := make([]string, 0)
+	for name, info := range a.stateFile.InstallationRegistry {
+		if installedAt, ok := before[name]; !ok || !installedAt.Equal(info.InstalledAt) {
+			vmIDs = append(vmIDs, info.ID)
+		}
+	}
+	slices.Sort(vmIDs)
+
+	return slices.Compact(vmIDs)
+}
+
+// vmIDs returns the IDs of the installed VMs in names.
+func (a *LPM) vmIDs(names []string) []string {
+	vmIDs := make([]string, 0, len(names))
+	for _, name := range names {
+		if info, ok := a.stateFile.InstallationRegistry[name]; ok {
+			vmIDs = append(vmIDs, info.ID)
+		}
+	}
+
+	return vmIDs
+}
+
+// verifyVMs reports the VMs the node just loaded or failed to load, and
+// returns an error if any of the VMs with vmIDs isn't loaded. The node's
+// health is reported too, since a VM that's loaded can still fail its chains.
+func (a *LPM) verifyVMs(vmIDs []string, newVMs map[string][]string, failedVMs map[string]string) error {
+	for _, vmID := range slices.Sorted(maps.Keys(newVMs)) {
+		fmt.Printf("Node loaded virtual machine %s%s.\n", vmID, formatAliases(newVMs[vmID]))
+	}
+	for _, vmID := range slices.Sorted(maps.Keys(failedVMs)) {
+		fmt.Printf("Node failed to load virtual machine %s: %s\n", vmID, failedVMs[vmID])
+	}
+
+	problems := make([]string, 0)
+	unconfirmed := make([]string, 0)
+	for _, vmID := range vmIDs {
+		if reason, ok := failedVMs[vmID]; ok {
+			problems = append(problems, fmt.Sprintf("virtual machine %s failed to load: %s", vmID, reason))
+		} else if _, ok := newVMs[vmID]; !ok {
+			unconfirmed = append(unconfirmed, vmID)
+		}
+	}
+
+	// The node only reports the VMs it hadn't loaded before, so the others
+	// are looked up in every VM it has loaded.
+	if len(unconfirmed) > 0 {
+		loadedVMs, err := a.nodeClient.VMs()
+		if err != nil {
+			fmt.Printf("Warning - couldn't confirm node at %s loaded %s: %s\n", a.adminAPIEndpoint, strings.Join(unconfirmed, ", "), err)
+			unconfirmed = nil
+		}
+
+		for _, vmID := range unconfirmed {
+			if _, ok := loadedVMs[vmID]; ok {
+				fmt.Printf("Node already had virtual machine %s loaded. Restart the node to run the installed binary.\n", vmID)
+				continue
+			}
+
+			pluginPath, err := filepath.Abs(a.pluginPath)
+			if err != nil {
+				return err
+			}
+			problems = append(problems, fmt.Sprintf("virtual machine %s isn't loaded. Check that the node's %s is %s",
+				vmID, node.PluginDirKey, pluginPath))
+		}
+	}
+
+	a.reportHealth()
+
+	if len(problems) > 0 {
+		return fmt.Errorf("node at %s didn't load every virtual machine: %s", a.adminAPIEndpoint, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// reportHealth warns about the node's failing health checks.
+func (a *LPM) reportHealth() {
+	healthy, failedChecks, err := a.nodeClient.Health()
+	switch {
+	case err != nil:
+		fmt.Printf("Warning - couldn't check the health of node at %s: %s\n", a.adminAPIEndpoint, err)
+		return
+	case healthy:
+		return
+	}
+
+	fmt.Printf("Warning - node at %s is unhealthy.\n", a.adminAPIEndpoint)
+	for _, check := range slices.Sorted(maps.Keys(failedChecks)) {
+		fmt.Printf("  %s: %s\n", check, failedChecks[check])
+	}
+}
+
+// formatAliases returns aliases in parentheses, or an empty string if there
+// aren't any.
+func formatAliases(aliases []string) string {
+	if len(aliases) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" (%s)", strings.Join(aliases, ", "))
+}
+
+// configurePluginDir sets the node config's plugin directory to lpm's if the
+// node doesn't load VMs from a directory of its own.
+func (a *LPM) configurePluginDir() error {
+	if a.nodeConfig == nil {
+		return nil
+	}
+
+	pluginPath, err := filepath.Abs(a.pluginPath)
+	if err != nil {
+		return err
+	}
+
+	dir, err := a.nodeConfig.PluginDir()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", a.nodeConfig.Path(), err)
+	}
+	switch {
+	case dir != "" && filepath.Clean(dir) == pluginPath:
+		return nil
+	case dir != "":
+		fmt.Printf("Warning - the node loads virtual machines from %s, not %s. Set %s in %s or use --plugin-path %s.\n",
+			dir, pluginPath, node.PluginDirKey, a.nodeConfig.Path(), dir)
+		return nil
+	case a.plan != nil:
+		a.plan.Write(a.nodeConfig.Path(), fmt.Sprintf("sets %s to %s", node.PluginDirKey, pluginPath))
+		return nil
+	}
+
+	if _, err := a.nodeConfig.SetPluginDir(pluginPath); err != nil {
+		return fmt.Errorf("failed to set %s in %s: %w", node.PluginDirKey, a.nodeConfig.Path(), err)
+	}
+	fmt.Printf("Set %s in %s to %s. Restart the node to load virtual machines from it.\n",
+		node.PluginDirKey, a.nodeConfig.Path(), pluginPath)
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package lpm
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/lpm/admin"
+	"github.com/luxfi/lpm/node"
+)
+
+func TestLoadVMs(t *testing.T) {
+	const vmID = "vmID"
+
+	tests := []struct {
+		name      string
+		newVMs    map[string][]string
+		failedVMs map[string]string
+		loadErr   error
+		// loadedVMs are every VM the node has loaded, or nil if they
+		// shouldn't be looked up
+		loadedVMs map[string][]string
+		vmsErr    error
+		wantErr   string
+	}{
+		{
+			name:   "loaded",
+			newVMs: map[string][]string{vmID: {"vm"}},
+		},
+		{
+			name:      "already loaded",
+			newVMs:    map[string][]string{},
+			loadedVMs: map[string][]string{vmID: {"vm"}},
+		},
+		{
+			name:      "failed",
+			failedVMs: map[string]string{vmID: "exec format error"},
+			wantErr:   "virtual machine vmID failed to load: exec format error",
+		},
+		{
+			name:      "other VM failed",
+			newVMs:    map[string][]string{vmID: {"vm"}},
+			failedVMs: map[string]string{"otherID": "exec format error"},
+		},
+		{
+			name:      "not loaded",
+			loadedVMs: map[string][]string{"otherID": {"other"}},
+			wantErr:   "virtual machine vmID isn't loaded. Check that the node's plugin-dir is",
+		},
+		{
+			name:      "loaded VMs unavailable",
+			loadedVMs: map[string][]string{},
+			vmsErr:    errors.New("info API disabled"),
+		},
+		{
+			name:    "offline",
+			loadErr: fmt.Errorf("failed to send request: %w", syscall.ECONNREFUSED),
+		},
+		{
+			name:    "load failed",
+			loadErr: errors.New("couldn't read plugin directory"),
+			wantErr: "couldn't read plugin directory",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			adminClient := admin.NewMockClient(ctrl)
+			nodeClient := node.NewMockClient(ctrl)
+
+			adminClient.EXPECT().LoadVMs().Return(test.newVMs, test.failedVMs, test.loadErr)
+			if test.loadedVMs != nil {
+				nodeClient.EXPECT().VMs().Return(test.loadedVMs, test.vmsErr)
+			}
+			if test.loadErr == nil {
+				nodeClient.EXPECT().Health().Return(false, map[string]string{"chain": "not bootstrapped"}, nil)
+			}
+
+			a := &LPM{
+				adminClient:      adminClient,
+				nodeClient:       nodeClient,
+				pluginPath:       "plugins",
+				adminAPIEndpoint: "127.0.0.1:9650",
+			}
+			err := a.loadVMs([]string{vmID})
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package lpm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OutputFormat is how a command prints its results.
+type OutputFormat string
+
+const (
+	TableOutput OutputFormat = "table"
+	JSONOutput  OutputFormat = "json"
+	YAMLOutput  OutputFormat = "yaml"
+)
+
+// OutputFormats are all supported output formats.
+var OutputFormats = []OutputFormat{TableOutput, JSONOutput, YAMLOutput}
+
+func ParseOutputFormat(format string) (OutputFormat, error) {
+	for _, f := range OutputFormats {
+		if string(f) == format {
+			return f, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown output format %s (must be one of %v)", format, OutputFormats)
+}
+
+// output prints v to stdout in the requested format. Machine-readable formats
+// serialize v directly, while the table format is written by table.
+func output(format OutputFormat, v interface{}, table func(w *tabwriter.Writer)) error {
+	switch format {
+	case JSONOutput:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case YAMLOutput:
+		encoder := yaml.NewEncoder(os.Stdout)
+		defer encoder.Close()
+		return encoder.Encode(v)
+	case TableOutput:
+		w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+		table(w)
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown output format %s", format)
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package lpm
+
+import (
+	"fmt"
+	"text/tabwriter"
+)
+
+// PrintPlan prints what a dry run would have done. It doesn't print anything
+// unless this is a dry run.
+func (a *LPM) PrintPlan() error {
+	if a.plan == nil {
+		return nil
+	}
+
+	steps := a.plan.Steps()
+	if len(steps) == 0 {
+		fmt.Printf("Dry run, nothing would be changed.\n")
+		return nil
+	}
+
+	fmt.Printf("Dry run, nothing was changed. The plan is:\n")
+	return output(TableOutput, steps, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "action\ttarget\tdetail")
+		for _, step := range steps {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", step.Action, step.Target, step.Detail)
+		}
+	})
+}
@@ -0,0 +1,37 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package lpm
+
+import (
+	"github.com/luxfi/lpm/workflow"
+)
+
+// Rollback restores a VM binary kept when it was upgraded and tells the node
+// to reload its virtual machines. to is the version or commit to roll back to,
+// or empty for the most recently replaced binary.
+func (a *LPM) Rollback(alias string, to string) error {
+	return a.parseAndRun(alias, vmDefinition, func(name string) error {
+		return a.rollback(name, to)
+	})
+}
+
+func (a *LPM) rollback(name string, to string) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	wf := workflow.NewRollback(workflow.RollbackConfig{
+		Name:       name,
+		To:         to,
+		PluginPath: a.pluginPath,
+		StateFile:  a.stateFile,
+		Fs:         a.fs,
+	})
+	return a.loadInstalled(func() error {
+		return a.executor.Execute(wf)
+	})
+}
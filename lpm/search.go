@@ -0,0 +1,56 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package lpm
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/luxfi/lpm/search"
+)
+
+// Search prints the VMs and chains in tracked repositories that match query.
+func (a *LPM) Search(query string, format OutputFormat) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	index, err := search.Load(a.fs, a.indexPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		// The index is built on update, so it's only missing if we haven't
+		// updated since search was introduced.
+		index, err = search.Build(a.fs, a.repositoriesPath)
+		if err == nil {
+			err = index.Save(a.fs, a.indexPath)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	results := make([]search.Result, 0)
+	for _, result := range index.Search(query) {
+		// Skip definitions from repositories removed since the last update.
+		if _, ok := a.stateFile.Sources[result.Repository]; ok {
+			results = append(results, result)
+		}
+	}
+
+	return output(format, results, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "name\tkind\trepository\tid\tdescription")
+		for _, result := range results {
+			id := strings.Join(result.IDs, ", ")
+			if id == "" {
+				id = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", result.Name, result.Kind, result.Repository, id, result.Description)
+		}
+	})
+}
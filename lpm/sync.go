@@ -0,0 +1,65 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package lpm
+
+import (
+	"fmt"
+
+	"github.com/luxfi/lpm/manifest"
+	"github.com/luxfi/lpm/workflow"
+)
+
+// WriteLockfile pins every installed plugin in a lockfile at path.
+func (a *LPM) WriteLockfile(path string) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	m := manifest.FromRegistry(a.stateFile.InstallationRegistry)
+	if err := m.Save(a.fs, path); err != nil {
+		return err
+	}
+
+	fmt.Printf("Locked %d plugins in %s.\n", len(m.Plugins), path)
+	return nil
+}
+
+// Sync installs, reinstalls and uninstalls plugins until the installed
+// plugins match the lockfile at path.
+func (a *LPM) Sync(path string) error {
+	if err := a.lock.TryLock(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = a.lock.Unlock()
+	}()
+
+	m, err := manifest.Load(a.fs, path)
+	if err != nil {
+		return err
+	}
+
+	wf := workflow.NewSync(workflow.SyncConfig{
+		Executor:      a.executor,
+		Manifest:      m,
+		RepoFactory:   a.repoFactory,
+		StateFile:     a.stateFile,
+		TmpPath:       a.tmpPath,
+		PluginPath:    a.pluginPath,
+		Platform:      a.platform,
+		AllowUnsigned: a.allowUnsigned,
+		Installer:     a.installer,
+		Verifier:      a.keyring,
+		Client:        a.urlClient,
+		Cache:         a.cache,
+		Fs:            a.fs,
+	})
+
+	return a.loadInstalled(func() error {
+		return a.executor.Execute(wf)
+	})
+}
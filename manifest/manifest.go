@@ -0,0 +1,124 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package manifest
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/luxfi/lpm/state"
+)
+
+const (
+	// DefaultFile is the name of the lockfile in a project.
+	DefaultFile = "lpm-lock.yaml"
+
+	// Version is the latest lockfile schema version.
+	Version = 1
+)
+
+// Manifest pins the exact set of plugins a node should have installed.
+type Manifest struct {
+	// Schema version this lockfile was written with
+	Version int      `yaml:"version"`
+	Plugins []Plugin `yaml:"plugins"`
+}
+
+// Plugin is a single pinned plugin.
+type Plugin struct {
+	// Name the plugin is recorded under in the installation registry
+	Name string `yaml:"name"`
+	ID   string `yaml:"id"`
+	// Version of the plugin, if it was installed with one
+	Version string `yaml:"version,omitempty"`
+	// Commit of the definition the plugin was installed from, for plugins
+	// installed from a plugin repository
+	Commit string `yaml:"commit,omitempty"`
+	// Constraint upgrades of the plugin have to satisfy
+	Constraint string `yaml:"constraint,omitempty"`
+	// SHA256 of the installed binary
+	SHA256 string       `yaml:"sha256,omitempty"`
+	Origin state.Origin `yaml:"origin"`
+}
+
+// FromRegistry returns a manifest pinning every install in registry.
+func FromRegistry(registry map[string]*state.InstallInfo) *Manifest {
+	m := &Manifest{
+		Version: Version,
+		Plugins: make([]Plugin, 0, len(registry)),
+	}
+
+	for name, info := range registry {
+		m.Plugins = append(m.Plugins, Plugin{
+			Name:       name,
+			ID:         info.ID,
+			Version:    info.Version,
+			Commit:     info.Commit,
+			Constraint: info.Constraint,
+			SHA256:     info.SHA256,
+			Origin:     info.Origin,
+		})
+	}
+
+	sort.Slice(m.Plugins, func(i, j int) bool {
+		return m.Plugins[i].Name < m.Plugins[j].Name
+	})
+
+	return m
+}
+
+// Matches returns whether info is an install of exactly this plugin.
+func (p Plugin) Matches(info *state.InstallInfo) bool {
+	if info.ID != p.ID || info.Version != p.Version || info.SHA256 != p.SHA256 || info.Origin != p.Origin {
+		return false
+	}
+
+	// Versioned installs are pinned by their version, so the definition they
+	// came from may have changed since without affecting the binary.
+	return p.Version != "" || info.Commit == p.Commit
+}
+
+// Load reads the lockfile at path.
+func Load(fs afero.Fs, path string) (*Manifest, error) {
+	b, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	if err := yaml.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile %s: %w", path, err)
+	}
+
+	if m.Version > Version {
+		return nil, fmt.Errorf("lockfile %s has version %d but this lpm only supports up to version %d, please upgrade lpm", path, m.Version, Version)
+	}
+
+	names := make(map[string]struct{}, len(m.Plugins))
+	for _, plugin := range m.Plugins {
+		if plugin.Name == "" || plugin.ID == "" {
+			return nil, fmt.Errorf("lockfile %s has a plugin without a name or id", path)
+		}
+		if _, ok := names[plugin.Name]; ok {
+			return nil, fmt.Errorf("lockfile %s lists %s more than once", path, plugin.Name)
+		}
+		names[plugin.Name] = struct{}{}
+	}
+
+	return m, nil
+}
+
+// Save writes the lockfile to path.
+func (m *Manifest) Save(fs afero.Fs, path string) error {
+	b, err := yaml.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(fs, path, b, perms.ReadWrite)
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package node
+
+import (
+	"context"
+	"strings"
+
+	"github.com/luxfi/rpc"
+	"github.com/luxfi/sdk/health"
+)
+
+var _ Client = &client{}
+
+// Client reads what a running node has loaded, and whether it's healthy,
+// through its info and health APIs.
+type Client interface {
+	// VMs returns the aliases of every VM the node has loaded, by VMID.
+	VMs() (map[string][]string, error)
+	// Health returns whether the node is healthy, and the errors of the
+	// health checks that failed by name.
+	Health() (bool, map[string]string, error)
+}
+
+type client struct {
+	info   rpc.EndpointRequester
+	health *health.Client
+}
+
+// NewClient returns a client of the APIs of the node at url.
+func NewClient(url string) Client {
+	url = strings.TrimRight(url, "/")
+	return &client{
+		info:   rpc.NewEndpointRequester(url + "/ext/info"),
+		health: health.NewClient(url),
+	}
+}
+
+// getVMsReply is the reply of info.getVMs. VMIDs are decoded as strings,
+// since ids.ID can't be decoded from a map key.
+type getVMsReply struct {
+	VMs map[string][]string `json:"vms"`
+}
+
+func (c *client) VMs() (map[string][]string, error) {
+	reply := &getVMsReply{}
+	err := c.info.SendRequest(
+		context.Background(),
+		"info.getVMs",
+		struct{}{},
+		reply,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return reply.VMs, nil
+}
+
+func (c *client) Health() (bool, map[string]string, error) {
+	reply, err := c.health.Health(context.Background(), nil)
+	if err != nil {
+		return false, nil, err
+	}
+
+	failed := make(map[string]string)
+	for name, check := range reply.Checks {
+		if check.Error != nil {
+			failed[name] = *check.Error
+		}
+	}
+
+	return reply.Healthy, failed, nil
+}
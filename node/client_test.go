@@ -0,0 +1,47 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package node
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	responses := map[string]string{
+		"info.getVMs":   `{"jsonrpc":"2.0","result":{"vms":{"vmID":["vm"]},"fxs":{}},"id":1}`,
+		"health.health": `{"jsonrpc":"2.0","result":{"healthy":false,"checks":{"network":{"duration":1},"chain":{"error":"not bootstrapped","duration":1}}},"id":1}`,
+	}
+	paths := map[string]string{
+		"info.getVMs":   "/ext/info",
+		"health.health": "/ext/health",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Method string `json:"method"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		require.Contains(t, responses, request.Method)
+		require.Equal(t, paths[request.Method], r.URL.Path)
+
+		_, _ = w.Write([]byte(responses[request.Method]))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL + "/")
+
+	vms, err := client.VMs()
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{"vmID": {"vm"}}, vms)
+
+	healthy, failed, err := client.Health()
+	require.NoError(t, err)
+	require.False(t, healthy)
+	require.Equal(t, map[string]string{"chain": "not bootstrapped"}, failed)
+}
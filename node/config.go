@@ -0,0 +1,387 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+)
+
+// Keys of the node config that lpm reads or edits.
+const (
+	// TrackChainsKey is the config key of the chains a node tracks.
+	TrackChainsKey = "track-chains"
+	// PluginDirKey is the config key of the directory a node loads VMs from.
+	PluginDirKey = "plugin-dir"
+	// ChainConfigDirKey is the config key of the directory a node reads
+	// per-chain config from.
+	ChainConfigDirKey = "chain-config-dir"
+	// DataDirKey is the config key of a node's data directory.
+	DataDirKey = "data-dir"
+)
+
+const (
+	// Names of a chain's files in its directory in the chain config
+	// directory.
+	ChainGenesisFile = "genesis.json"
+	ChainConfigFile  = "config.json"
+	ChainUpgradeFile = "upgrade.json"
+
+	defaultDataDir = ".luxd"
+	configsDir     = "configs"
+	chainsDir      = "chains"
+	backupSuffix   = ".bak"
+)
+
+// DefaultConfigPaths returns where a node's config file is looked for, in
+// order.
+func DefaultConfigPaths(homeDir string) []string {
+	return []string{
+		filepath.Join(homeDir, defaultDataDir, configsDir, "node.json"),
+		filepath.Join(homeDir, defaultDataDir, "config.json"),
+	}
+}
+
+// FindConfigFile returns the first of DefaultConfigPaths that exists.
+func FindConfigFile(fs afero.Fs, homeDir string) (string, bool) {
+	for _, path := range DefaultConfigPaths(homeDir) {
+		if info, err := fs.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+
+	return "", false
+}
+
+// ConfigFile is a node's JSON config file.
+type ConfigFile struct {
+	fs   afero.Fs
+	path string
+}
+
+// NewConfigFile returns the node config file at path. It's created when it's
+// first written if it doesn't exist.
+func NewConfigFile(fs afero.Fs, path string) *ConfigFile {
+	return &ConfigFile{
+		fs:   fs,
+		path: path,
+	}
+}
+
+// Path returns where the config file is.
+func (c *ConfigFile) Path() string {
+	return c.path
+}
+
+// TrackedChains returns the chains the node is configured to track.
+func (c *ConfigFile) TrackedChains() ([]string, error) {
+	config, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+
+	return trackedChains(config)
+}
+
+// PluginDir returns the directory the node loads VMs from, or an empty string
+// if it uses its default.
+func (c *ConfigFile) PluginDir() (string, error) {
+	config, err := c.read()
+	if err != nil {
+		return "", err
+	}
+
+	return stringValue(config, PluginDirKey)
+}
+
+// SetPluginDir has the node load VMs from dir. It returns false if the node
+// already does.
+func (c *ConfigFile) SetPluginDir(dir string) (bool, error) {
+	config, err := c.read()
+	if err != nil {
+		return false, err
+	}
+
+	current, err := stringValue(config, PluginDirKey)
+	if err != nil {
+		return false, err
+	}
+	if current == dir {
+		return false, nil
+	}
+	config[PluginDirKey] = dir
+
+	return true, c.write(config)
+}
+
+// ChainConfigDir returns the directory the node reads per-chain config from.
+// It defaults to the chains directory next to the config file, or in the
+// configs directory of the node's data directory if one is set.
+func (c *ConfigFile) ChainConfigDir() (string, error) {
+	config, err := c.read()
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := stringValue(config, ChainConfigDirKey)
+	if err != nil || dir != "" {
+		return dir, err
+	}
+
+	dataDir, err := stringValue(config, DataDirKey)
+	if err != nil {
+		return "", err
+	}
+	if dataDir != "" {
+		return filepath.Join(dataDir, configsDir, chainsDir), nil
+	}
+
+	return filepath.Join(filepath.Dir(c.path), chainsDir), nil
+}
+
+// ChainFilePath returns where the node reads file of chainID from, e.g
+// ChainConfigFile.
+func (c *ConfigFile) ChainFilePath(chainID string, file string) (string, error) {
+	dir, err := c.ChainConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, chainID, file), nil
+}
+
+// WriteChainFile writes file of chainID to the chain config directory. It
+// returns false if the node already has it.
+func (c *ConfigFile) WriteChainFile(chainID string, file string, b []byte) (bool, error) {
+	path, err := c.ChainFilePath(chainID, file)
+	if err != nil {
+		return false, err
+	}
+
+	return writeFile(c.fs, path, b)
+}
+
+// RemoveChainFile backs up and removes file of chainID from the chain config
+// directory. It returns false if the node doesn't have it.
+func (c *ConfigFile) RemoveChainFile(chainID string, file string) (bool, error) {
+	path, err := c.ChainFilePath(chainID, file)
+	if err != nil {
+		return false, err
+	}
+
+	old, err := afero.ReadFile(c.fs, path)
+	if errors.Is(err, iofs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	backupPath := path + backupSuffix
+	if err := replaceFile(c.fs, backupPath, old); err != nil {
+		return false, fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	fmt.Printf("Backed up %s to %s.\n", path, backupPath)
+
+	return true, c.fs.Remove(path)
+}
+
+// TrackChain adds chainID to the chains the node is configured to track. It
+// returns false if the node already tracks it.
+func (c *ConfigFile) TrackChain(chainID string) (bool, error) {
+	return c.updateTrackedChains(func(chains []string) []string {
+		if slices.Contains(chains, chainID) {
+			return chains
+		}
+		return append(chains, chainID)
+	})
+}
+
+// UntrackChain removes chainID from the chains the node is configured to
+// track. It returns false if the node doesn't track it.
+func (c *ConfigFile) UntrackChain(chainID string) (bool, error) {
+	return c.updateTrackedChains(func(chains []string) []string {
+		return slices.DeleteFunc(chains, func(chain string) bool {
+			return chain == chainID
+		})
+	})
+}
+
+// updateTrackedChains replaces the tracked chains with the result of update.
+// It returns false if they didn't change.
+func (c *ConfigFile) updateTrackedChains(update func([]string) []string) (bool, error) {
+	config, err := c.read()
+	if err != nil {
+		return false, err
+	}
+
+	chains, err := trackedChains(config)
+	if err != nil {
+		return false, err
+	}
+	updated := update(slices.Clone(chains))
+	if slices.Equal(chains, updated) {
+		return false, nil
+	}
+
+	// Keep whichever form the node was configured with.
+	if len(updated) == 0 {
+		delete(config, TrackChainsKey)
+	} else if _, ok := config[TrackChainsKey].([]any); ok {
+		config[TrackChainsKey] = updated
+	} else {
+		config[TrackChainsKey] = strings.Join(updated, ",")
+	}
+
+	return true, c.write(config)
+}
+
+func (c *ConfigFile) read() (map[string]any, error) {
+	b, err := afero.ReadFile(c.fs, c.path)
+	if errors.Is(err, iofs.ErrNotExist) {
+		return map[string]any{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	config := map[string]any{}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return config, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	// Numbers are written back exactly as they were read.
+	decoder.UseNumber()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to parse node config %s: %w", c.path, err)
+	}
+
+	return config, nil
+}
+
+func (c *ConfigFile) write(config map[string]any) error {
+	b, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	_, err = writeFile(c.fs, c.path, b)
+	return err
+}
+
+// writeFile replaces the file at path with b. The changes are printed and the
+// file is backed up first. It returns false if the file already holds b.
+func writeFile(fs afero.Fs, path string, b []byte) (bool, error) {
+	old, err := afero.ReadFile(fs, path)
+	switch {
+	case errors.Is(err, iofs.ErrNotExist):
+		fmt.Printf("Creating %s...\n", path)
+	case err != nil:
+		return false, err
+	case bytes.Equal(old, b):
+		return false, nil
+	default:
+		fmt.Printf("Updating %s:\n%s", path, diff(old, b))
+		backupPath := path + backupSuffix
+		if err := replaceFile(fs, backupPath, old); err != nil {
+			return false, fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+		fmt.Printf("Backed up %s to %s.\n", path, backupPath)
+	}
+
+	return true, replaceFile(fs, path, b)
+}
+
+// replaceFile replaces the file at path with b, so the node never reads a
+// partially written file.
+func replaceFile(fs afero.Fs, path string, b []byte) (err error) {
+	mode := os.FileMode(perms.ReadWrite)
+	if info, err := fs.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(path)
+	if err := fs.MkdirAll(dir, perms.ReadWriteExecute); err != nil {
+		return err
+	}
+
+	f, err := afero.TempFile(fs, dir, "."+filepath.Base(path)+"-")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+	defer func() {
+		_ = f.Close()
+		if err != nil {
+			_ = fs.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := f.Write(b); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := fs.Chmod(tmpPath, mode); err != nil {
+		return err
+	}
+
+	return fs.Rename(tmpPath, path)
+}
+
+// stringValue returns the string at key in config, or an empty string if it
+// isn't set. Environment variables in it are expanded like the node does.
+func stringValue(config map[string]any, key string) (string, error) {
+	switch value := config[key].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return os.ExpandEnv(value), nil
+	default:
+		return "", fmt.Errorf("%s must be a string, not %T", key, value)
+	}
+}
+
+// trackedChains parses the tracked chains in config, which are either a comma
+// separated string or a list.
+func trackedChains(config map[string]any) ([]string, error) {
+	switch value := config[TrackChainsKey].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		chains := make([]string, 0)
+		for _, chain := range strings.Split(value, ",") {
+			if chain = strings.TrimSpace(chain); chain != "" {
+				chains = append(chains, chain)
+			}
+		}
+		return chains, nil
+	case []any:
+		chains := make([]string, 0, len(value))
+		for _, chain := range value {
+			s, ok := chain.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s has an invalid chain %v", TrackChainsKey, chain)
+			}
+			chains = append(chains, s)
+		}
+		return chains, nil
+	default:
+		return nil, fmt.Errorf("%s must be a string or a list, not %T", TrackChainsKey, value)
+	}
+}
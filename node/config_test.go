@@ -0,0 +1,287 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+const configPath = "node/config.json"
+
+func TestTrackChain(t *testing.T) {
+	tests := []struct {
+		name string
+		// config is the contents of the config file, or empty if it doesn't
+		// exist
+		config    string
+		wantAdded bool
+		want      string
+		// wantBackup is whether the config is backed up before it's changed
+		wantBackup bool
+		wantErr    string
+	}{
+		{
+			name:      "missing config",
+			wantAdded: true,
+			want:      "{\n  \"track-chains\": \"chain\"\n}\n",
+		},
+		{
+			name:       "comma separated chains",
+			config:     `{"http-port": 9650, "track-chains": "other, another"}`,
+			wantAdded:  true,
+			want:       "{\n  \"http-port\": 9650,\n  \"track-chains\": \"other,another,chain\"\n}\n",
+			wantBackup: true,
+		},
+		{
+			name:       "list of chains",
+			config:     `{"track-chains": ["other"]}`,
+			wantAdded:  true,
+			want:       "{\n  \"track-chains\": [\n    \"other\",\n    \"chain\"\n  ]\n}\n",
+			wantBackup: true,
+		},
+		{
+			name:   "already tracked",
+			config: `{"track-chains": "other,chain"}`,
+			want:   `{"track-chains": "other,chain"}`,
+		},
+		{
+			name:    "invalid json",
+			config:  `{"track-chains":`,
+			want:    `{"track-chains":`,
+			wantErr: "failed to parse node config",
+		},
+		{
+			name:    "invalid chains",
+			config:  `{"track-chains": 1}`,
+			want:    `{"track-chains": 1}`,
+			wantErr: "must be a string or a list",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if test.config != "" {
+				require.NoError(t, afero.WriteFile(fs, configPath, []byte(test.config), 0o600))
+			}
+
+			added, err := NewConfigFile(fs, configPath).TrackChain("chain")
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.wantAdded, added)
+
+			b, err := afero.ReadFile(fs, configPath)
+			require.NoError(t, err)
+			require.Equal(t, test.want, string(b))
+
+			requireBackup(t, fs, configPath, test.wantBackup, test.config)
+		})
+	}
+}
+
+func TestUntrackChain(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		wantRemoved bool
+		want        string
+	}{
+		{
+			name:        "comma separated chains",
+			config:      `{"track-chains": "other,chain"}`,
+			wantRemoved: true,
+			want:        "{\n  \"track-chains\": \"other\"\n}\n",
+		},
+		{
+			name:        "list of chains",
+			config:      `{"track-chains": ["chain", "other"]}`,
+			wantRemoved: true,
+			want:        "{\n  \"track-chains\": [\n    \"other\"\n  ]\n}\n",
+		},
+		{
+			name:        "last chain",
+			config:      `{"http-port": 9650, "track-chains": "chain"}`,
+			wantRemoved: true,
+			want:        "{\n  \"http-port\": 9650\n}\n",
+		},
+		{
+			name:   "not tracked",
+			config: `{"track-chains": "other"}`,
+			want:   `{"track-chains": "other"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, configPath, []byte(test.config), 0o600))
+
+			removed, err := NewConfigFile(fs, configPath).UntrackChain("chain")
+			require.NoError(t, err)
+			require.Equal(t, test.wantRemoved, removed)
+
+			b, err := afero.ReadFile(fs, configPath)
+			require.NoError(t, err)
+			require.Equal(t, test.want, string(b))
+			requireBackup(t, fs, configPath, test.wantRemoved, test.config)
+		})
+	}
+}
+
+func TestSetPluginDir(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, configPath, []byte(`{"plugin-dir": "$HOME/plugins"}`), 0o600))
+	t.Setenv("HOME", "/home/user")
+
+	config := NewConfigFile(fs, configPath)
+	dir, err := config.PluginDir()
+	require.NoError(t, err)
+	require.Equal(t, "/home/user/plugins", dir)
+
+	set, err := config.SetPluginDir("/home/user/plugins")
+	require.NoError(t, err)
+	require.False(t, set)
+
+	set, err = config.SetPluginDir("/lpm/plugins")
+	require.NoError(t, err)
+	require.True(t, set)
+
+	dir, err = config.PluginDir()
+	require.NoError(t, err)
+	require.Equal(t, "/lpm/plugins", dir)
+	requireBackup(t, fs, configPath, true, `{"plugin-dir": "$HOME/plugins"}`)
+
+	// The file keeps its permissions.
+	info, err := fs.Stat(configPath)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestChainFilePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "next to the config",
+			want: filepath.Join("node", "chains", "chain", ChainConfigFile),
+		},
+		{
+			name:   "in the data directory",
+			config: `{"data-dir": "/data"}`,
+			want:   filepath.Join("/data", "configs", "chains", "chain", ChainConfigFile),
+		},
+		{
+			name:   "chain config directory",
+			config: `{"data-dir": "/data", "chain-config-dir": "/chains"}`,
+			want:   filepath.Join("/chains", "chain", ChainConfigFile),
+		},
+		{
+			name:    "invalid chain config directory",
+			config:  `{"chain-config-dir": ["/chains"]}`,
+			wantErr: "must be a string",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if test.config != "" {
+				require.NoError(t, afero.WriteFile(fs, configPath, []byte(test.config), 0o600))
+			}
+
+			path, err := NewConfigFile(fs, configPath).ChainFilePath("chain", ChainConfigFile)
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, path)
+		})
+	}
+}
+
+func TestWriteChainFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	config := NewConfigFile(fs, configPath)
+	path := filepath.Join("node", "chains", "chain", ChainConfigFile)
+
+	written, err := config.WriteChainFile("chain", ChainConfigFile, []byte("{}\n"))
+	require.NoError(t, err)
+	require.True(t, written)
+	requireBackup(t, fs, path, false, "")
+
+	written, err = config.WriteChainFile("chain", ChainConfigFile, []byte("{}\n"))
+	require.NoError(t, err)
+	require.False(t, written)
+
+	written, err = config.WriteChainFile("chain", ChainConfigFile, []byte("{\"pruning-enabled\": true}\n"))
+	require.NoError(t, err)
+	require.True(t, written)
+	requireBackup(t, fs, path, true, "{}\n")
+
+	// The node config itself isn't created.
+	exists, err := afero.Exists(fs, configPath)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	removed, err := config.RemoveChainFile("chain", ChainConfigFile)
+	require.NoError(t, err)
+	require.True(t, removed)
+	exists, err = afero.Exists(fs, path)
+	require.NoError(t, err)
+	require.False(t, exists)
+	b, err := afero.ReadFile(fs, path+backupSuffix)
+	require.NoError(t, err)
+	require.Equal(t, "{\"pruning-enabled\": true}\n", string(b))
+
+	removed, err = config.RemoveChainFile("chain", ChainConfigFile)
+	require.NoError(t, err)
+	require.False(t, removed)
+}
+
+func TestFindConfigFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_, ok := FindConfigFile(fs, "/home/user")
+	require.False(t, ok)
+
+	paths := DefaultConfigPaths("/home/user")
+	require.NoError(t, afero.WriteFile(fs, paths[1], []byte("{}"), 0o600))
+	path, ok := FindConfigFile(fs, "/home/user")
+	require.True(t, ok)
+	require.Equal(t, paths[1], path)
+
+	require.NoError(t, afero.WriteFile(fs, paths[0], []byte("{}"), 0o600))
+	path, ok = FindConfigFile(fs, "/home/user")
+	require.True(t, ok)
+	require.Equal(t, paths[0], path)
+}
+
+// requireBackup checks that the file at path was backed up with old if
+// wantBackup, and that nothing else is left behind next to it.
+func requireBackup(t *testing.T, fs afero.Fs, path string, wantBackup bool, old string) {
+	t.Helper()
+
+	entries, err := afero.ReadDir(fs, filepath.Dir(path))
+	require.NoError(t, err)
+	if !wantBackup {
+		require.Len(t, entries, 1)
+		return
+	}
+	require.Len(t, entries, 2)
+
+	b, err := afero.ReadFile(fs, path+backupSuffix)
+	require.NoError(t, err)
+	require.Equal(t, old, string(b))
+}
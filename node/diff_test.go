@@ -0,0 +1,48 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     string
+		updated string
+		want    string
+	}{
+		{
+			name:    "unchanged",
+			old:     "a\nb\n",
+			updated: "a\nb\n",
+		},
+		{
+			name:    "created",
+			updated: "a\n",
+			want:    "  + a\n",
+		},
+		{
+			name:    "changed line",
+			old:     "{\n  \"a\": 1,\n  \"b\": 2\n}\n",
+			updated: "{\n  \"a\": 1,\n  \"b\": 3\n}\n",
+			want:    "  -   \"b\": 2\n  +   \"b\": 3\n",
+		},
+		{
+			name:    "added and removed lines",
+			old:     "a\nb\nc\n",
+			updated: "b\nc\nd\n",
+			want:    "  - a\n  + d\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.want, diff([]byte(test.old), []byte(test.updated)))
+		})
+	}
+}
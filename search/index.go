@@ -0,0 +1,201 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package search
+
+import (
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/luxfi/lpm/constant"
+	"github.com/luxfi/lpm/types"
+)
+
+const (
+	vmDir     = "vms"
+	chainDir  = "chains"
+	extension = ".yaml"
+)
+
+// Kind is the type of definition an Entry describes.
+type Kind string
+
+const (
+	VMKind    Kind = "vm"
+	ChainKind Kind = "chain"
+)
+
+// Entry is a single searchable definition in a plugin repository.
+type Entry struct {
+	// Alias of the repository the definition is in
+	Repository string `yaml:"repository" json:"repository"`
+	// Name of the definition file, without its extension
+	Name        string   `yaml:"name" json:"name"`
+	Kind        Kind     `yaml:"kind" json:"kind"`
+	Alias       string   `yaml:"alias" json:"alias"`
+	Description string   `yaml:"description" json:"description"`
+	Maintainers []string `yaml:"maintainers" json:"maintainers"`
+	// VMID for VMs, or the chain ID on every network for chains
+	IDs []string `yaml:"ids" json:"ids"`
+}
+
+// QualifiedName returns the name the definition can be installed with.
+func (e Entry) QualifiedName() string {
+	return strings.Join([]string{e.Repository, e.Name}, constant.QualifiedNameDelimiter)
+}
+
+// Index is a snapshot of every definition in the tracked repositories.
+type Index struct {
+	Entries []Entry `yaml:"entries"`
+}
+
+// Build indexes every definition in repositoriesPath, which is laid out as
+// <organization>/<repository>/{vms,chains}/<name>.yaml.
+func Build(fs afero.Fs, repositoriesPath string) (*Index, error) {
+	organizations, err := readDirs(fs, repositoriesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &Index{}
+	for _, organization := range organizations {
+		repositories, err := readDirs(fs, filepath.Join(repositoriesPath, organization))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, repository := range repositories {
+			alias := strings.Join([]string{organization, repository}, constant.AliasDelimiter)
+			repositoryPath := filepath.Join(repositoriesPath, organization, repository)
+
+			if err := index.add(fs, alias, filepath.Join(repositoryPath, vmDir), VMKind); err != nil {
+				return nil, err
+			}
+			if err := index.add(fs, alias, filepath.Join(repositoryPath, chainDir), ChainKind); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.Slice(index.Entries, func(i, j int) bool {
+		return index.Entries[i].QualifiedName() < index.Entries[j].QualifiedName()
+	})
+
+	return index, nil
+}
+
+// add indexes each definition in dir. Definitions that can't be parsed are
+// skipped.
+func (i *Index) add(fs afero.Fs, repository string, dir string, kind Kind) error {
+	files, err := afero.ReadDir(fs, dir)
+	if errors.Is(err, iofs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != extension {
+			continue
+		}
+
+		b, err := afero.ReadFile(fs, filepath.Join(dir, file.Name()))
+		if err != nil {
+			return err
+		}
+
+		entry := Entry{
+			Repository: repository,
+			Name:       strings.TrimSuffix(file.Name(), extension),
+			Kind:       kind,
+		}
+
+		switch kind {
+		case VMKind:
+			var vm types.VM
+			if err := yaml.Unmarshal(b, &vm); err != nil {
+				fmt.Printf("Skipping %s in %s while indexing: %s\n", file.Name(), repository, err)
+				continue
+			}
+			entry.Alias = vm.Alias
+			entry.Description = vm.Description
+			entry.Maintainers = vm.Maintainers
+			if vm.ID != "" {
+				entry.IDs = []string{vm.ID}
+			}
+		case ChainKind:
+			var chain types.Chain
+			if err := yaml.Unmarshal(b, &chain); err != nil {
+				fmt.Printf("Skipping %s in %s while indexing: %s\n", file.Name(), repository, err)
+				continue
+			}
+			entry.Alias = chain.Alias
+			entry.Description = chain.Description
+			entry.Maintainers = chain.Maintainers
+			for _, id := range chain.ID {
+				entry.IDs = append(entry.IDs, id)
+			}
+			sort.Strings(entry.IDs)
+		}
+
+		i.Entries = append(i.Entries, entry)
+	}
+
+	return nil
+}
+
+// Load reads a previously saved index.
+func Load(fs afero.Fs, path string) (*Index, error) {
+	b, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &Index{}
+	if err := yaml.Unmarshal(b, index); err != nil {
+		return nil, fmt.Errorf("failed to parse search index %s: %w", path, err)
+	}
+
+	return index, nil
+}
+
+// Save writes the index to path, replacing any previous index.
+func (i *Index) Save(fs afero.Fs, path string) error {
+	b, err := yaml.Marshal(i)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	if err := afero.WriteFile(fs, tmpPath, b, perms.ReadWrite); err != nil {
+		return err
+	}
+
+	return fs.Rename(tmpPath, path)
+}
+
+func readDirs(fs afero.Fs, path string) ([]string, error) {
+	entries, err := afero.ReadDir(fs, path)
+	if errors.Is(err, iofs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	dirs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			dirs = append(dirs, entry.Name())
+		}
+	}
+
+	return dirs, nil
+}
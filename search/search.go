@@ -0,0 +1,99 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package search
+
+import (
+	"sort"
+	"strings"
+)
+
+// Scores awarded to an entry for each way a query term can match it.
+const (
+	exactNameScore   = 100
+	exactIDScore     = 100
+	namePrefixScore  = 50
+	idPrefixScore    = 40
+	nameScore        = 25
+	descriptionScore = 10
+	maintainerScore  = 5
+)
+
+// Result is an entry that matched a query.
+type Result struct {
+	Entry `yaml:",inline"`
+	Score int `yaml:"score" json:"score"`
+}
+
+// Search returns every entry matching all of the whitespace separated terms
+// in query, best matches first. Terms are matched case-insensitively against
+// the name, alias, description, maintainers and IDs of each entry.
+func (i *Index) Search(query string) []Result {
+	terms := strings.Fields(strings.ToLower(query))
+
+	results := make([]Result, 0)
+	for _, entry := range i.Entries {
+		total := 0
+		for _, term := range terms {
+			score := entry.score(term)
+			if score == 0 {
+				total = 0
+				break
+			}
+			total += score
+		}
+
+		if total > 0 {
+			results = append(results, Result{Entry: entry, Score: total})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].QualifiedName() < results[j].QualifiedName()
+	})
+
+	return results
+}
+
+// score returns how well a single lower-cased term matches the entry, or 0 if
+// it doesn't match.
+func (e Entry) score(term string) int {
+	best := 0
+	for _, name := range []string{e.Name, e.Alias, e.QualifiedName()} {
+		name = strings.ToLower(name)
+		switch {
+		case name == "":
+		case name == term:
+			best = max(best, exactNameScore)
+		case strings.HasPrefix(name, term):
+			best = max(best, namePrefixScore)
+		case strings.Contains(name, term):
+			best = max(best, nameScore)
+		}
+	}
+
+	for _, id := range e.IDs {
+		id = strings.ToLower(id)
+		switch {
+		case id == term:
+			best = max(best, exactIDScore)
+		case strings.HasPrefix(id, term):
+			best = max(best, idPrefixScore)
+		}
+	}
+
+	if strings.Contains(strings.ToLower(e.Description), term) {
+		best = max(best, descriptionScore)
+	}
+
+	for _, maintainer := range e.Maintainers {
+		if strings.Contains(strings.ToLower(maintainer), term) {
+			best = max(best, maintainerScore)
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,125 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package search
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	const repositoriesPath = "repositories"
+
+	definitions := map[string]string{
+		"luxfi/plugins-core/vms/spacesvm.yaml": `
+id: sqja3uK17MJxfC7AN8nGadBw9JK5BcrsNwNynsqP5Gih8M5Bm
+alias: spacesvm
+description: Authenticated key-value storage
+maintainers:
+  - alice@example.com
+`,
+		"luxfi/plugins-core/vms/timestampvm.yaml": `
+id: tGas3T58KzdjcJ2iKSyiYsWiqYctRXaPTqBCA11BcEkRgoKC4
+alias: timestampvm
+description: Stores timestamps, used by spaces tooling
+maintainers:
+  - bob@example.com
+`,
+		"luxfi/plugins-core/chains/spaces.yaml": `
+id:
+  testnet: Ai42MkKqk8yjXFCpoHXw7rdTWSHiKEMqh5h8gbxwjgkCUfkrk
+alias: spaces
+description: Spaces chain
+maintainers:
+  - alice@example.com
+vms:
+  - spacesvm
+`,
+		"acme/plugins/vms/spacesvm.yaml": `
+id: 2ZiSSqMhXkb8fuGyNmBn3ZUqyRYE7tTrLuuiDPbxqLcAGu7hAt
+alias: spacesvm
+description: A fork of spacesvm
+maintainers:
+  - carol@example.com
+`,
+		"acme/plugins/vms/broken.yaml": "id: [",
+		"acme/plugins/README.md":       "not a definition",
+	}
+
+	fs := afero.NewMemMapFs()
+	for path, contents := range definitions {
+		require.NoError(t, afero.WriteFile(fs, filepath.Join(repositoriesPath, path), []byte(contents), perms.ReadWrite))
+	}
+
+	index, err := Build(fs, repositoriesPath)
+	require.NoError(t, err)
+	require.Len(t, index.Entries, 4)
+
+	// the index round-trips through disk
+	require.NoError(t, index.Save(fs, "index"))
+	loaded, err := Load(fs, "index")
+	require.NoError(t, err)
+	require.Equal(t, index, loaded)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "exact names rank above partial matches",
+			query: "spacesvm",
+			want:  []string{"acme/plugins:spacesvm", "luxfi/plugins-core:spacesvm"},
+		},
+		{
+			name:  "prefix matches rank above description matches",
+			query: "spaces",
+			want: []string{
+				"luxfi/plugins-core:spaces",
+				"acme/plugins:spacesvm",
+				"luxfi/plugins-core:spacesvm",
+				"luxfi/plugins-core:timestampvm",
+			},
+		},
+		{
+			name:  "every term has to match",
+			query: "spaces alice",
+			want:  []string{"luxfi/plugins-core:spaces", "luxfi/plugins-core:spacesvm"},
+		},
+		{
+			name:  "vm id",
+			query: "tGas3T58",
+			want:  []string{"luxfi/plugins-core:timestampvm"},
+		},
+		{
+			name:  "chain id",
+			query: "Ai42MkKqk8yjXFCpoHXw7rdTWSHiKEMqh5h8gbxwjgkCUfkrk",
+			want:  []string{"luxfi/plugins-core:spaces"},
+		},
+		{
+			name:  "repository",
+			query: "acme",
+			want:  []string{"acme/plugins:spacesvm"},
+		},
+		{
+			name:  "no matches",
+			query: "nothing",
+			want:  []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, result := range index.Search(test.query) {
+				got = append(got, result.QualifiedName())
+			}
+			require.Equal(t, test.want, got)
+		})
+	}
+}
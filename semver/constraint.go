@@ -0,0 +1,196 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package semver
+
+import (
+	"fmt"
+	"strings"
+)
+
+type operator string
+
+const (
+	equal          operator = "="
+	greater        operator = ">"
+	greaterOrEqual operator = ">="
+	less           operator = "<"
+	lessOrEqual    operator = "<="
+)
+
+type comparator struct {
+	op      operator
+	version Version
+}
+
+func (c comparator) check(v Version) bool {
+	switch cmp := v.Compare(c.version); c.op {
+	case equal:
+		return cmp == 0
+	case greater:
+		return cmp > 0
+	case greaterOrEqual:
+		return cmp >= 0
+	case less:
+		return cmp < 0
+	case lessOrEqual:
+		return cmp <= 0
+	default:
+		return false
+	}
+}
+
+// Constraint is a set of requirements a version has to satisfy, like ^1.2,
+// ~1.2.3, >=1.0.0, <2.0.0 or 1.2.3.
+type Constraint struct {
+	raw         string
+	comparators []comparator
+}
+
+// ParseConstraint parses a comma separated list of requirements which all
+// have to be satisfied. Supported requirements are:
+//
+//   - 1.2.3 or =1.2.3: exactly 1.2.3
+//   - 1.2 or 1.2.x: any 1.2 patch release
+//   - ^1.2.3: compatible with 1.2.3, i.e >=1.2.3 <2.0.0
+//   - ~1.2.3: patch releases of 1.2.3, i.e >=1.2.3 <1.3.0
+//   - >1.2.3, >=1.2.3, <1.2.3 and <=1.2.3
+//   - * or an empty string: any release
+func ParseConstraint(s string) (*Constraint, error) {
+	constraint := &Constraint{raw: strings.TrimSpace(s)}
+
+	for _, requirement := range strings.Split(s, ",") {
+		comparators, err := parseRequirement(strings.TrimSpace(requirement))
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %w", s, err)
+		}
+		constraint.comparators = append(constraint.comparators, comparators...)
+	}
+
+	return constraint, nil
+}
+
+func parseRequirement(s string) ([]comparator, error) {
+	if s == "" || s == "*" {
+		return nil, nil
+	}
+
+	for _, op := range []operator{greaterOrEqual, lessOrEqual, greater, less} {
+		if rest, ok := strings.CutPrefix(s, string(op)); ok {
+			v, _, err := parseWildcard(rest)
+			if err != nil {
+				return nil, err
+			}
+			return []comparator{{op: op, version: v}}, nil
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(s, "^"):
+		v, parts, err := parseWildcard(s[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		// The left-most non-zero component can't change.
+		var upper Version
+		switch {
+		case v.Major > 0 || parts == 1:
+			upper = Version{Major: v.Major + 1}
+		case v.Minor > 0 || parts == 2:
+			upper = Version{Minor: v.Minor + 1}
+		default:
+			upper = Version{Minor: v.Minor, Patch: v.Patch + 1}
+		}
+		return between(v, upper), nil
+	case strings.HasPrefix(s, "~"):
+		v, parts, err := parseWildcard(s[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		upper := Version{Major: v.Major, Minor: v.Minor + 1}
+		if parts == 1 {
+			upper = Version{Major: v.Major + 1}
+		}
+		return between(v, upper), nil
+	}
+
+	v, parts, err := parseWildcard(strings.TrimPrefix(s, string(equal)))
+	if err != nil {
+		return nil, err
+	}
+
+	switch parts {
+	case 1:
+		return between(v, Version{Major: v.Major + 1}), nil
+	case 2:
+		return between(v, Version{Major: v.Major, Minor: v.Minor + 1}), nil
+	default:
+		return []comparator{{op: equal, version: v}}, nil
+	}
+}
+
+// parseWildcard parses a possibly partial version where missing components
+// may also be written as x or *.
+func parseWildcard(s string) (Version, int, error) {
+	s = strings.TrimSpace(s)
+	components := strings.Split(s, ".")
+	for i, component := range components {
+		if component == "x" || component == "X" || component == "*" {
+			if i == 0 {
+				return Version{}, 0, fmt.Errorf("invalid version %q", s)
+			}
+			s = strings.Join(components[:i], ".")
+			break
+		}
+	}
+
+	return parse(s)
+}
+
+// between returns the comparators for versions in [lower, upper).
+func between(lower, upper Version) []comparator {
+	// Exclude prereleases of the upper bound, e.g 2.0.0-rc.1 for ^1.2.
+	upper.Prerelease = "0"
+	return []comparator{
+		{op: greaterOrEqual, version: lower},
+		{op: less, version: upper},
+	}
+}
+
+// Check returns whether v satisfies the constraint. Prereleases only satisfy
+// a constraint that explicitly mentions a prerelease of the same version.
+func (c *Constraint) Check(v Version) bool {
+	if v.Prerelease != "" && !c.allowsPrerelease(v) {
+		return false
+	}
+
+	for _, comparator := range c.comparators {
+		if !comparator.check(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Constraint) allowsPrerelease(v Version) bool {
+	for _, comparator := range c.comparators {
+		bound := comparator.version
+		if bound.Prerelease != "" && bound.Major == v.Major && bound.Minor == v.Minor && bound.Patch == v.Patch {
+			// the artificial bound added by between doesn't count
+			if comparator.op == less && bound.Prerelease == "0" {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Constraint) String() string {
+	if c.raw == "" {
+		return "*"
+	}
+	return c.raw
+}
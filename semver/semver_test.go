@@ -0,0 +1,179 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Version
+		wantErr bool
+	}{
+		{in: "1.2.3", want: Version{Major: 1, Minor: 2, Patch: 3}},
+		{in: "v1.2.3", want: Version{Major: 1, Minor: 2, Patch: 3}},
+		{in: "1.2.3-rc.1+abc", want: Version{Major: 1, Minor: 2, Patch: 3, Prerelease: "rc.1", Build: "abc"}},
+		{in: "1.2", wantErr: true},
+		{in: "1.2.3.4", wantErr: true},
+		{in: "1.a.3", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			got, err := Parse(test.in)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// in ascending order
+	versions := []string{
+		"0.9.9",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.10.0",
+	}
+
+	for i := range versions {
+		for j := range versions {
+			a, err := Parse(versions[i])
+			require.NoError(t, err)
+			b, err := Parse(versions[j])
+			require.NoError(t, err)
+
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			require.Equal(t, want, a.Compare(b), "%s vs %s", versions[i], versions[j])
+		}
+	}
+}
+
+func TestConstraintCheck(t *testing.T) {
+	tests := []struct {
+		constraint string
+		match      []string
+		noMatch    []string
+	}{
+		{
+			constraint: "",
+			match:      []string{"0.0.1", "9.9.9"},
+			noMatch:    []string{"1.0.0-rc.1"},
+		},
+		{
+			constraint: "*",
+			match:      []string{"0.0.1", "9.9.9"},
+		},
+		{
+			constraint: "1.2.3",
+			match:      []string{"1.2.3", "v1.2.3+build"},
+			noMatch:    []string{"1.2.4", "1.2.3-rc.1"},
+		},
+		{
+			constraint: "=1.2.3",
+			match:      []string{"1.2.3"},
+			noMatch:    []string{"1.2.2"},
+		},
+		{
+			constraint: "1.2",
+			match:      []string{"1.2.0", "1.2.9"},
+			noMatch:    []string{"1.3.0", "1.1.9"},
+		},
+		{
+			constraint: "1.x",
+			match:      []string{"1.0.0", "1.9.0"},
+			noMatch:    []string{"2.0.0"},
+		},
+		{
+			constraint: "^1.2",
+			match:      []string{"1.2.0", "1.9.9"},
+			noMatch:    []string{"1.1.9", "2.0.0", "2.0.0-rc.1"},
+		},
+		{
+			constraint: "^0.2.3",
+			match:      []string{"0.2.3", "0.2.9"},
+			noMatch:    []string{"0.3.0", "0.2.2"},
+		},
+		{
+			constraint: "^0.0.3",
+			match:      []string{"0.0.3"},
+			noMatch:    []string{"0.0.4"},
+		},
+		{
+			constraint: "~1.2.3",
+			match:      []string{"1.2.3", "1.2.9"},
+			noMatch:    []string{"1.3.0", "1.2.2"},
+		},
+		{
+			constraint: "~1",
+			match:      []string{"1.0.0", "1.9.0"},
+			noMatch:    []string{"2.0.0"},
+		},
+		{
+			constraint: ">=1.0.0, <2",
+			match:      []string{"1.0.0", "1.99.0"},
+			noMatch:    []string{"0.9.0", "2.0.0"},
+		},
+		{
+			constraint: ">1.0.0",
+			match:      []string{"1.0.1"},
+			noMatch:    []string{"1.0.0"},
+		},
+		{
+			constraint: "<=1.0.0",
+			match:      []string{"1.0.0", "0.1.0"},
+			noMatch:    []string{"1.0.1"},
+		},
+		{
+			constraint: ">=1.0.0-rc.1",
+			match:      []string{"1.0.0-rc.2", "1.0.0", "1.1.0"},
+			noMatch:    []string{"1.0.0-beta.1", "1.1.0-rc.1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.constraint, func(t *testing.T) {
+			constraint, err := ParseConstraint(test.constraint)
+			require.NoError(t, err)
+
+			for _, s := range test.match {
+				v, err := Parse(s)
+				require.NoError(t, err)
+				require.True(t, constraint.Check(v), "%s should satisfy %s", s, test.constraint)
+			}
+			for _, s := range test.noMatch {
+				v, err := Parse(s)
+				require.NoError(t, err)
+				require.False(t, constraint.Check(v), "%s shouldn't satisfy %s", s, test.constraint)
+			}
+		})
+	}
+}
+
+func TestParseConstraintInvalid(t *testing.T) {
+	for _, s := range []string{"^", ">=a", "1.2.3.4", "x.1", "~1.2-rc.1"} {
+		_, err := ParseConstraint(s)
+		require.Error(t, err, s)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package signature
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+)
+
+const keyExtension = ".pub"
+
+var (
+	_ Verifier = &Keyring{}
+
+	ErrUntrustedKey = errors.New("signed by an untrusted key")
+
+	keyName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+)
+
+// Verifier verifies detached signatures.
+type Verifier interface {
+	// Verify checks that sig is a valid signature of message made by a
+	// trusted key and returns that key.
+	Verify(message io.Reader, sig []byte) (Key, error)
+}
+
+// Key is a trusted public key.
+type Key struct {
+	Name      string
+	PublicKey PublicKey
+}
+
+// Keyring is the set of trusted public keys, stored as minisign .pub files in
+// a directory.
+type Keyring struct {
+	fs  afero.Fs
+	dir string
+}
+
+func NewKeyring(fs afero.Fs, dir string) *Keyring {
+	return &Keyring{
+		fs:  fs,
+		dir: dir,
+	}
+}
+
+// Add trusts key under name. key is either a base64 encoded minisign public
+// key or the contents of a minisign .pub file.
+func (k *Keyring) Add(name string, key []byte) (Key, error) {
+	if !keyName.MatchString(name) {
+		return Key{}, fmt.Errorf("%s is not a valid key name (must only contain letters, digits, '.', '_' and '-')", name)
+	}
+
+	publicKey, err := ParsePublicKey(key)
+	if err != nil {
+		return Key{}, err
+	}
+
+	keys, err := k.List()
+	if err != nil {
+		return Key{}, err
+	}
+	for _, existing := range keys {
+		if existing.Name == name {
+			return Key{}, fmt.Errorf("a key named %s already exists", name)
+		}
+		if existing.PublicKey.ID() == publicKey.ID() {
+			return Key{}, fmt.Errorf("key %s is already trusted as %s", publicKey.ID(), existing.Name)
+		}
+	}
+
+	if err := k.fs.MkdirAll(k.dir, perms.ReadWriteExecute); err != nil {
+		return Key{}, err
+	}
+
+	contents := fmt.Sprintf("%s minisign public key %s\n%s\n", untrustedCommentPrefix, publicKey.ID(), publicKey)
+	if err := afero.WriteFile(k.fs, k.path(name), []byte(contents), perms.ReadWrite); err != nil {
+		return Key{}, err
+	}
+
+	return Key{Name: name, PublicKey: publicKey}, nil
+}
+
+// Remove stops trusting the key called name.
+func (k *Keyring) Remove(name string) error {
+	if !keyName.MatchString(name) {
+		return fmt.Errorf("%s is not a valid key name", name)
+	}
+
+	if err := k.fs.Remove(k.path(name)); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("no key named %s", name)
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// List returns the trusted keys sorted by name.
+func (k *Keyring) List() ([]Key, error) {
+	entries, err := afero.ReadDir(k.fs, k.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	keys := make([]Key, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), keyExtension) {
+			continue
+		}
+
+		b, err := afero.ReadFile(k.fs, filepath.Join(k.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		publicKey, err := ParsePublicKey(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load trusted key %s: %w", entry.Name(), err)
+		}
+
+		keys = append(keys, Key{
+			Name:      strings.TrimSuffix(entry.Name(), keyExtension),
+			PublicKey: publicKey,
+		})
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Name < keys[j].Name
+	})
+
+	return keys, nil
+}
+
+func (k *Keyring) Verify(message io.Reader, sig []byte) (Key, error) {
+	signature, err := ParseSignature(sig)
+	if err != nil {
+		return Key{}, err
+	}
+
+	keys, err := k.List()
+	if err != nil {
+		return Key{}, err
+	}
+
+	for _, key := range keys {
+		if key.PublicKey.ID() != signature.KeyID() {
+			continue
+		}
+
+		if err := key.PublicKey.Verify(message, signature); err != nil {
+			return Key{}, err
+		}
+		return key, nil
+	}
+
+	return Key{}, fmt.Errorf("%w %s. Use lpm key add to trust it", ErrUntrustedKey, signature.KeyID())
+}
+
+func (k *Keyring) path(name string) string {
+	return filepath.Join(k.dir, name+keyExtension)
+}
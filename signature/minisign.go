@@ -0,0 +1,193 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package signature
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// Extension is the conventional extension of detached signature files.
+	Extension = ".minisig"
+
+	untrustedCommentPrefix = "untrusted comment:"
+	trustedCommentPrefix   = "trusted comment: "
+
+	keyIDSize = 8
+)
+
+var (
+	// legacyAlgorithm signs the message itself.
+	legacyAlgorithm = [2]byte{'E', 'd'}
+	// hashedAlgorithm signs the BLAKE2b-512 hash of the message.
+	hashedAlgorithm = [2]byte{'E', 'D'}
+
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// PublicKey is a minisign ed25519 public key.
+type PublicKey struct {
+	keyID [keyIDSize]byte
+	key   ed25519.PublicKey
+}
+
+// ParsePublicKey parses a minisign public key, either as the base64 encoded
+// key or as the contents of a minisign .pub file.
+func ParsePublicKey(text []byte) (PublicKey, error) {
+	encoded, err := payload(text)
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("invalid public key: %w", err)
+	}
+	if len(b) != 2+keyIDSize+ed25519.PublicKeySize {
+		return PublicKey{}, fmt.Errorf("invalid public key: expected %d bytes but got %d", 2+keyIDSize+ed25519.PublicKeySize, len(b))
+	}
+	if !bytes.Equal(b[:2], legacyAlgorithm[:]) {
+		return PublicKey{}, fmt.Errorf("invalid public key: unsupported algorithm %q", b[:2])
+	}
+
+	k := PublicKey{key: ed25519.PublicKey(b[2+keyIDSize:])}
+	copy(k.keyID[:], b[2:2+keyIDSize])
+	return k, nil
+}
+
+// ID returns the key ID the way minisign displays it.
+func (k PublicKey) ID() string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(k.keyID[:]))
+}
+
+// String returns the base64 encoded key.
+func (k PublicKey) String() string {
+	b := make([]byte, 0, 2+keyIDSize+ed25519.PublicKeySize)
+	b = append(b, legacyAlgorithm[:]...)
+	b = append(b, k.keyID[:]...)
+	b = append(b, k.key...)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// Signature is a detached minisign signature.
+type Signature struct {
+	algorithm [2]byte
+	keyID     [keyIDSize]byte
+	signature []byte
+
+	// TrustedComment is signed along with the signature.
+	TrustedComment  string
+	globalSignature []byte
+}
+
+// ParseSignature parses the contents of a minisign signature file.
+func ParseSignature(text []byte) (Signature, error) {
+	lines := strings.Split(strings.ReplaceAll(string(text), "\r\n", "\n"), "\n")
+	if len(lines) < 4 {
+		return Signature{}, fmt.Errorf("%w: expected 4 lines but got %d", ErrInvalidSignature, len(lines))
+	}
+	if !strings.HasPrefix(lines[0], untrustedCommentPrefix) {
+		return Signature{}, fmt.Errorf("%w: missing untrusted comment", ErrInvalidSignature)
+	}
+	if !strings.HasPrefix(lines[2], trustedCommentPrefix) {
+		return Signature{}, fmt.Errorf("%w: missing trusted comment", ErrInvalidSignature)
+	}
+
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return Signature{}, fmt.Errorf("%w: %s", ErrInvalidSignature, err)
+	}
+	if len(b) != 2+keyIDSize+ed25519.SignatureSize {
+		return Signature{}, fmt.Errorf("%w: expected %d bytes but got %d", ErrInvalidSignature, 2+keyIDSize+ed25519.SignatureSize, len(b))
+	}
+
+	globalSignature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil {
+		return Signature{}, fmt.Errorf("%w: %s", ErrInvalidSignature, err)
+	}
+	if len(globalSignature) != ed25519.SignatureSize {
+		return Signature{}, fmt.Errorf("%w: invalid trusted comment signature", ErrInvalidSignature)
+	}
+
+	s := Signature{
+		signature:       b[2+keyIDSize:],
+		TrustedComment:  strings.TrimPrefix(lines[2], trustedCommentPrefix),
+		globalSignature: globalSignature,
+	}
+	copy(s.algorithm[:], b[:2])
+	copy(s.keyID[:], b[2:2+keyIDSize])
+
+	if s.algorithm != legacyAlgorithm && s.algorithm != hashedAlgorithm {
+		return Signature{}, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidSignature, s.algorithm[:])
+	}
+
+	return s, nil
+}
+
+// KeyID returns the ID of the key that made the signature.
+func (s Signature) KeyID() string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(s.keyID[:]))
+}
+
+// Verify checks that sig is a signature of message made by k.
+func (k PublicKey) Verify(message io.Reader, sig Signature) error {
+	if k.keyID != sig.keyID {
+		return fmt.Errorf("%w: signed by key %s but expected %s", ErrInvalidSignature, sig.KeyID(), k.ID())
+	}
+
+	var signed []byte
+	switch sig.algorithm {
+	case hashedAlgorithm:
+		h, err := blake2b.New512(nil)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(h, message); err != nil {
+			return err
+		}
+		signed = h.Sum(nil)
+	default:
+		b, err := io.ReadAll(message)
+		if err != nil {
+			return err
+		}
+		signed = b
+	}
+
+	if !ed25519.Verify(k.key, signed, sig.signature) {
+		return ErrInvalidSignature
+	}
+
+	global := make([]byte, 0, len(sig.signature)+len(sig.TrustedComment))
+	global = append(global, sig.signature...)
+	global = append(global, sig.TrustedComment...)
+	if !ed25519.Verify(k.key, global, sig.globalSignature) {
+		return fmt.Errorf("%w: trusted comment was tampered with", ErrInvalidSignature)
+	}
+
+	return nil
+}
+
+// payload returns the base64 payload of a minisign file, skipping its
+// untrusted comment if there is one.
+func payload(text []byte) (string, error) {
+	for _, line := range strings.Split(string(text), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, untrustedCommentPrefix) {
+			continue
+		}
+		return line, nil
+	}
+
+	return "", errors.New("no key found")
+}
@@ -0,0 +1,166 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package signature
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+)
+
+// signer produces minisign keys and signatures.
+type signer struct {
+	keyID [keyIDSize]byte
+	pub   ed25519.PublicKey
+	priv  ed25519.PrivateKey
+}
+
+func newSigner(t *testing.T) signer {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	s := signer{pub: pub, priv: priv}
+	_, err = rand.Read(s.keyID[:])
+	require.NoError(t, err)
+	return s
+}
+
+func (s signer) publicKey() []byte {
+	b := append(append(legacyAlgorithm[:], s.keyID[:]...), s.pub...)
+	return []byte(fmt.Sprintf("untrusted comment: minisign public key\n%s\n", base64.StdEncoding.EncodeToString(b)))
+}
+
+func (s signer) sign(message []byte, algorithm [2]byte, trustedComment string) []byte {
+	signed := message
+	if algorithm == hashedAlgorithm {
+		h := blake2b.Sum512(message)
+		signed = h[:]
+	}
+
+	sig := ed25519.Sign(s.priv, signed)
+	global := ed25519.Sign(s.priv, append(append([]byte{}, sig...), trustedComment...))
+
+	b := append(append(algorithm[:], s.keyID[:]...), sig...)
+	return []byte(fmt.Sprintf(
+		"untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(b),
+		trustedComment,
+		base64.StdEncoding.EncodeToString(global),
+	))
+}
+
+func TestPublicKeyVerify(t *testing.T) {
+	s := newSigner(t)
+	message := []byte("plugin binary")
+
+	key, err := ParsePublicKey(s.publicKey())
+	require.NoError(t, err)
+
+	tamperedComment := bytes.Replace(s.sign(message, hashedAlgorithm, "file:plugin"), []byte("file:plugin"), []byte("file:other!"), 1)
+
+	tests := []struct {
+		name    string
+		message []byte
+		sig     []byte
+		wantErr error
+	}{
+		{
+			name:    "prehashed",
+			message: message,
+			sig:     s.sign(message, hashedAlgorithm, "file:plugin"),
+		},
+		{
+			name:    "legacy",
+			message: message,
+			sig:     s.sign(message, legacyAlgorithm, "file:plugin"),
+		},
+		{
+			name:    "wrong message",
+			message: []byte("malicious binary"),
+			sig:     s.sign(message, hashedAlgorithm, "file:plugin"),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "wrong key",
+			message: message,
+			sig:     newSigner(t).sign(message, hashedAlgorithm, "file:plugin"),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "tampered trusted comment",
+			message: message,
+			sig:     tamperedComment,
+			wantErr: ErrInvalidSignature,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sig, err := ParseSignature(test.sig)
+			require.NoError(t, err)
+
+			err = key.Verify(bytes.NewReader(test.message), sig)
+			require.ErrorIs(t, err, test.wantErr)
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	_, err := ParsePublicKey([]byte("not a key"))
+	require.Error(t, err)
+
+	_, err = ParseSignature([]byte("untrusted comment: foo\nAAAA\ntrusted comment: bar\nAAAA\n"))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestKeyring(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	keyring := NewKeyring(fs, "keys")
+	trusted, untrusted := newSigner(t), newSigner(t)
+	message := []byte("plugin binary")
+
+	// Nothing is trusted yet.
+	keys, err := keyring.List()
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	_, err = keyring.Verify(bytes.NewReader(message), trusted.sign(message, hashedAlgorithm, ""))
+	require.ErrorIs(t, err, ErrUntrustedKey)
+
+	added, err := keyring.Add("lux", trusted.publicKey())
+	require.NoError(t, err)
+
+	_, err = keyring.Add("lux", untrusted.publicKey())
+	require.ErrorContains(t, err, "already exists")
+	_, err = keyring.Add("other", trusted.publicKey())
+	require.ErrorContains(t, err, "already trusted")
+	_, err = keyring.Add("../escape", untrusted.publicKey())
+	require.ErrorContains(t, err, "not a valid key name")
+
+	keys, err = keyring.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.Equal(t, "lux", keys[0].Name)
+	require.Equal(t, added.PublicKey.ID(), keys[0].PublicKey.ID())
+
+	key, err := keyring.Verify(bytes.NewReader(message), trusted.sign(message, hashedAlgorithm, ""))
+	require.NoError(t, err)
+	require.Equal(t, "lux", key.Name)
+
+	_, err = keyring.Verify(bytes.NewReader(message), untrusted.sign(message, hashedAlgorithm, ""))
+	require.ErrorIs(t, err, ErrUntrustedKey)
+
+	require.NoError(t, keyring.Remove("lux"))
+	require.ErrorContains(t, keyring.Remove("lux"), "no key named lux")
+
+	_, err = keyring.Verify(bytes.NewReader(message), trusted.sign(message, hashedAlgorithm, ""))
+	require.ErrorIs(t, err, ErrUntrustedKey)
+}
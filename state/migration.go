@@ -0,0 +1,99 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package state
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/luxfi/lpm/constant"
+)
+
+const versionKey = "version"
+
+// Version is the latest state file schema version.
+var Version = len(migrations)
+
+// migration upgrades a raw state file document by one schema version.
+type migration func(document map[string]interface{}) error
+
+// migrations[i] upgrades a state file from version i to version i+1. New
+// fields that need to be backfilled for existing state files should add a
+// migration to the end of this list.
+var migrations = []migration{
+	// 0 -> 1: state files written before schema versioning was introduced.
+	// The layout is unchanged.
+	func(map[string]interface{}) error {
+		return nil
+	},
+	// 1 -> 2: installs record where they came from. Only installs from a
+	// plugin repository were recorded before this.
+	func(document map[string]interface{}) error {
+		registry, ok := document["installation-registry"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		for name, entry := range registry {
+			info, ok := entry.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("invalid installation registry entry for %s", name)
+			}
+
+			repository, definition, _ := strings.Cut(name, constant.QualifiedNameDelimiter)
+			info["origin"] = map[string]interface{}{
+				"type":       string(RepositoryOrigin),
+				"repository": repository,
+				"definition": definition,
+			}
+		}
+
+		return nil
+	},
+	// 2 -> 3: joined chains are recorded in the chain registry. Chains
+	// joined before this weren't recorded, so it starts out empty.
+	func(document map[string]interface{}) error {
+		document["chain-registry"] = map[string]interface{}{}
+		return nil
+	},
+	// 3 -> 4: installs record the joined chains that need them. Installs
+	// from before this are treated as explicit, so leaving a chain never
+	// uninstalls them.
+	func(map[string]interface{}) error {
+		return nil
+	},
+}
+
+type newerVersionError struct {
+	version int
+}
+
+func (e *newerVersionError) Error() string {
+	return fmt.Sprintf("state file has version %d but this lpm only supports up to version %d, please upgrade lpm", e.version, Version)
+}
+
+// migrate upgrades document in place to the latest schema version.
+func migrate(document map[string]interface{}) error {
+	version := 0
+	if v, ok := document[versionKey]; ok {
+		parsed, ok := v.(int)
+		if !ok || parsed < 0 {
+			return fmt.Errorf("invalid state file version %v", v)
+		}
+		version = parsed
+	}
+
+	if version > Version {
+		return &newerVersionError{version: version}
+	}
+
+	for ; version < Version; version++ {
+		if err := migrations[version](document); err != nil {
+			return fmt.Errorf("failed to migrate state file from version %d: %w", version, err)
+		}
+	}
+
+	document[versionKey] = version
+	return nil
+}
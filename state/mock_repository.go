@@ -67,6 +67,36 @@ func (mr *MockRepositoryMockRecorder) GetChain(name interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChain", reflect.TypeOf((*MockRepository)(nil).GetChain), name)
 }
 
+// ListChains mocks base method.
+func (m *MockRepository) ListChains() ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListChains")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListChains indicates an expected call of ListChains.
+func (mr *MockRepositoryMockRecorder) ListChains() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListChains", reflect.TypeOf((*MockRepository)(nil).ListChains))
+}
+
+// ListVMs mocks base method.
+func (m *MockRepository) ListVMs() ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVMs")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVMs indicates an expected call of ListVMs.
+func (mr *MockRepositoryMockRecorder) ListVMs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVMs", reflect.TypeOf((*MockRepository)(nil).ListVMs))
+}
+
 // GetVM mocks base method.
 func (m *MockRepository) GetVM(name string) (Definition[types.VM], error) {
 	m.ctrl.T.Helper()
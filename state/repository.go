@@ -4,9 +4,11 @@
 package state
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 
@@ -26,6 +28,11 @@ type Repository interface {
 	GetPath() string
 	GetVM(name string) (Definition[types.VM], error)
 	GetChain(name string) (Definition[types.Chain], error)
+	// ListVMs returns the name of every VM definition in the repository.
+	ListVMs() ([]string, error)
+	// ListChains returns the name of every chain definition in the
+	// repository.
+	ListChains() ([]string, error)
 }
 
 type DiskRepository struct {
@@ -41,6 +48,14 @@ func (d DiskRepository) GetChain(name string) (Definition[types.Chain], error) {
 	return get[types.Chain](d, chainDir, name)
 }
 
+func (d DiskRepository) ListVMs() ([]string, error) {
+	return list(d, vmDir)
+}
+
+func (d DiskRepository) ListChains() ([]string, error) {
+	return list(d, chainDir)
+}
+
 func (d DiskRepository) GetPath() string {
 	return d.Path
 }
@@ -68,3 +83,23 @@ func get[T types.Definition](d DiskRepository, dir string, file string) (Definit
 		Commit:     commit,
 	}, nil
 }
+
+func list(d DiskRepository, dir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(d.Path, dir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	suffix := fmt.Sprintf(".%s", extension)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), suffix) {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(entry.Name(), suffix))
+	}
+
+	return names, nil
+}
@@ -4,6 +4,10 @@
 package state
 
 import (
+	"fmt"
+	"path/filepath"
+	"time"
+
 	"github.com/go-git/go-git/v5/plumbing"
 
 	"github.com/luxfi/lpm/types"
@@ -16,9 +20,142 @@ type SourceInfo struct {
 	Branch plumbing.ReferenceName `yaml:"branch"`
 }
 
+// InstallInfo records an installed VM and where it was installed from.
 type InstallInfo struct {
-	ID     string `yaml:"id"`
-	Commit string `yaml:"commit"`
+	ID string `yaml:"id" json:"id"`
+	// Commit of the definition the VM was installed from, for VMs installed
+	// from a plugin repository.
+	Commit string `yaml:"commit" json:"commit"`
+	// Version is the semantic version installed from a definition, or the
+	// release tag, source ref or link label the VM was installed with, if
+	// any.
+	Version string `yaml:"version,omitempty" json:"version,omitempty"`
+	// Constraint the version has to satisfy when the VM is upgraded
+	Constraint string `yaml:"constraint,omitempty" json:"constraint,omitempty"`
+	// SHA256 of the installed binary
+	SHA256 string `yaml:"sha256,omitempty" json:"sha256,omitempty"`
+	// Signer is the ID of the key that signed the installed artifact, if it
+	// was signed.
+	Signer      string    `yaml:"signer,omitempty" json:"signer,omitempty"`
+	InstalledAt time.Time `yaml:"installed-at,omitempty" json:"installed-at,omitzero"`
+	Origin      Origin    `yaml:"origin" json:"origin"`
+	// Previous are the binaries kept when the VM was upgraded, most recently
+	// replaced first.
+	Previous []PreviousVersion `yaml:"previous,omitempty" json:"previous,omitempty"`
+	// Chains are the joined chains that need the VM, by their fully
+	// qualified names. It's the VM's reference count.
+	Chains []string `yaml:"chains,omitempty" json:"chains,omitempty"`
+	// Dependency is true if the VM was only installed because a chain
+	// needed it. It's uninstalled once no joined chain needs it, unlike VMs
+	// that were installed explicitly.
+	Dependency bool `yaml:"dependency,omitempty" json:"dependency,omitempty"`
+}
+
+// PreviousVersion is a binary kept when a VM was upgraded, so the VM can be
+// rolled back to it.
+type PreviousVersion struct {
+	Version     string    `yaml:"version,omitempty" json:"version,omitempty"`
+	Commit      string    `yaml:"commit,omitempty" json:"commit,omitempty"`
+	SHA256      string    `yaml:"sha256,omitempty" json:"sha256,omitempty"`
+	Signer      string    `yaml:"signer,omitempty" json:"signer,omitempty"`
+	InstalledAt time.Time `yaml:"installed-at,omitempty" json:"installed-at,omitzero"`
+	// Path of the kept binary, relative to the plugin directory
+	Path string `yaml:"path" json:"path"`
+}
+
+// Label returns the version, or the commit if there is no version.
+func (p PreviousVersion) Label() string {
+	if p.Version != "" {
+		return p.Version
+	}
+	return p.Commit
+}
+
+// BinaryPath returns where the VM's binary lives in the plugin directory.
+func (i InstallInfo) BinaryPath(pluginDir string) string {
+	if i.Origin.Type == LinkOrigin {
+		return filepath.Join(pluginDir, LinkDir, i.ID)
+	}
+
+	return filepath.Join(pluginDir, i.ID)
+}
+
+// LinkDir is the plugin sub-directory development links are created in.
+const LinkDir = "current"
+
+// VersionsDir is the plugin sub-directory previous binaries are kept in, by
+// VMID.
+const VersionsDir = ".versions"
+
+// OriginType is the kind of source a VM was installed from.
+type OriginType string
+
+const (
+	RepositoryOrigin OriginType = "repository"
+	GitHubOrigin     OriginType = "github"
+	GitLabOrigin     OriginType = "gitlab"
+	URLOrigin        OriginType = "url"
+	SourceOrigin     OriginType = "source"
+	LinkOrigin       OriginType = "link"
+)
+
+// Origin describes where an installed VM came from. Only the fields relevant
+// to Type are set.
+type Origin struct {
+	Type OriginType `yaml:"type" json:"type"`
+	// Alias of the plugin repository and the name of the VM's definition in
+	// it (repository)
+	Repository string `yaml:"repository,omitempty" json:"repository,omitempty"`
+	Definition string `yaml:"definition,omitempty" json:"definition,omitempty"`
+	// Project the VM was released or built from (github, gitlab, source)
+	Owner string `yaml:"owner,omitempty" json:"owner,omitempty"`
+	Repo  string `yaml:"repo,omitempty" json:"repo,omitempty"`
+	// Release tag (github, gitlab) or git ref (source)
+	Tag string `yaml:"tag,omitempty" json:"tag,omitempty"`
+	Ref string `yaml:"ref,omitempty" json:"ref,omitempty"`
+	// GitLab instance the release was downloaded from (gitlab)
+	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
+	// URL the binary was downloaded from (github, gitlab, url)
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+	// Checksum of the release asset at URL, as published in the release
+	// (github, gitlab) or passed with --sha256 (url)
+	SHA256 string `yaml:"sha256,omitempty" json:"sha256,omitempty"`
+	// Detached signature of the binary at URL (url)
+	Signature string `yaml:"signature,omitempty" json:"signature,omitempty"`
+	// Path of the binary inside the release archive at URL (github, gitlab,
+	// url)
+	Binary string `yaml:"binary,omitempty" json:"binary,omitempty"`
+	// Local binary the plugin links to (link)
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+}
+
+func (o Origin) String() string {
+	switch o.Type {
+	case RepositoryOrigin:
+		return fmt.Sprintf("%s %s", o.Type, o.Repository)
+	case URLOrigin:
+		return fmt.Sprintf("%s %s", o.Type, o.URL)
+	case LinkOrigin:
+		return fmt.Sprintf("%s %s", o.Type, o.Path)
+	case GitLabOrigin:
+		return fmt.Sprintf("%s %s/%s/%s", o.Type, o.BaseURL, o.Owner, o.Repo)
+	default:
+		return fmt.Sprintf("%s %s/%s", o.Type, o.Owner, o.Repo)
+	}
+}
+
+// RegistryName returns the name an install from this origin is recorded
+// under in the installation registry. VMs from a plugin repository use their
+// qualified name, everything else is keyed by the origin type.
+func (o Origin) RegistryName(vmID string) string {
+	switch o.Type {
+	case RepositoryOrigin:
+		return fmt.Sprintf("%s:%s", o.Repository, o.Definition)
+	case URLOrigin:
+		return fmt.Sprintf("%s:%s", o.Type, vmID)
+	default:
+		return fmt.Sprintf("%s:%s/%s", o.Type, o.Owner, o.Repo)
+	}
 }
 
 // Definition stores a plugin definition alongside the plugin-repository's commit
@@ -27,3 +164,18 @@ type Definition[T types.Definition] struct {
 	Definition T      `yaml:"definition"`
 	Commit     string `yaml:"commit"`
 }
+
+// ChainInfo records a joined chain and the configs written for it.
+type ChainInfo struct {
+	// ID of the chain on Network
+	ID      string `yaml:"id" json:"id"`
+	Network string `yaml:"network" json:"network"`
+	// Commit of the definition the configs were written from
+	Commit string `yaml:"commit" json:"commit"`
+	// Configs maps each file written to the node's chain config directory
+	// to its SHA256.
+	Configs map[string]string `yaml:"configs,omitempty" json:"configs,omitempty"`
+	// VMs the chain needs, by their fully qualified names
+	VMs      []string  `yaml:"vms,omitempty" json:"vms,omitempty"`
+	JoinedAt time.Time `yaml:"joined-at,omitempty" json:"joined-at,omitzero"`
+}
@@ -5,6 +5,7 @@ package state
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -14,47 +15,212 @@ import (
 
 const (
 	stateFile = "lpm.state"
+	// number of previous versions of the state file to keep around
+	backups = 5
 )
 
 func newEmpty(path string) File {
 	return File{
+		Version:              Version,
 		Sources:              make(map[string]*SourceInfo),
 		InstallationRegistry: make(map[string]*InstallInfo),
+		ChainRegistry:        make(map[string]*ChainInfo),
 		path:                 filepath.Join(path, stateFile),
 	}
 }
 
+// New loads the state file in the provided directory, migrating it to the
+// latest schema version if needed. If the state file is corrupt, the most
+// recent readable backup is used instead.
 func New(path string) (File, error) {
 	result := newEmpty(path)
 
 	b, err := os.ReadFile(result.path)
-	if err != nil && !errors.Is(err, os.ErrNotExist) {
+	if errors.Is(err, os.ErrNotExist) {
+		return result, nil
+	} else if err != nil {
 		return File{}, err
 	}
 
-	if err := yaml.Unmarshal(b, result); err != nil {
-		return File{}, err
+	loadErr := load(b, &result)
+	if loadErr == nil {
+		return result, nil
+	}
+
+	var errNewer *newerVersionError
+	if errors.As(loadErr, &errNewer) {
+		return File{}, loadErr
+	}
+
+	for i := 1; i <= backups; i++ {
+		backup := backupPath(result.path, i)
+		b, err := os.ReadFile(backup)
+		if err != nil {
+			continue
+		}
+
+		restored := newEmpty(path)
+		if err := load(b, &restored); err != nil {
+			continue
+		}
+
+		fmt.Printf("State file %s is unreadable (%s). Using backup %s instead.\n", result.path, loadErr, backup)
+		return restored, nil
+	}
+
+	return File{}, fmt.Errorf("failed to load state file %s: %w", result.path, loadErr)
+}
+
+// load migrates the serialized state in b to the latest version and decodes
+// it into result.
+func load(b []byte, result *File) error {
+	document := make(map[string]interface{})
+	if err := yaml.Unmarshal(b, &document); err != nil {
+		return err
+	}
+
+	if err := migrate(document); err != nil {
+		return err
+	}
+
+	b, err := yaml.Marshal(document)
+	if err != nil {
+		return err
 	}
 
-	return result, nil
+	return yaml.Unmarshal(b, result)
 }
 
 // File is the representation of the current LPM state.
 // Not safe for concurrent use.
 type File struct {
+	// Schema version this file was written with
+	Version int `yaml:"version"`
 	// Mapping of each tracked repository's alias to its metadata
 	Sources map[string]*SourceInfo `yaml:"sources"`
 	// Mapping of each installed vm's alias to the version installed
 	InstallationRegistry map[string]*InstallInfo `yaml:"installation-registry"`
+	// Mapping of each joined chain's alias to the chain and its configs
+	ChainRegistry map[string]*ChainInfo `yaml:"chain-registry"`
 
 	path string
 }
 
+// Snapshot returns a deep copy of the current state that can later be passed
+// to Restore.
+func (s *File) Snapshot() ([]byte, error) {
+	return yaml.Marshal(s)
+}
+
+// Restore resets the state to a snapshot previously returned by Snapshot.
+// The underlying maps are updated in place, so every copy of this File
+// observes the restored state.
+func (s *File) Restore(snapshot []byte) error {
+	restored := newEmpty(filepath.Dir(s.path))
+	if err := yaml.Unmarshal(snapshot, &restored); err != nil {
+		return err
+	}
+
+	replace(s.Sources, restored.Sources)
+	replace(s.InstallationRegistry, restored.InstallationRegistry)
+	replace(s.ChainRegistry, restored.ChainRegistry)
+	return nil
+}
+
+// Commit persists the state file. The new contents are written to a
+// temporary file which is synced to disk and then atomically renamed over the
+// previous state file, so a crash never leaves a partially written state.
+// The previous state file is kept as a backup.
 func (s *File) Commit() error {
 	bytes, err := yaml.Marshal(s)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(s.path, bytes, perms.ReadWrite)
+	dir := filepath.Dir(s.path)
+	tmp, err := os.CreateTemp(dir, fmt.Sprintf(".%s.tmp-*", stateFile))
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		// no-op if the rename succeeded
+		_ = os.Remove(tmpPath)
+	}()
+
+	if _, err := tmp.Write(bytes); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perms.ReadWrite); err != nil {
+		return err
+	}
+
+	if err := s.rotateBackups(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// rotateBackups shifts each backup back by one, dropping the oldest, and
+// makes the current state file the most recent backup.
+func (s *File) rotateBackups() error {
+	if _, err := os.Stat(s.path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for i := backups - 1; i >= 1; i-- {
+		err := os.Rename(backupPath(s.path, i), backupPath(s.path, i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	// The current state file has to stay in place until the new one is
+	// renamed over it, so copy it instead of moving it.
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(backupPath(s.path, 1), b, perms.ReadWrite)
+}
+
+func backupPath(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+// syncDir flushes a directory entry update (e.g a rename) to disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	// Not every platform supports syncing directories, and the rename has
+	// already happened at this point.
+	_ = d.Sync()
+	return nil
+}
+
+func replace[K comparable, V any](dst map[K]V, src map[K]V) {
+	clear(dst)
+	for k, v := range src {
+		dst[k] = v
+	}
 }
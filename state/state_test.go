@@ -0,0 +1,136 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/luxfi/filesystem/perms"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    func(*testing.T, File)
+		wantErr bool
+	}{
+		{
+			name: "no state file",
+			want: func(t *testing.T, f File) {
+				require.Equal(t, Version, f.Version)
+				require.Empty(t, f.Sources)
+			},
+		},
+		{
+			name: "unversioned state file is migrated",
+			files: map[string]string{
+				stateFile: "sources:\n  organization/repository:\n    url: url\n    commit: commit\n",
+			},
+			want: func(t *testing.T, f File) {
+				require.Equal(t, Version, f.Version)
+				require.Equal(t, "url", f.Sources["organization/repository"].URL)
+			},
+		},
+		{
+			name: "unversioned installs are recorded as repository installs",
+			files: map[string]string{
+				stateFile: "installation-registry:\n  organization/repository:vm:\n    id: id\n    commit: commit\n",
+			},
+			want: func(t *testing.T, f File) {
+				info := f.InstallationRegistry["organization/repository:vm"]
+				require.Equal(t, "id", info.ID)
+				require.Equal(t, Origin{
+					Type:       RepositoryOrigin,
+					Repository: "organization/repository",
+					Definition: "vm",
+				}, info.Origin)
+			},
+		},
+		{
+			name: "chains joined before they were recorded",
+			files: map[string]string{
+				stateFile: "version: 2\ninstallation-registry: {}\n",
+			},
+			want: func(t *testing.T, f File) {
+				require.Equal(t, Version, f.Version)
+				require.NotNil(t, f.ChainRegistry)
+				require.Empty(t, f.ChainRegistry)
+			},
+		},
+		{
+			name: "state file from a newer lpm",
+			files: map[string]string{
+				stateFile: fmt.Sprintf("version: %d\n", Version+1),
+			},
+			wantErr: true,
+		},
+		{
+			name: "corrupt state file falls back to backup",
+			files: map[string]string{
+				stateFile:                "sources: [",
+				backupPath(stateFile, 1): "sources:\n  organization/repository:\n    url: url\n",
+			},
+			want: func(t *testing.T, f File) {
+				require.Equal(t, "url", f.Sources["organization/repository"].URL)
+			},
+		},
+		{
+			name: "corrupt state file without backups",
+			files: map[string]string{
+				stateFile: "sources: [",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, contents := range test.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), perms.ReadWrite))
+			}
+
+			f, err := New(dir)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			test.want(t, f)
+		})
+	}
+}
+
+func TestCommit(t *testing.T) {
+	dir := t.TempDir()
+
+	f, err := New(dir)
+	require.NoError(t, err)
+
+	for i := 0; i < backups+2; i++ {
+		f.Sources["organization/repository"] = &SourceInfo{Commit: fmt.Sprint(i)}
+		require.NoError(t, f.Commit())
+	}
+
+	loaded, err := New(dir)
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprint(backups+1), loaded.Sources["organization/repository"].Commit)
+
+	// the most recent backup is the previous commit
+	b, err := os.ReadFile(backupPath(filepath.Join(dir, stateFile), 1))
+	require.NoError(t, err)
+	require.Contains(t, string(b), fmt.Sprintf("commit: \"%d\"", backups))
+
+	// only the configured number of backups are kept and no temporary files
+	// are left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, backups+1)
+}
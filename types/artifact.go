@@ -0,0 +1,28 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package types
+
+// Archive formats an artifact can be published in.
+const (
+	TarFormat    = "tar"
+	TarGzFormat  = "tar.gz"
+	TarXzFormat  = "tar.xz"
+	TarZstFormat = "tar.zst"
+	ZipFormat    = "zip"
+	// BinaryFormat is an executable that isn't wrapped in an archive.
+	BinaryFormat = "binary"
+)
+
+// Artifact is a prebuilt VM for a single platform.
+type Artifact struct {
+	URL    string `yaml:"url" json:"url"`
+	SHA256 string `yaml:"sha256" json:"sha256"`
+	// URL of a detached minisign signature of the file at URL
+	Signature string `yaml:"signature,omitempty" json:"signature,omitempty"`
+	// Path of the binary in the archive. Unused for binary artifacts.
+	BinaryPath string `yaml:"binaryPath,omitempty" json:"binaryPath,omitempty"`
+	// Format of the artifact at URL, one of tar, tar.gz, tar.xz, tar.zst, zip
+	// or binary. Defaults to tar.gz.
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+}
@@ -0,0 +1,36 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package types
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+const platformDelimiter = "/"
+
+// Platform is an operating system and architecture pair, e.g linux/amd64.
+type Platform struct {
+	OS   string
+	Arch string
+}
+
+// CurrentPlatform returns the platform lpm is running on.
+func CurrentPlatform() Platform {
+	return Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
+}
+
+// ParsePlatform parses a platform in the form os/arch.
+func ParsePlatform(s string) (Platform, error) {
+	os, arch, ok := strings.Cut(s, platformDelimiter)
+	if !ok || os == "" || arch == "" || strings.Contains(arch, platformDelimiter) {
+		return Platform{}, fmt.Errorf("invalid platform %q (must be in the form of os/arch, e.g linux/amd64)", s)
+	}
+	return Platform{OS: os, Arch: arch}, nil
+}
+
+func (p Platform) String() string {
+	return p.OS + platformDelimiter + p.Arch
+}
@@ -6,13 +6,29 @@ package types
 var _ Definition = &Chain{}
 
 type Chain struct {
-	ID          map[string]string `yaml:"id"`
-	Alias       string            `yaml:"alias"`
-	Homepage    string            `yaml:"homepage"`
-	Description string            `yaml:"description"`
-	Maintainers []string          `yaml:"maintainers"`
-	VMs         []string          `yaml:"vms"`
-	// Config      chains.ChainConfig `yaml:"config,omitempty"`
+	ID          map[string]string `yaml:"id" json:"id"`
+	Alias       string            `yaml:"alias" json:"alias"`
+	Homepage    string            `yaml:"homepage" json:"homepage"`
+	Description string            `yaml:"description" json:"description"`
+	Maintainers []string          `yaml:"maintainers" json:"maintainers"`
+	VMs         []string          `yaml:"vms" json:"vms"`
+	// Configs are the files validators of the chain need, by network. They're
+	// written to the node's chain config directory when the chain is joined.
+	Configs map[string]ChainConfig `yaml:"configs,omitempty" json:"configs,omitempty"`
+}
+
+// ChainConfig holds the files a node needs to validate a chain on a network.
+type ChainConfig struct {
+	Genesis *ChainFile `yaml:"genesis,omitempty" json:"genesis,omitempty"`
+	Config  *ChainFile `yaml:"config,omitempty" json:"config,omitempty"`
+	Upgrade *ChainFile `yaml:"upgrade,omitempty" json:"upgrade,omitempty"`
+}
+
+// ChainFile is the JSON contents of a chain config file.
+type ChainFile struct {
+	Data string `yaml:"data" json:"data"`
+	// SHA256 of Data, checked before it's written
+	SHA256 string `yaml:"sha256" json:"sha256"`
 }
 
 func (s Chain) GetID(network string) (string, bool) {
@@ -20,6 +36,12 @@ func (s Chain) GetID(network string) (string, bool) {
 	return id, ok
 }
 
+// GetConfig returns the files validators of the chain need on network.
+func (s Chain) GetConfig(network string) (ChainConfig, bool) {
+	config, ok := s.Configs[network]
+	return config, ok
+}
+
 func (s Chain) GetAlias() string {
 	return s.Alias
 }
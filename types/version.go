@@ -0,0 +1,19 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package types
+
+// Version is a released version of a VM. The install script and binary path
+// fall back to the ones in the VM definition if they aren't set.
+type Version struct {
+	// Semantic version of the release, e.g 1.2.3
+	Version       string `yaml:"version" json:"version"`
+	URL           string `yaml:"url" json:"url"`
+	SHA256        string `yaml:"sha256" json:"sha256"`
+	Signature     string `yaml:"signature,omitempty" json:"signature,omitempty"`
+	InstallScript string `yaml:"installScript,omitempty" json:"installScript,omitempty"`
+	BinaryPath    string `yaml:"binaryPath,omitempty" json:"binaryPath,omitempty"`
+	Format        string `yaml:"format,omitempty" json:"format,omitempty"`
+	// Prebuilt artifacts of this version keyed by platform
+	Artifacts map[string]Artifact `yaml:"artifacts,omitempty" json:"artifacts,omitempty"`
+}
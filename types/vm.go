@@ -6,15 +6,25 @@ package types
 var _ Definition = &VM{}
 
 type VM struct {
-	ID            string   `yaml:"id"`
-	Alias         string   `yaml:"alias"`
-	Homepage      string   `yaml:"homepage"`
-	Description   string   `yaml:"description"`
-	Maintainers   []string `yaml:"maintainers"`
-	InstallScript string   `yaml:"installScript"`
-	BinaryPath    string   `yaml:"binaryPath"`
-	URL           string   `yaml:"url"`
-	SHA256        string   `yaml:"sha256"`
+	ID            string   `yaml:"id" json:"id"`
+	Alias         string   `yaml:"alias" json:"alias"`
+	Homepage      string   `yaml:"homepage" json:"homepage"`
+	Description   string   `yaml:"description" json:"description"`
+	Maintainers   []string `yaml:"maintainers" json:"maintainers"`
+	InstallScript string   `yaml:"installScript" json:"installScript"`
+	BinaryPath    string   `yaml:"binaryPath" json:"binaryPath"`
+	URL           string   `yaml:"url" json:"url"`
+	SHA256        string   `yaml:"sha256" json:"sha256"`
+	// URL of a detached minisign signature of the file at URL
+	Signature string `yaml:"signature,omitempty" json:"signature,omitempty"`
+	// Format of the archive at URL. Defaults to tar.gz.
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+	// Prebuilt artifacts keyed by the platform they're for (e.g
+	// linux/amd64). If there are any, they're installed instead of URL.
+	Artifacts map[string]Artifact `yaml:"artifacts,omitempty" json:"artifacts,omitempty"`
+	// Released versions of the VM. If there are none, URL and SHA256 are
+	// installed.
+	Versions []Version `yaml:"versions,omitempty" json:"versions,omitempty"`
 }
 
 func (vm VM) GetID() string {
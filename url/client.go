@@ -4,61 +4,494 @@
 package url
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/cavaliergopher/grab/v3"
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
 )
 
-var _ Client = &client{}
+const (
+	DefaultTimeout    = 30 * time.Second
+	DefaultRetries    = 5
+	DefaultBackoff    = time.Second
+	DefaultMaxBackoff = 30 * time.Second
+
+	// partialExtension is appended to the path a download is written to until
+	// it completes.
+	partialExtension = ".part"
+)
+
+var (
+	_ Client = &client{}
+
+	errChanged = errors.New("remote file changed during download")
+)
 
 type Client interface {
+	// Download downloads url to path.
 	Download(url string, path string) error
+	// DownloadWithHeader downloads url to path, sending header with every
+	// request.
+	DownloadWithHeader(url string, path string, header http.Header) error
+	// Get returns the body of url, sending header with the request.
+	Get(url string, header http.Header) ([]byte, error)
 }
 
-func NewClient() Client {
+// Config configures how files are downloaded.
+type Config struct {
+	// Timeout bounds connecting, waiting for a response and waiting for more
+	// data while downloading. Defaults to DefaultTimeout.
+	Timeout time.Duration
+	// Retries is the number of times a failed request is retried. Defaults to
+	// DefaultRetries. Negative values disable retries.
+	Retries int
+	// Backoff is the delay before the first retry, doubled after every retry
+	// up to MaxBackoff. Defaults to DefaultBackoff and DefaultMaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Proxy is the URL of the proxy requests are sent through. Defaults to
+	// the proxy set in the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment
+	// variables.
+	Proxy string
+	// CAFile is a PEM bundle of certificate authorities trusted on top of the
+	// system's.
+	CAFile string
+	// Fs is where downloads are written to. Defaults to the OS filesystem.
+	Fs afero.Fs
+	// Offline refuses every request instead of touching the network
+	Offline bool
+}
+
+// NewClient returns a Client that retries failed requests with exponential
+// backoff and resumes interrupted downloads where they left off.
+func NewClient(config Config) (Client, error) {
+	if config.Offline {
+		return offlineClient{}, nil
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.Retries == 0 {
+		config.Retries = DefaultRetries
+	}
+	if config.Retries < 0 {
+		config.Retries = 0
+	}
+	if config.Backoff == 0 {
+		config.Backoff = DefaultBackoff
+	}
+	if config.MaxBackoff == 0 {
+		config.MaxBackoff = DefaultMaxBackoff
+	}
+	if config.Fs == nil {
+		config.Fs = afero.NewOsFs()
+	}
+
+	transport, err := newTransport(config)
+	if err != nil {
+		return nil, err
+	}
+
 	return &client{
-		grab.NewClient(),
+		client:     &http.Client{Transport: transport},
+		fs:         config.Fs,
+		timeout:    config.Timeout,
+		retries:    config.Retries,
+		backoff:    config.Backoff,
+		maxBackoff: config.MaxBackoff,
+		sleep:      time.Sleep,
+	}, nil
+}
+
+func newTransport(config Config) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   config.Timeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = config.Timeout
+	transport.ResponseHeaderTimeout = config.Timeout
+
+	if config.Proxy != "" {
+		proxy, err := neturl.Parse(config.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %s: %w", config.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
 	}
+
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", config.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    pool,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	return transport, nil
 }
 
 type client struct {
-	client *grab.Client
+	client *http.Client
+	fs     afero.Fs
+
+	timeout    time.Duration
+	retries    int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	sleep      func(time.Duration)
+}
+
+func (c *client) Download(url string, path string) error {
+	return c.DownloadWithHeader(url, path, nil)
+}
+
+func (c *client) DownloadWithHeader(url string, path string, header http.Header) error {
+	fmt.Printf("Downloading %v...\n", url)
+
+	if err := c.fs.MkdirAll(filepath.Dir(path), perms.ReadWriteExecute); err != nil {
+		return err
+	}
+
+	partialPath := path + partialExtension
+	// Don't resume a download left behind by an earlier run, since there's
+	// no telling whether it's still the same file.
+	if err := c.fs.Remove(partialPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	d := &download{
+		client:      c,
+		url:         url,
+		header:      header,
+		partialPath: partialPath,
+	}
+	if err := c.retry(d.attempt); err != nil {
+		_ = c.fs.Remove(partialPath)
+		return fmt.Errorf("download of %s failed: %w", url, err)
+	}
+
+	if err := c.fs.Rename(partialPath, path); err != nil {
+		return err
+	}
+
+	fmt.Printf("Downloaded %d bytes\n", d.written)
+	return nil
+}
+
+func (c *client) Get(url string, header http.Header) ([]byte, error) {
+	var body []byte
+	err := c.retry(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		defer cancel()
+
+		resp, err := c.do(ctx, url, header)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return newStatusError(resp, string(b))
+		}
+
+		body, err = io.ReadAll(resp.Body)
+		return err
+	})
+	return body, err
+}
+
+// retry calls f until it succeeds, it fails with an error that isn't worth
+// retrying or it runs out of retries.
+func (c *client) retry(f func() error) error {
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		err := f()
+		if err == nil || !retryable(err) || attempt >= c.retries {
+			return err
+		}
+
+		fmt.Printf("Warning - %s. Retrying in %s (%d/%d)...\n", err, backoff, attempt+1, c.retries)
+		c.sleep(backoff)
+
+		backoff *= 2
+		if backoff > c.maxBackoff {
+			backoff = c.maxBackoff
+		}
+	}
+}
+
+func (c *client) do(ctx context.Context, url string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, &permanentError{err: err}
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	return c.client.Do(req)
+}
+
+// download is a download that's resumed from where the last attempt stopped.
+type download struct {
+	client      *client
+	url         string
+	header      http.Header
+	partialPath string
+
+	// validator identifies the version of the file being downloaded, so a
+	// resumed download starts over rather than mixing two versions.
+	validator string
+	written   int64
 }
 
-func (h client) Download(url string, path string) error {
-	req, err := grab.NewRequest(path, url)
+func (d *download) attempt() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	header := d.header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	if d.written > 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-", d.written))
+		if d.validator != "" {
+			header.Set("If-Range", d.validator)
+		}
+	}
+
+	resp, err := d.client.do(ctx, d.url, header)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
-	fmt.Printf("Downloading %v...\n", req.URL())
-	resp := h.client.Do(req)
-	fmt.Printf("HTTP response %v\n", resp.HTTPResponse.Status)
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if d.written > 0 {
+			// Either the server can't serve ranges or the file changed.
+			fmt.Printf("Warning - couldn't resume the download of %s. Starting over...\n", d.url)
+		}
+		d.written = 0
+		d.validator = validator(resp)
+		flags |= os.O_TRUNC
+	case http.StatusPartialContent:
+		start, err := contentRangeStart(resp.Header.Get("Content-Range"))
+		if err != nil || start != d.written {
+			return &permanentError{err: fmt.Errorf("%w: unexpected range %q", errChanged, resp.Header.Get("Content-Range"))}
+		}
+		fmt.Printf("Resuming download at %d bytes...\n", d.written)
+		flags |= os.O_APPEND
+	case http.StatusRequestedRangeNotSatisfiable:
+		if d.written > 0 && contentRangeSize(resp.Header.Get("Content-Range")) == d.written {
+			// The last attempt got everything but didn't know it was done.
+			return nil
+		}
+		return newStatusError(resp, "")
+	default:
+		return newStatusError(resp, "")
+	}
 
-	// Start progress loop
-	t := time.NewTicker(1 * time.Second)
-	defer t.Stop()
+	f, err := d.client.fs.OpenFile(d.partialPath, flags, perms.ReadWrite)
+	if err != nil {
+		return &permanentError{err: err}
+	}
+	defer f.Close()
 
-Loop:
-	for {
-		select {
-		case <-t.C:
-			fmt.Printf("  transferred %v / %v bytes (%.2f%%)\n",
-				resp.BytesComplete(),
-				resp.Size(),
-				100*resp.Progress())
+	total := d.written + resp.ContentLength
+	if resp.ContentLength < 0 {
+		total = -1
+	}
+	body := newIdleTimeoutReader(resp.Body, d.client.timeout, cancel)
+	defer body.stop()
 
-		case <-resp.Done:
-			// download is complete
-			break Loop
+	p := &progress{total: total, written: d.written, last: time.Now()}
+	n, err := io.Copy(f, io.TeeReader(body, p))
+	d.written += n
+	if err != nil {
+		if body.timedOut() {
+			return fmt.Errorf("no data received for %s", d.client.timeout)
 		}
+		return err
+	}
+	if resp.ContentLength >= 0 && n != resp.ContentLength {
+		return fmt.Errorf("connection closed after %d of %d bytes", d.written, total)
 	}
 
-	// check for errors
-	if err := resp.Err(); err != nil {
-		return fmt.Errorf("Download failed: %s", err)
+	return f.Close()
+}
+
+// validator returns the value to send in If-Range to resume resp's file.
+func validator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
 	}
+	return resp.Header.Get("Last-Modified")
+}
 
-	return nil
+// contentRangeStart returns the first byte of a "bytes start-end/size"
+// Content-Range.
+func contentRangeStart(contentRange string) (int64, error) {
+	r, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return 0, fmt.Errorf("invalid content range %q", contentRange)
+	}
+	start, _, ok := strings.Cut(r, "-")
+	if !ok {
+		return 0, fmt.Errorf("invalid content range %q", contentRange)
+	}
+	return strconv.ParseInt(start, 10, 64)
+}
+
+// contentRangeSize returns the size in a "bytes */size" Content-Range, or -1.
+func contentRangeSize(contentRange string) int64 {
+	_, size, ok := strings.Cut(contentRange, "/")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(size, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// progress prints how much of a download is done at most once per second.
+type progress struct {
+	total   int64
+	written int64
+	last    time.Time
+}
+
+func (p *progress) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+	if now := time.Now(); now.Sub(p.last) >= time.Second {
+		p.last = now
+		if p.total > 0 {
+			fmt.Printf("  transferred %v / %v bytes (%.2f%%)\n", p.written, p.total, 100*float64(p.written)/float64(p.total))
+		} else {
+			fmt.Printf("  transferred %v bytes\n", p.written)
+		}
+	}
+	return len(b), nil
+}
+
+// idleTimeoutReader cancels a request if its body doesn't produce any data
+// for timeout.
+type idleTimeoutReader struct {
+	r       io.Reader
+	timeout time.Duration
+	timer   *time.Timer
+	expired chan struct{}
+}
+
+func newIdleTimeoutReader(r io.Reader, timeout time.Duration, cancel context.CancelFunc) *idleTimeoutReader {
+	i := &idleTimeoutReader{
+		r:       r,
+		timeout: timeout,
+		expired: make(chan struct{}),
+	}
+	i.timer = time.AfterFunc(timeout, func() {
+		close(i.expired)
+		cancel()
+	})
+	return i
+}
+
+func (i *idleTimeoutReader) Read(b []byte) (int, error) {
+	n, err := i.r.Read(b)
+	if n > 0 {
+		i.timer.Reset(i.timeout)
+	}
+	return n, err
+}
+
+func (i *idleTimeoutReader) stop() {
+	i.timer.Stop()
+}
+
+func (i *idleTimeoutReader) timedOut() bool {
+	select {
+	case <-i.expired:
+		return true
+	default:
+		return false
+	}
+}
+
+// statusError is an unexpected HTTP response.
+type statusError struct {
+	code int
+	msg  string
+}
+
+func newStatusError(resp *http.Response, body string) *statusError {
+	msg := fmt.Sprintf("HTTP %d", resp.StatusCode)
+	if body = strings.TrimSpace(body); body != "" {
+		msg = fmt.Sprintf("%s: %s", msg, body)
+	}
+	return &statusError{code: resp.StatusCode, msg: msg}
+}
+
+func (e *statusError) Error() string {
+	return e.msg
+}
+
+// permanentError is an error that retrying won't fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+func retryable(err error) bool {
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+
+	var status *statusError
+	if errors.As(err, &status) {
+		return status.code == http.StatusRequestTimeout ||
+			status.code == http.StatusTooManyRequests ||
+			status.code >= http.StatusInternalServerError
+	}
+
+	// Anything else went wrong with the connection.
+	return true
 }
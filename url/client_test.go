@@ -0,0 +1,203 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package url
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+var binary = []byte(strings.Repeat("0123456789", 1000))
+
+// serveBinary serves binary with support for range requests.
+func serveBinary(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("ETag", `"v1"`)
+	http.ServeContent(w, r, "binary", time.Time{}, bytes.NewReader(binary))
+}
+
+// cutOff sends the headers for all of binary but closes the connection
+// halfway through the body.
+func cutOff(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("ETag", `"v1"`)
+	w.Header().Set("Content-Length", fmt.Sprint(len(binary)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(binary[:len(binary)/2])
+	w.(http.Flusher).Flush()
+
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err == nil {
+		_ = conn.Close()
+	}
+}
+
+func TestClient_Download(t *testing.T) {
+	tests := []struct {
+		name string
+		// responses handles each request in turn. The last one handles any
+		// further requests.
+		responses    []http.HandlerFunc
+		retries      int
+		wantRequests int32
+		wantRanges   []string
+		wantErr      bool
+	}{
+		{
+			name:         "success",
+			responses:    []http.HandlerFunc{serveBinary},
+			wantRequests: 1,
+			wantRanges:   []string{""},
+		},
+		{
+			name: "retries server errors",
+			responses: []http.HandlerFunc{
+				func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusBadGateway) },
+				func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) },
+				serveBinary,
+			},
+			wantRequests: 3,
+			wantRanges:   []string{"", "", ""},
+		},
+		{
+			name: "resumes interrupted downloads",
+			responses: []http.HandlerFunc{
+				cutOff,
+				serveBinary,
+			},
+			wantRequests: 2,
+			wantRanges:   []string{"", fmt.Sprintf("bytes=%d-", len(binary)/2)},
+		},
+		{
+			name: "starts over if the server can't resume",
+			responses: []http.HandlerFunc{
+				cutOff,
+				func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write(binary) },
+			},
+			wantRequests: 2,
+			wantRanges:   []string{"", fmt.Sprintf("bytes=%d-", len(binary)/2)},
+		},
+		{
+			name: "gives up after retries",
+			responses: []http.HandlerFunc{
+				func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusInternalServerError) },
+			},
+			retries:      2,
+			wantRequests: 3,
+			wantErr:      true,
+		},
+		{
+			name: "doesn't retry client errors",
+			responses: []http.HandlerFunc{
+				func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNotFound) },
+			},
+			wantRequests: 1,
+			wantErr:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests atomic.Int32
+			var ranges []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				i := int(requests.Add(1)) - 1
+				ranges = append(ranges, r.Header.Get("Range"))
+				test.responses[min(i, len(test.responses)-1)](w, r)
+			}))
+			defer server.Close()
+
+			fs := afero.NewMemMapFs()
+			c, err := NewClient(Config{Retries: test.retries, Fs: fs})
+			require.NoError(t, err)
+			var sleeps []time.Duration
+			c.(*client).sleep = func(d time.Duration) {
+				sleeps = append(sleeps, d)
+			}
+
+			err = c.Download(server.URL, "plugin")
+			require.Equal(t, test.wantRequests, requests.Load())
+			if test.wantRanges != nil {
+				require.Equal(t, test.wantRanges, ranges)
+			}
+
+			partial, _ := afero.Exists(fs, "plugin"+partialExtension)
+			require.False(t, partial)
+			if test.wantErr {
+				require.Error(t, err)
+				exists, _ := afero.Exists(fs, "plugin")
+				require.False(t, exists)
+				return
+			}
+			require.NoError(t, err)
+
+			b, err := afero.ReadFile(fs, "plugin")
+			require.NoError(t, err)
+			require.Equal(t, binary, b)
+
+			// Retries back off exponentially.
+			for i := 1; i < len(sleeps); i++ {
+				require.Equal(t, 2*sleeps[i-1], sleeps[i])
+			}
+		})
+	}
+}
+
+func TestClient_Get(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(r.Header.Get("PRIVATE-TOKEN")))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(Config{Fs: afero.NewMemMapFs()})
+	require.NoError(t, err)
+	c.(*client).sleep = func(time.Duration) {}
+
+	body, err := c.Get(server.URL, http.Header{"Private-Token": []string{"token"}})
+	require.NoError(t, err)
+	require.Equal(t, "token", string(body))
+	require.Equal(t, int32(2), requests.Load())
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := NewClient(Config{CAFile: "missing.pem"})
+	require.ErrorContains(t, err, "failed to read CA file")
+
+	_, err = NewClient(Config{Proxy: "://proxy"})
+	require.ErrorContains(t, err, "invalid proxy")
+}
+
+func TestOfflineClient(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	fs := afero.NewMemMapFs()
+	c, err := NewClient(Config{Fs: fs, Offline: true})
+	require.NoError(t, err)
+
+	require.ErrorIs(t, c.Download(server.URL, "file"), ErrOffline)
+	require.ErrorIs(t, c.DownloadWithHeader(server.URL, "file", nil), ErrOffline)
+	_, err = c.Get(server.URL, nil)
+	require.ErrorIs(t, err, ErrOffline)
+
+	require.Zero(t, requests.Load())
+	exists, err := afero.Exists(fs, "file")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
@@ -8,6 +8,7 @@
 package url
 
 import (
+	http "net/http"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -49,3 +50,32 @@ func (mr *MockClientMockRecorder) Download(path, url interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Download", reflect.TypeOf((*MockClient)(nil).Download), path, url)
 }
+
+// DownloadWithHeader mocks base method.
+func (m *MockClient) DownloadWithHeader(url, path string, header http.Header) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadWithHeader", url, path, header)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DownloadWithHeader indicates an expected call of DownloadWithHeader.
+func (mr *MockClientMockRecorder) DownloadWithHeader(url, path, header interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadWithHeader", reflect.TypeOf((*MockClient)(nil).DownloadWithHeader), url, path, header)
+}
+
+// Get mocks base method.
+func (m *MockClient) Get(url string, header http.Header) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", url, header)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockClientMockRecorder) Get(url, header interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockClient)(nil).Get), url, header)
+}
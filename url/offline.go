@@ -0,0 +1,33 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package url
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	_ Client = offlineClient{}
+
+	// ErrOffline is returned for every request made in offline mode.
+	ErrOffline = errors.New("network access is disabled in offline mode")
+)
+
+// offlineClient refuses every request, so anything that isn't available
+// locally fails instead of reaching out to the network.
+type offlineClient struct{}
+
+func (offlineClient) Download(url string, _ string) error {
+	return fmt.Errorf("can't download %s: %w", url, ErrOffline)
+}
+
+func (offlineClient) DownloadWithHeader(url string, _ string, _ http.Header) error {
+	return fmt.Errorf("can't download %s: %w", url, ErrOffline)
+}
+
+func (offlineClient) Get(url string, _ http.Header) ([]byte, error) {
+	return nil, fmt.Errorf("can't get %s: %w", url, ErrOffline)
+}
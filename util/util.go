@@ -15,6 +15,13 @@ func ParseQualifiedName(name string) (source string, plugin string) {
 	return parsed[0], parsed[1]
 }
 
+// ParseVersionedName splits a name like spacesvm@^1.2 into the name and its
+// version constraint. The constraint is empty if there is none.
+func ParseVersionedName(name string) (string, string) {
+	name, constraint, _ := strings.Cut(name, constant.VersionDelimiter)
+	return name, constraint
+}
+
 func ParseAlias(alias string) (organization string, repository string) {
 	parsed := strings.Split(alias, constant.AliasDelimiter)
 
@@ -0,0 +1,49 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/luxfi/lpm/types"
+)
+
+// resolveArtifact returns the definition of vm with its artifact for
+// platform, if it publishes any.
+func resolveArtifact(name string, vm types.VM, platform types.Platform) (types.VM, error) {
+	if len(vm.Artifacts) == 0 {
+		return vm, nil
+	}
+
+	artifact, ok := vm.Artifacts[platform.String()]
+	if !ok {
+		platforms := make([]string, 0, len(vm.Artifacts))
+		for p := range vm.Artifacts {
+			platforms = append(platforms, p)
+		}
+		sort.Strings(platforms)
+
+		return types.VM{}, fmt.Errorf(
+			"%s has no artifact for %s. Available platforms: %s",
+			name,
+			platform,
+			strings.Join(platforms, ", "),
+		)
+	}
+
+	resolved := vm
+	resolved.URL = artifact.URL
+	resolved.SHA256 = artifact.SHA256
+	resolved.Signature = artifact.Signature
+	resolved.Format = artifact.Format
+	if artifact.BinaryPath != "" {
+		resolved.BinaryPath = artifact.BinaryPath
+	}
+	// Artifacts are prebuilt, so there's nothing left to build.
+	resolved.InstallScript = ""
+
+	return resolved, nil
+}
@@ -0,0 +1,256 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+
+	"github.com/luxfi/lpm/bundle"
+	"github.com/luxfi/lpm/cache"
+	"github.com/luxfi/lpm/checksum"
+	"github.com/luxfi/lpm/signature"
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/types"
+	"github.com/luxfi/lpm/util"
+)
+
+var _ Workflow = &ExportBundle{}
+
+// BundleVM is a virtual machine whose artifact is exported into a bundle.
+type BundleVM struct {
+	// Name is the fully qualified name of the VM
+	Name string
+	// Constraint the exported version has to satisfy. Empty exports the
+	// latest version.
+	Constraint string
+}
+
+type ExportBundleConfig struct {
+	// Path the bundle is written to
+	Path string
+	VMs  []BundleVM
+	// Platform to export artifacts for. Defaults to the current platform.
+	Platform types.Platform
+
+	TmpPath          string
+	RepositoriesPath string
+	StateFile        state.File
+	RepoFactory      state.RepositoryFactory
+	Installer        Installer
+	Cache            *cache.Cache
+	Fs               afero.Fs
+}
+
+func NewExportBundle(config ExportBundleConfig) *ExportBundle {
+	if config.Platform == (types.Platform{}) {
+		config.Platform = types.CurrentPlatform()
+	}
+
+	return &ExportBundle{
+		path:             config.Path,
+		vms:              config.VMs,
+		platform:         config.Platform,
+		tmpPath:          config.TmpPath,
+		repositoriesPath: config.RepositoriesPath,
+		stateFile:        config.StateFile,
+		repoFactory:      config.RepoFactory,
+		installer:        config.Installer,
+		cache:            config.Cache,
+		fs:               config.Fs,
+		checksummer:      checksum.NewSHA256(config.Fs),
+	}
+}
+
+// ExportBundle packages every tracked repository and the artifacts of the
+// selected VMs into a bundle, which can be imported on a host without network
+// access.
+type ExportBundle struct {
+	path     string
+	vms      []BundleVM
+	platform types.Platform
+
+	tmpPath          string
+	repositoriesPath string
+	stateFile        state.File
+	repoFactory      state.RepositoryFactory
+	installer        Installer
+	cache            *cache.Cache
+	fs               afero.Fs
+	checksummer      checksum.Checksummer
+}
+
+func (e *ExportBundle) Execute() error {
+	if err := e.fs.MkdirAll(e.tmpPath, perms.ReadWriteExecute); err != nil {
+		return err
+	}
+	dir, err := afero.TempDir(e.fs, e.tmpPath, "bundle-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = e.fs.RemoveAll(dir)
+	}()
+
+	manifest := bundle.Manifest{
+		Version:   bundle.Version,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	aliases := make([]string, 0, len(e.stateFile.Sources))
+	for alias := range e.stateFile.Sources {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		source := e.stateFile.Sources[alias]
+		manifest.Repositories = append(manifest.Repositories, bundle.Repository{
+			Alias:  alias,
+			URL:    source.URL,
+			Branch: source.Branch,
+			Commit: source.Commit,
+		})
+	}
+
+	// Artifacts are collected in dir by their checksum before anything is
+	// written, so a failed download doesn't leave a partial bundle behind.
+	for _, vm := range e.vms {
+		artifact, err := e.fetch(vm, dir)
+		if err != nil {
+			return err
+		}
+		manifest.Artifacts = append(manifest.Artifacts, artifact)
+	}
+
+	fmt.Printf("Writing bundle to %s...\n", e.path)
+	w, err := bundle.Create(e.fs, e.path)
+	if err != nil {
+		return err
+	}
+	if err := e.write(w, manifest, dir); err != nil {
+		_ = w.Close()
+		_ = e.fs.Remove(e.path)
+		return err
+	}
+	if err := w.Close(); err != nil {
+		_ = e.fs.Remove(e.path)
+		return err
+	}
+
+	fmt.Printf("Exported %d repositories and %d artifacts to %s.\n", len(manifest.Repositories), len(manifest.Artifacts), e.path)
+	return nil
+}
+
+// fetch downloads the artifact of vm into dir, unless it's cached, and
+// verifies it against its definition.
+func (e *ExportBundle) fetch(vm BundleVM, dir string) (bundle.Artifact, error) {
+	repoAlias, plugin := util.ParseQualifiedName(vm.Name)
+	repository, err := e.repoFactory.GetRepository(repoAlias)
+	if err != nil {
+		return bundle.Artifact{}, err
+	}
+
+	definition, err := repository.GetVM(plugin)
+	if err != nil {
+		return bundle.Artifact{}, err
+	}
+
+	resolved, version, err := resolveVersion(vm.Name, definition.Definition, vm.Constraint)
+	if err != nil {
+		return bundle.Artifact{}, err
+	}
+	resolved, err = resolveArtifact(vm.Name, resolved, e.platform)
+	if err != nil {
+		return bundle.Artifact{}, err
+	}
+
+	// The definition's checksum isn't trusted as a path until the download
+	// matches it.
+	downloadPath := filepath.Join(dir, "download")
+	cached := fetchCached(e.cache, resolved.SHA256, downloadPath)
+	if !cached {
+		if err := e.installer.Download(resolved.URL, downloadPath); err != nil {
+			return bundle.Artifact{}, err
+		}
+	}
+
+	hash := fmt.Sprintf("%x", e.checksummer.Checksum(downloadPath))
+	if hash != resolved.SHA256 {
+		return bundle.Artifact{}, fmt.Errorf("checksums of %s did not match. Expected %s but saw %s", vm.Name, resolved.SHA256, hash)
+	}
+	if !cached {
+		addToCache(e.cache, downloadPath, resolved.URL)
+	}
+
+	artifactPath := filepath.Join(dir, hash)
+	if err := e.fs.Rename(downloadPath, artifactPath); err != nil {
+		return bundle.Artifact{}, err
+	}
+	info, err := e.fs.Stat(artifactPath)
+	if err != nil {
+		return bundle.Artifact{}, err
+	}
+
+	signed := resolved.Signature != ""
+	if signed {
+		// Signatures are verified when the artifact is installed, since the
+		// trusted keys may differ between hosts.
+		signaturePath := artifactPath + signature.Extension
+		if !fetchCachedSignature(e.cache, hash, signaturePath) {
+			if err := e.installer.Download(resolved.Signature, signaturePath); err != nil {
+				return bundle.Artifact{}, err
+			}
+		}
+	}
+
+	fmt.Printf("Added %s for %s (%s)\n", versionedName(vm.Name, version), e.platform, hash)
+	return bundle.Artifact{
+		VM:       vm.Name,
+		Version:  version,
+		Platform: e.platform.String(),
+		URL:      resolved.URL,
+		SHA256:   hash,
+		Size:     info.Size(),
+		Signed:   signed,
+	}, nil
+}
+
+func (e *ExportBundle) write(w *bundle.Writer, manifest bundle.Manifest, dir string) error {
+	if err := w.WriteManifest(manifest); err != nil {
+		return err
+	}
+
+	for _, repository := range manifest.Repositories {
+		organization, repo := util.ParseAlias(repository.Alias)
+		if err := w.AddDir(filepath.Join(e.repositoriesPath, organization, repo), bundle.RepositoryPath(repository.Alias)); err != nil {
+			return fmt.Errorf("failed to add repository %s: %w", repository.Alias, err)
+		}
+	}
+
+	// The same artifact may be used by more than one VM.
+	written := make(map[string]struct{}, len(manifest.Artifacts))
+	for _, artifact := range manifest.Artifacts {
+		if _, ok := written[artifact.SHA256]; ok {
+			continue
+		}
+		written[artifact.SHA256] = struct{}{}
+
+		artifactPath := filepath.Join(dir, artifact.SHA256)
+		if err := w.AddFile(artifactPath, bundle.ArtifactPath(artifact.SHA256)); err != nil {
+			return err
+		}
+		if artifact.Signed {
+			if err := w.AddFile(artifactPath+signature.Extension, bundle.SignaturePath(artifact.SHA256)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
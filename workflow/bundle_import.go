@@ -0,0 +1,203 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+
+	"github.com/luxfi/lpm/bundle"
+	"github.com/luxfi/lpm/cache"
+	"github.com/luxfi/lpm/checksum"
+	"github.com/luxfi/lpm/search"
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/util"
+)
+
+var (
+	_ Workflow = &ImportBundle{}
+
+	errNoCache = errors.New("importing a bundle requires the artifact cache")
+)
+
+type ImportBundleConfig struct {
+	// Path of the bundle to import
+	Path string
+
+	TmpPath          string
+	RepositoriesPath string
+	IndexPath        string
+	StateFile        state.File
+	Cache            *cache.Cache
+	Fs               afero.Fs
+}
+
+func NewImportBundle(config ImportBundleConfig) *ImportBundle {
+	return &ImportBundle{
+		path:             config.Path,
+		tmpPath:          config.TmpPath,
+		repositoriesPath: config.RepositoriesPath,
+		indexPath:        config.IndexPath,
+		stateFile:        config.StateFile,
+		cache:            config.Cache,
+		fs:               config.Fs,
+		checksummer:      checksum.NewSHA256(config.Fs),
+	}
+}
+
+// ImportBundle loads the artifacts in a bundle into the cache and replaces
+// the tracked repositories with the ones in the bundle.
+type ImportBundle struct {
+	transactional
+
+	path             string
+	tmpPath          string
+	repositoriesPath string
+	indexPath        string
+	stateFile        state.File
+	cache            *cache.Cache
+	fs               afero.Fs
+	checksummer      checksum.Checksummer
+}
+
+func (i *ImportBundle) Execute() error {
+	if i.cache == nil {
+		return errNoCache
+	}
+
+	if err := i.fs.MkdirAll(i.tmpPath, perms.ReadWriteExecute); err != nil {
+		return err
+	}
+	dir, err := afero.TempDir(i.fs, i.tmpPath, "bundle-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = i.fs.RemoveAll(dir)
+	}()
+
+	fmt.Printf("Extracting bundle %s...\n", i.path)
+	manifest, err := bundle.Open(i.fs, i.path, dir)
+	if err != nil {
+		return err
+	}
+
+	// Check everything in the bundle before changing anything.
+	for _, artifact := range manifest.Artifacts {
+		path := filepath.Join(dir, filepath.FromSlash(bundle.ArtifactPath(artifact.SHA256)))
+		if hash := fmt.Sprintf("%x", i.checksummer.Checksum(path)); hash != artifact.SHA256 {
+			return fmt.Errorf("artifact %s in the bundle is missing or corrupt. Expected checksum %s but saw %s", artifact.VM, artifact.SHA256, hash)
+		}
+		if artifact.Signed {
+			if _, err := i.fs.Stat(filepath.Join(dir, filepath.FromSlash(bundle.SignaturePath(artifact.SHA256)))); err != nil {
+				return fmt.Errorf("signature of %s is missing from the bundle: %w", artifact.VM, err)
+			}
+		}
+	}
+	for _, repository := range manifest.Repositories {
+		info, err := i.fs.Stat(filepath.Join(dir, filepath.FromSlash(bundle.RepositoryPath(repository.Alias))))
+		if err != nil || !info.IsDir() {
+			return fmt.Errorf("repository %s is missing from the bundle", repository.Alias)
+		}
+	}
+
+	// The cache is content addressed, so artifacts are left in it even if the
+	// import fails.
+	for _, artifact := range manifest.Artifacts {
+		path := filepath.Join(dir, filepath.FromSlash(bundle.ArtifactPath(artifact.SHA256)))
+		if _, err := i.cache.Add(path, artifact.URL); err != nil {
+			return fmt.Errorf("failed to cache %s: %w", artifact.VM, err)
+		}
+		if artifact.Signed {
+			if err := i.cache.AddSignature(artifact.SHA256, filepath.Join(dir, filepath.FromSlash(bundle.SignaturePath(artifact.SHA256)))); err != nil {
+				return fmt.Errorf("failed to cache the signature of %s: %w", artifact.VM, err)
+			}
+		}
+		fmt.Printf("Cached %s for %s (%s)\n", versionedName(artifact.VM, artifact.Version), artifact.Platform, artifact.SHA256)
+	}
+
+	// Replaced repositories are kept outside of the repositories directory
+	// until the import succeeds, so they aren't indexed.
+	backupDir, err := afero.TempDir(i.fs, i.tmpPath, "repositories-")
+	if err != nil {
+		return err
+	}
+	tx := i.transaction()
+	tx.OnRollback(func() error {
+		return i.fs.RemoveAll(backupDir)
+	})
+
+	for _, repository := range manifest.Repositories {
+		if err := i.importRepository(repository, dir, backupDir); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Track(i.indexPath); err != nil {
+		return err
+	}
+	index, err := search.Build(i.fs, i.repositoriesPath)
+	if err != nil {
+		return fmt.Errorf("failed to build search index: %w", err)
+	}
+	if err := index.Save(i.fs, i.indexPath); err != nil {
+		return err
+	}
+
+	_ = i.fs.RemoveAll(backupDir)
+
+	fmt.Printf("Imported %d repositories and %d artifacts from %s.\n", len(manifest.Repositories), len(manifest.Artifacts), i.path)
+	return nil
+}
+
+// importRepository replaces the repository with the one extracted into dir.
+// The previous repository is moved into backupDir and restored on rollback.
+func (i *ImportBundle) importRepository(repository bundle.Repository, dir string, backupDir string) error {
+	organization, repo := util.ParseAlias(repository.Alias)
+	src := filepath.Join(dir, filepath.FromSlash(bundle.RepositoryPath(repository.Alias)))
+	dest := filepath.Join(i.repositoriesPath, organization, repo)
+	tx := i.transaction()
+
+	if err := i.fs.MkdirAll(filepath.Dir(dest), perms.ReadWriteExecute); err != nil {
+		return err
+	}
+
+	if exists, err := afero.DirExists(i.fs, dest); err != nil {
+		return err
+	} else if exists {
+		backup := filepath.Join(backupDir, organization, repo)
+		if err := i.fs.MkdirAll(filepath.Dir(backup), perms.ReadWriteExecute); err != nil {
+			return err
+		}
+		if err := i.fs.Rename(dest, backup); err != nil {
+			return fmt.Errorf("failed to replace repository %s: %w", repository.Alias, err)
+		}
+		tx.OnRollback(func() error {
+			if err := i.fs.RemoveAll(dest); err != nil {
+				return err
+			}
+			return i.fs.Rename(backup, dest)
+		})
+	} else {
+		tx.OnRollback(func() error {
+			return i.fs.RemoveAll(dest)
+		})
+	}
+
+	if err := i.fs.Rename(src, dest); err != nil {
+		return fmt.Errorf("failed to import repository %s: %w", repository.Alias, err)
+	}
+
+	i.stateFile.Sources[repository.Alias] = &state.SourceInfo{
+		URL:    repository.URL,
+		Commit: repository.Commit,
+		Branch: repository.Branch,
+	}
+	fmt.Printf("Imported definitions for %s@%s.\n", repository.Alias, repository.Commit)
+	return nil
+}
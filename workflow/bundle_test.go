@@ -0,0 +1,163 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/golang/mock/gomock"
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/lpm/cache"
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/types"
+)
+
+func TestBundleExportImport(t *testing.T) {
+	const (
+		alias = "organization/repository"
+		name  = "organization/repository:vm"
+	)
+
+	archive := []byte("archive")
+	hash := fmt.Sprintf("%x", sha256.Sum256(archive))
+	platform := types.Platform{OS: "linux", Arch: "amd64"}
+	definition := state.Definition[types.VM]{
+		Definition: types.VM{
+			ID:    "id",
+			Alias: "vm",
+			Versions: []types.Version{
+				{Version: "1.0.0", URL: "www.website.com/1.0.0", SHA256: hash, Signature: "www.website.com/1.0.0.minisig"},
+				{Version: "2.0.0", URL: "www.website.com/2.0.0", SHA256: "666f6f626172"},
+			},
+		},
+	}
+	source := &state.SourceInfo{
+		URL:    "https://github.com/organization/repository",
+		Commit: "commit",
+		Branch: plumbing.NewBranchReferenceName("main"),
+	}
+
+	ctrl := gomock.NewController(t)
+	fs := afero.NewMemMapFs()
+	repositoryPath := filepath.Join("exporter", "repositories", "organization", "repository")
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(repositoryPath, "vms", "vm.yaml"), []byte("id: id\nalias: vm\n"), perms.ReadWrite))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(repositoryPath, ".git", "HEAD"), []byte("ref: refs/heads/main"), perms.ReadWrite))
+
+	exporter, err := state.New("exporter")
+	require.NoError(t, err)
+	exporter.Sources[alias] = source
+
+	repository := state.NewMockRepository(ctrl)
+	repository.EXPECT().GetVM("vm").Return(definition, nil).Times(2)
+	repoFactory := state.NewMockRepositoryFactory(ctrl)
+	repoFactory.EXPECT().GetRepository(alias).Return(repository, nil).Times(2)
+
+	// The artifact is only downloaded once, the second export uses the cache.
+	installer := NewMockInstaller(ctrl)
+	installer.EXPECT().Download("www.website.com/1.0.0", gomock.Any()).DoAndReturn(func(_ string, path string) error {
+		return afero.WriteFile(fs, path, archive, perms.ReadWrite)
+	})
+	installer.EXPECT().Download("www.website.com/1.0.0.minisig", gomock.Any()).DoAndReturn(func(_ string, path string) error {
+		return afero.WriteFile(fs, path, []byte("signature"), perms.ReadWrite)
+	}).Times(2)
+
+	exporterCache := cache.New(cache.Config{Fs: fs, Dir: filepath.Join("exporter", "cache")})
+	for range 2 {
+		require.NoError(t, NewExportBundle(ExportBundleConfig{
+			Path:             "bundle.tar.gz",
+			VMs:              []BundleVM{{Name: name, Constraint: "^1"}},
+			Platform:         platform,
+			TmpPath:          filepath.Join("exporter", "tmp"),
+			RepositoriesPath: filepath.Join("exporter", "repositories"),
+			StateFile:        exporter,
+			RepoFactory:      repoFactory,
+			Installer:        installer,
+			Cache:            exporterCache,
+			Fs:               fs,
+		}).Execute())
+	}
+
+	importer, err := state.New("importer")
+	require.NoError(t, err)
+	importerCache := cache.New(cache.Config{Fs: fs, Dir: filepath.Join("importer", "cache")})
+
+	// Importing over an existing repository replaces it.
+	importedPath := filepath.Join("importer", "repositories", "organization", "repository")
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(importedPath, "vms", "old.yaml"), []byte("id: old\n"), perms.ReadWrite))
+
+	require.NoError(t, NewImportBundle(ImportBundleConfig{
+		Path:             "bundle.tar.gz",
+		TmpPath:          filepath.Join("importer", "tmp"),
+		RepositoriesPath: filepath.Join("importer", "repositories"),
+		IndexPath:        filepath.Join("importer", "search-index"),
+		StateFile:        importer,
+		Cache:            importerCache,
+		Fs:               fs,
+	}).Execute())
+
+	require.Equal(t, source, importer.Sources[alias])
+
+	b, err := afero.ReadFile(fs, filepath.Join(importedPath, "vms", "vm.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, "id: id\nalias: vm\n", string(b))
+	exists, err := afero.Exists(fs, filepath.Join(importedPath, "vms", "old.yaml"))
+	require.NoError(t, err)
+	require.False(t, exists)
+	exists, err = afero.Exists(fs, filepath.Join("importer", "search-index"))
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	ok, err := importerCache.Fetch(hash, "fetched")
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = importerCache.FetchSignature(hash, "fetched.minisig")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Nothing is left behind in the scratch space.
+	entries, err := afero.ReadDir(fs, filepath.Join("importer", "tmp"))
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestExportBundleChecksumMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	fs := afero.NewMemMapFs()
+
+	stateFile, err := state.New("stateFile")
+	require.NoError(t, err)
+
+	repository := state.NewMockRepository(ctrl)
+	repository.EXPECT().GetVM("vm").Return(state.Definition[types.VM]{
+		Definition: types.VM{ID: "id", URL: "www.website.com", SHA256: "666f6f626172"},
+	}, nil)
+	repoFactory := state.NewMockRepositoryFactory(ctrl)
+	repoFactory.EXPECT().GetRepository("organization/repository").Return(repository, nil)
+	installer := NewMockInstaller(ctrl)
+	installer.EXPECT().Download("www.website.com", gomock.Any()).DoAndReturn(func(_ string, path string) error {
+		return afero.WriteFile(fs, path, []byte("tampered"), perms.ReadWrite)
+	})
+
+	err = NewExportBundle(ExportBundleConfig{
+		Path:        "bundle.tar.gz",
+		VMs:         []BundleVM{{Name: "organization/repository:vm"}},
+		TmpPath:     "tmp",
+		StateFile:   stateFile,
+		RepoFactory: repoFactory,
+		Installer:   installer,
+		Fs:          fs,
+	}).Execute()
+	require.ErrorContains(t, err, "checksums of organization/repository:vm did not match")
+
+	exists, err := afero.Exists(fs, "bundle.tar.gz")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
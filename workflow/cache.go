@@ -0,0 +1,68 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"fmt"
+
+	"github.com/luxfi/lpm/cache"
+)
+
+// fetchCached copies the artifact with checksum sha256 from c to dest and
+// returns whether it was cached. A broken cache never fails an install, the
+// artifact is just downloaded again.
+func fetchCached(c *cache.Cache, sha256 string, dest string) bool {
+	if c == nil || sha256 == "" {
+		return false
+	}
+
+	ok, err := c.Fetch(sha256, dest)
+	if err != nil {
+		fmt.Printf("Warning - failed to read %s from the cache: %s\n", sha256, err)
+		return false
+	}
+	if ok {
+		fmt.Printf("Using cached artifact %s\n", sha256)
+	}
+	return ok
+}
+
+// addToCache adds the artifact downloaded from name to path to c, so it isn't
+// downloaded again.
+func addToCache(c *cache.Cache, path string, name string) {
+	if c == nil {
+		return
+	}
+
+	if _, err := c.Add(path, name); err != nil {
+		fmt.Printf("Warning - failed to cache %s: %s\n", name, err)
+	}
+}
+
+// fetchCachedSignature copies the signature of the artifact with checksum
+// sha256 from c to dest and returns whether it was cached.
+func fetchCachedSignature(c *cache.Cache, sha256 string, dest string) bool {
+	if c == nil || sha256 == "" {
+		return false
+	}
+
+	ok, err := c.FetchSignature(sha256, dest)
+	if err != nil {
+		fmt.Printf("Warning - failed to read the signature of %s from the cache: %s\n", sha256, err)
+		return false
+	}
+	return ok
+}
+
+// addSignatureToCache adds the signature at path of the cached artifact with
+// checksum sha256 to c.
+func addSignatureToCache(c *cache.Cache, sha256 string, path string) {
+	if c == nil {
+		return
+	}
+
+	if err := c.AddSignature(sha256, path); err != nil {
+		fmt.Printf("Warning - failed to cache the signature of %s: %s\n", sha256, err)
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/luxfi/lpm/node"
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/types"
+)
+
+var _ Workflow = &ApplyChainConfig{}
+
+// ApplyChainConfigConfig configures writing a chain's configs to the node.
+type ApplyChainConfigConfig struct {
+	// Name is the fully qualified name of the chain's definition
+	Name       string
+	Network    string
+	ChainID    string
+	Definition state.Definition[types.Chain]
+	// VMs the chain needs, by their fully qualified names. The chain is
+	// recorded as a reference to each of them. Nil keeps the VMs recorded
+	// when the chain was joined.
+	VMs []string
+	// NodeConfig is the node config the chain config directory is read
+	// from. Configs aren't written without one.
+	NodeConfig *node.ConfigFile
+	StateFile  state.File
+	// Plan records what the workflow would do instead of doing it
+	Plan *Plan
+}
+
+// NewApplyChainConfig creates a new workflow that writes a chain's configs to
+// the node.
+func NewApplyChainConfig(config ApplyChainConfigConfig) *ApplyChainConfig {
+	return &ApplyChainConfig{
+		name:       config.Name,
+		network:    config.Network,
+		chainID:    config.ChainID,
+		definition: config.Definition,
+		vms:        config.VMs,
+		nodeConfig: config.NodeConfig,
+		stateFile:  config.StateFile,
+		plan:       config.Plan,
+	}
+}
+
+// ApplyChainConfig writes the genesis, config and upgrade files a chain's
+// definition has for a network to the node's chain config directory, and
+// records the chain in the chain registry and as a reference to its VMs.
+type ApplyChainConfig struct {
+	transactional
+
+	name       string
+	network    string
+	chainID    string
+	definition state.Definition[types.Chain]
+	vms        []string
+	nodeConfig *node.ConfigFile
+	stateFile  state.File
+	plan       *Plan
+}
+
+// chainFile is a file of a chain config and its name in the chain's
+// directory.
+type chainFile struct {
+	name string
+	file *types.ChainFile
+}
+
+func (a *ApplyChainConfig) Execute() error {
+	config, _ := a.definition.Definition.GetConfig(a.network)
+	files := make([]chainFile, 0, 3)
+	for _, f := range []chainFile{
+		{name: node.ChainGenesisFile, file: config.Genesis},
+		{name: node.ChainConfigFile, file: config.Config},
+		{name: node.ChainUpgradeFile, file: config.Upgrade},
+	} {
+		if f.file != nil {
+			files = append(files, f)
+		}
+	}
+
+	// Nothing is written unless every file matches its checksum.
+	for _, f := range files {
+		if f.file.SHA256 == "" {
+			return fmt.Errorf("%s of chain %s has no sha256", f.name, a.name)
+		}
+		hash := fmt.Sprintf("%x", sha256.Sum256([]byte(f.file.Data)))
+		if !strings.EqualFold(hash, f.file.SHA256) {
+			return fmt.Errorf("checksum of %s of chain %s did not match. Expected %s but saw %s", f.name, a.name, f.file.SHA256, hash)
+		}
+	}
+
+	previous := a.stateFile.ChainRegistry[a.name]
+	info := &state.ChainInfo{
+		ID:       a.chainID,
+		Network:  a.network,
+		Commit:   a.definition.Commit,
+		Configs:  make(map[string]string, len(files)),
+		JoinedAt: time.Now().UTC(),
+	}
+	if previous != nil {
+		info.JoinedAt = previous.JoinedAt
+	}
+	info.VMs = a.vms
+	if info.VMs == nil && previous != nil {
+		info.VMs = previous.VMs
+	}
+
+	if len(files) > 0 && a.nodeConfig == nil {
+		fmt.Printf("Warning - chain %s has configs that weren't written. Pass --node-config to write them to the node's chain config directory.\n", a.name)
+		files = nil
+	}
+	// Without the node config, the files written before can't be found, so
+	// they're still recorded.
+	if a.nodeConfig == nil && previous != nil && previous.ID == a.chainID {
+		info.Configs = previous.Configs
+	}
+
+	stale := make(map[string]bool)
+	if previous != nil && previous.ID == a.chainID && a.nodeConfig != nil {
+		for name := range previous.Configs {
+			stale[name] = true
+		}
+	}
+
+	tx := a.transaction()
+	for _, f := range files {
+		delete(stale, f.name)
+		info.Configs[f.name] = strings.ToLower(f.file.SHA256)
+
+		path, err := a.nodeConfig.ChainFilePath(a.chainID, f.name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", a.nodeConfig.Path(), err)
+		}
+		if a.plan != nil {
+			a.plan.Write(path, "sha256 "+f.file.SHA256)
+			continue
+		}
+
+		if err := tx.Track(path); err != nil {
+			return err
+		}
+		written, err := a.nodeConfig.WriteChainFile(a.chainID, f.name, []byte(f.file.Data))
+		if err != nil {
+			return fmt.Errorf("failed to write %s of chain %s: %w", f.name, a.name, err)
+		}
+		if written {
+			fmt.Printf("Wrote %s of chain %s to %s.\n", f.name, a.name, path)
+		}
+	}
+
+	// Files the definition no longer has are removed, so the node doesn't
+	// keep using them.
+	for _, name := range slices.Sorted(maps.Keys(stale)) {
+		path, err := a.nodeConfig.ChainFilePath(a.chainID, name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", a.nodeConfig.Path(), err)
+		}
+		if a.plan != nil {
+			a.plan.Remove(path, "no longer in the definition")
+			continue
+		}
+
+		if err := tx.Track(path); err != nil {
+			return err
+		}
+		if _, err := a.nodeConfig.RemoveChainFile(a.chainID, name); err != nil {
+			return fmt.Errorf("failed to remove %s of chain %s: %w", name, a.name, err)
+		}
+		fmt.Printf("Removed %s of chain %s, which is no longer in its definition.\n", name, a.name)
+	}
+
+	if a.plan != nil {
+		a.plan.Register(a.name, a.definition.Commit)
+		return nil
+	}
+
+	for _, vm := range info.VMs {
+		installed, ok := a.stateFile.InstallationRegistry[vm]
+		if ok && !slices.Contains(installed.Chains, a.name) {
+			installed.Chains = append(installed.Chains, a.name)
+		}
+	}
+	// The chain no longer needs VMs that were dropped from its definition.
+	if previous != nil {
+		for _, vm := range previous.VMs {
+			if !slices.Contains(info.VMs, vm) {
+				releaseVM(a.stateFile, vm, a.name)
+			}
+		}
+	}
+
+	a.stateFile.ChainRegistry[a.name] = info
+	return nil
+}
+
+// releaseVM removes chain from the chains that need vm. It returns the
+// chains that still do.
+func releaseVM(stateFile state.File, vm string, chain string) []string {
+	installed, ok := stateFile.InstallationRegistry[vm]
+	if !ok {
+		return nil
+	}
+
+	installed.Chains = slices.DeleteFunc(installed.Chains, func(other string) bool {
+		return other == chain
+	})
+	return installed.Chains
+}
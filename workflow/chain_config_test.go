@@ -0,0 +1,254 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/lpm/node"
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/types"
+)
+
+func chainFileOf(data string) *types.ChainFile {
+	return &types.ChainFile{
+		Data:   data,
+		SHA256: fmt.Sprintf("%x", sha256.Sum256([]byte(data))),
+	}
+}
+
+func TestApplyChainConfigExecute(t *testing.T) {
+	const (
+		name    = "organization/repository:chain"
+		chainID = "chainID"
+	)
+	chainDir := filepath.Join("node", "chains", chainID)
+
+	tests := []struct {
+		name       string
+		config     types.ChainConfig
+		noNode     bool
+		previous   *state.ChainInfo
+		existing   map[string]string
+		wantFiles  map[string]string
+		wantConfig map[string]string
+		wantErr    string
+	}{
+		{
+			name: "writes files",
+			config: types.ChainConfig{
+				Genesis: chainFileOf(`{"genesis": true}`),
+				Config:  chainFileOf(`{"config": true}`),
+			},
+			wantFiles: map[string]string{
+				node.ChainGenesisFile: `{"genesis": true}`,
+				node.ChainConfigFile:  `{"config": true}`,
+			},
+			wantConfig: map[string]string{
+				node.ChainGenesisFile: chainFileOf(`{"genesis": true}`).SHA256,
+				node.ChainConfigFile:  chainFileOf(`{"config": true}`).SHA256,
+			},
+		},
+		{
+			name: "checksum mismatch writes nothing",
+			config: types.ChainConfig{
+				Genesis: chainFileOf(`{"genesis": true}`),
+				Upgrade: &types.ChainFile{Data: `{"upgrade": true}`, SHA256: "bad"},
+			},
+			wantErr: "checksum of upgrade.json",
+		},
+		{
+			name: "missing checksum",
+			config: types.ChainConfig{
+				Config: &types.ChainFile{Data: `{}`},
+			},
+			wantErr: "has no sha256",
+		},
+		{
+			name: "removes files no longer in the definition",
+			config: types.ChainConfig{
+				Config: chainFileOf(`{"config": 2}`),
+			},
+			previous: &state.ChainInfo{
+				ID:     chainID,
+				Commit: "old",
+				Configs: map[string]string{
+					node.ChainConfigFile:  chainFileOf(`{"config": 1}`).SHA256,
+					node.ChainUpgradeFile: chainFileOf(`{"upgrade": 1}`).SHA256,
+				},
+			},
+			existing: map[string]string{
+				node.ChainConfigFile:  `{"config": 1}`,
+				node.ChainUpgradeFile: `{"upgrade": 1}`,
+			},
+			wantFiles: map[string]string{
+				node.ChainConfigFile:           `{"config": 2}`,
+				node.ChainConfigFile + ".bak":  `{"config": 1}`,
+				node.ChainUpgradeFile + ".bak": `{"upgrade": 1}`,
+			},
+			wantConfig: map[string]string{
+				node.ChainConfigFile: chainFileOf(`{"config": 2}`).SHA256,
+			},
+		},
+		{
+			name: "no node config",
+			config: types.ChainConfig{
+				Config: chainFileOf(`{"config": true}`),
+			},
+			noNode:     true,
+			wantConfig: map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for file, data := range test.existing {
+				require.NoError(t, afero.WriteFile(fs, filepath.Join(chainDir, file), []byte(data), 0o600))
+			}
+
+			stateFile, err := state.New(t.TempDir())
+			require.NoError(t, err)
+			if test.previous != nil {
+				stateFile.ChainRegistry[name] = test.previous
+			}
+
+			var nodeConfig *node.ConfigFile
+			if !test.noNode {
+				nodeConfig = node.NewConfigFile(fs, filepath.Join("node", "node.json"))
+			}
+
+			wf := NewApplyChainConfig(ApplyChainConfigConfig{
+				Name:    name,
+				Network: "network",
+				ChainID: chainID,
+				Definition: state.Definition[types.Chain]{
+					Definition: types.Chain{
+						ID:      map[string]string{"network": chainID},
+						Configs: map[string]types.ChainConfig{"network": test.config},
+					},
+					Commit: "commit",
+				},
+				NodeConfig: nodeConfig,
+				StateFile:  stateFile,
+			})
+			err = wf.Execute()
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				exists, err := afero.DirExists(fs, chainDir)
+				require.NoError(t, err)
+				require.False(t, exists)
+				require.Empty(t, stateFile.ChainRegistry)
+				return
+			}
+			require.NoError(t, err)
+
+			files := make(map[string]string)
+			if exists, _ := afero.DirExists(fs, chainDir); exists {
+				entries, err := afero.ReadDir(fs, chainDir)
+				require.NoError(t, err)
+				for _, entry := range entries {
+					b, err := afero.ReadFile(fs, filepath.Join(chainDir, entry.Name()))
+					require.NoError(t, err)
+					files[entry.Name()] = string(b)
+				}
+			}
+			if test.wantFiles == nil {
+				test.wantFiles = map[string]string{}
+			}
+			require.Equal(t, test.wantFiles, files)
+
+			info := stateFile.ChainRegistry[name]
+			require.Equal(t, chainID, info.ID)
+			require.Equal(t, "network", info.Network)
+			require.Equal(t, "commit", info.Commit)
+			require.Equal(t, test.wantConfig, info.Configs)
+		})
+	}
+}
+
+func TestApplyChainConfigPlan(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	stateFile, err := state.New(t.TempDir())
+	require.NoError(t, err)
+
+	config := chainFileOf(`{"config": true}`)
+	plan := &Plan{}
+	wf := NewApplyChainConfig(ApplyChainConfigConfig{
+		Name:    "organization/repository:chain",
+		Network: "network",
+		ChainID: "chainID",
+		Definition: state.Definition[types.Chain]{
+			Definition: types.Chain{
+				Configs: map[string]types.ChainConfig{"network": {Config: config}},
+			},
+			Commit: "commit",
+		},
+		NodeConfig: node.NewConfigFile(fs, filepath.Join("node", "node.json")),
+		StateFile:  stateFile,
+		Plan:       plan,
+	})
+	require.NoError(t, wf.Execute())
+
+	require.Equal(t, []Step{
+		{Action: WriteAction, Target: filepath.Join("node", "chains", "chainID", node.ChainConfigFile), Detail: "sha256 " + config.SHA256},
+		{Action: RegisterAction, Target: "organization/repository:chain", Detail: "commit"},
+	}, plan.Steps())
+
+	// Nothing was changed.
+	require.Empty(t, stateFile.ChainRegistry)
+	entries, err := afero.ReadDir(fs, "/")
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestApplyChainConfigReferences(t *testing.T) {
+	const (
+		chain = "organization/repository:chain"
+		other = "organization/repository:other"
+	)
+
+	stateFile, err := state.New(t.TempDir())
+	require.NoError(t, err)
+	stateFile.ChainRegistry[chain] = &state.ChainInfo{
+		ID:  "chainID",
+		VMs: []string{"kept", "dropped"},
+	}
+	stateFile.InstallationRegistry["kept"] = &state.InstallInfo{Chains: []string{chain}}
+	stateFile.InstallationRegistry["dropped"] = &state.InstallInfo{Chains: []string{chain, other}}
+	stateFile.InstallationRegistry["added"] = &state.InstallInfo{}
+
+	wf := NewApplyChainConfig(ApplyChainConfigConfig{
+		Name:    chain,
+		Network: "network",
+		ChainID: "chainID",
+		Definition: state.Definition[types.Chain]{
+			Commit: "commit",
+		},
+		VMs:       []string{"kept", "added"},
+		StateFile: stateFile,
+	})
+	require.NoError(t, wf.Execute())
+
+	require.Equal(t, []string{"kept", "added"}, stateFile.ChainRegistry[chain].VMs)
+	require.Equal(t, []string{chain}, stateFile.InstallationRegistry["kept"].Chains)
+	require.Equal(t, []string{other}, stateFile.InstallationRegistry["dropped"].Chains)
+	require.Equal(t, []string{chain}, stateFile.InstallationRegistry["added"].Chains)
+
+	// Re-applying without VMs keeps the ones recorded.
+	wf = NewApplyChainConfig(ApplyChainConfigConfig{
+		Name:      chain,
+		Network:   "network",
+		ChainID:   "chainID",
+		StateFile: stateFile,
+	})
+	require.NoError(t, wf.Execute())
+	require.Equal(t, []string{"kept", "added"}, stateFile.ChainRegistry[chain].VMs)
+}
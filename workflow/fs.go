@@ -0,0 +1,128 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"path/filepath"
+	"syscall"
+
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+)
+
+// removeIfExists removes the file at path, treating a missing file as success.
+func removeIfExists(fs afero.Fs, path string) error {
+	if err := fs.Remove(path); err != nil && !errors.Is(err, iofs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// installBinary copies the executable at src into the plugin directory at
+// dest. Whatever was previously at dest is restored if the workflow fails.
+func installBinary(tx Transaction, fs afero.Fs, src string, dest string) error {
+	if err := prepareBinary(tx, fs, dest); err != nil {
+		return err
+	}
+
+	if err := replaceBinary(fs, src, dest); err != nil {
+		return fmt.Errorf("failed to install binary: %w", err)
+	}
+
+	return nil
+}
+
+// moveBinary moves the executable at src into the plugin directory at dest.
+// It's renamed into place if src is on the same device as the plugin
+// directory and copied otherwise. Whatever was previously at dest is restored
+// if the workflow fails.
+func moveBinary(tx Transaction, fs afero.Fs, src string, dest string) error {
+	if err := prepareBinary(tx, fs, dest); err != nil {
+		return err
+	}
+
+	if err := syncBinary(fs, src); err != nil {
+		return fmt.Errorf("failed to install binary: %w", err)
+	}
+
+	err := fs.Rename(src, dest)
+	if errors.Is(err, syscall.EXDEV) {
+		if err := replaceBinary(fs, src, dest); err != nil {
+			return fmt.Errorf("failed to install binary: %w", err)
+		}
+		return removeIfExists(fs, src)
+	}
+
+	return err
+}
+
+// prepareBinary creates the plugin directory dest is installed in and tracks
+// dest so that it's restored on rollback.
+func prepareBinary(tx Transaction, fs afero.Fs, dest string) error {
+	if err := fs.MkdirAll(filepath.Dir(dest), perms.ReadWriteExecute); err != nil {
+		return fmt.Errorf("failed to create plugin directory: %w", err)
+	}
+
+	return tx.Track(dest)
+}
+
+// syncBinary makes the executable at path executable and flushes it to disk,
+// so that it can be renamed into place.
+func syncBinary(fs afero.Fs, path string) error {
+	if err := fs.Chmod(path, perms.ReadWriteExecute); err != nil {
+		return fmt.Errorf("failed to set permissions: %w", err)
+	}
+
+	f, err := fs.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return f.Sync()
+}
+
+// replaceBinary copies the executable at src to a temporary file next to dest
+// and renames it over dest, so that a running node never sees a partially
+// written binary.
+func replaceBinary(fs afero.Fs, src string, dest string) (err error) {
+	in, err := fs.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	// Hidden so it's never mistaken for a plugin.
+	out, err := afero.TempFile(fs, filepath.Dir(dest), "."+filepath.Base(dest)+"-")
+	if err != nil {
+		return err
+	}
+	tmpPath := out.Name()
+	defer func() {
+		_ = out.Close()
+		if err != nil {
+			_ = removeIfExists(fs, tmpPath)
+		}
+	}()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := fs.Chmod(tmpPath, perms.ReadWriteExecute); err != nil {
+		return fmt.Errorf("failed to set permissions: %w", err)
+	}
+
+	return fs.Rename(tmpPath, dest)
+}
@@ -0,0 +1,112 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// crossDeviceFs fails to rename files out of the tmp directory, like a tmp
+// directory on a different device than the plugin directory.
+type crossDeviceFs struct {
+	afero.Fs
+}
+
+func (c crossDeviceFs) Rename(oldname, newname string) error {
+	if strings.HasPrefix(oldname, "tmp") {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EXDEV}
+	}
+
+	return c.Fs.Rename(oldname, newname)
+}
+
+func TestMoveBinary(t *testing.T) {
+	tests := []struct {
+		name      string
+		fs        func(afero.Fs) afero.Fs
+		installed bool
+	}{
+		{
+			name: "same device",
+			fs:   func(fs afero.Fs) afero.Fs { return fs },
+		},
+		{
+			name: "different device",
+			fs:   func(fs afero.Fs) afero.Fs { return crossDeviceFs{Fs: fs} },
+		},
+		{
+			name:      "replaces installed binary",
+			fs:        func(fs afero.Fs) afero.Fs { return crossDeviceFs{Fs: fs} },
+			installed: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := test.fs(afero.NewMemMapFs())
+			src := filepath.Join("tmp", "binary")
+			dest := filepath.Join("plugins", "id")
+			require.NoError(t, afero.WriteFile(fs, src, []byte("new"), perms.ReadWrite))
+			if test.installed {
+				require.NoError(t, afero.WriteFile(fs, dest, []byte("old"), perms.ReadWriteExecute))
+			}
+
+			require.NoError(t, moveBinary(nopTransaction{}, fs, src, dest))
+
+			b, err := afero.ReadFile(fs, dest)
+			require.NoError(t, err)
+			require.Equal(t, "new", string(b))
+
+			info, err := fs.Stat(dest)
+			require.NoError(t, err)
+			require.Equal(t, os.FileMode(perms.ReadWriteExecute), info.Mode().Perm())
+
+			exists, err := afero.Exists(fs, src)
+			require.NoError(t, err)
+			require.False(t, exists)
+
+			// Nothing is left behind next to the binary.
+			entries, err := afero.ReadDir(fs, "plugins")
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+		})
+	}
+}
+
+func TestInstallBinary(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	src := filepath.Join("tmp", "binary")
+	dest := filepath.Join("plugins", "id")
+	require.NoError(t, afero.WriteFile(fs, src, []byte("new"), perms.ReadWrite))
+	require.NoError(t, afero.WriteFile(fs, dest, []byte("old"), perms.ReadWriteExecute))
+
+	require.NoError(t, installBinary(nopTransaction{}, fs, src, dest))
+
+	b, err := afero.ReadFile(fs, dest)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(b))
+
+	// The source is copied, not moved.
+	exists, err := afero.Exists(fs, src)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	entries, err := afero.ReadDir(fs, "plugins")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	// A missing source leaves the installed binary alone.
+	require.Error(t, installBinary(nopTransaction{}, fs, "missing", dest))
+	b, err = afero.ReadFile(fs, dest)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(b))
+}
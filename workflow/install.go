@@ -13,8 +13,13 @@ import (
 	"github.com/luxfi/filesystem/perms"
 	"github.com/spf13/afero"
 
+	"github.com/luxfi/lpm/archive"
+	"github.com/luxfi/lpm/cache"
 	"github.com/luxfi/lpm/checksum"
+	"github.com/luxfi/lpm/constant"
+	"github.com/luxfi/lpm/signature"
 	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/types"
 )
 
 var _ Workflow = &Install{}
@@ -26,41 +31,81 @@ type InstallConfig struct {
 	Repo         string
 	TmpPath      string
 	PluginPath   string
+	// Constraint the installed version has to satisfy, for definitions
+	// that publish versions. Empty installs the latest version.
+	Constraint string
+	// Platform to install artifacts for. Defaults to the current platform.
+	Platform types.Platform
+	// AllowUnsigned installs artifacts that aren't signed. Artifacts with an
+	// invalid signature are never installed.
+	AllowUnsigned bool
+	// KeepVersions is how many replaced binaries are kept to roll back to
+	KeepVersions int
+	// Dependency marks the VM as installed only because a chain needs it
+	Dependency bool
 
 	StateFile  state.File
 	Repository state.Repository
 	Fs         afero.Fs
 	Installer  Installer
+	Verifier   signature.Verifier
+	// Cache is checked for the artifact before downloading it
+	Cache *cache.Cache
+	// Plan records what the install would do instead of doing it
+	Plan *Plan
 }
 
 func NewInstall(config InstallConfig) *Install {
+	if config.Platform == (types.Platform{}) {
+		config.Platform = types.CurrentPlatform()
+	}
+
 	return &Install{
-		name:         config.Name,
-		plugin:       config.Plugin,
-		organization: config.Organization,
-		repo:         config.Repo,
-		tmpPath:      config.TmpPath,
-		pluginPath:   config.PluginPath,
-		stateFile:    config.StateFile,
-		repository:   config.Repository,
-		fs:           config.Fs,
-		installer:    config.Installer,
-		checksummer:  checksum.NewSHA256(config.Fs),
+		name:          config.Name,
+		plugin:        config.Plugin,
+		organization:  config.Organization,
+		repo:          config.Repo,
+		tmpPath:       config.TmpPath,
+		pluginPath:    config.PluginPath,
+		constraint:    config.Constraint,
+		platform:      config.Platform,
+		allowUnsigned: config.AllowUnsigned,
+		keepVersions:  config.KeepVersions,
+		dependency:    config.Dependency,
+		stateFile:     config.StateFile,
+		repository:    config.Repository,
+		fs:            config.Fs,
+		installer:     config.Installer,
+		verifier:      config.Verifier,
+		cache:         config.Cache,
+		plan:          config.Plan,
+		checksummer:   checksum.NewSHA256(config.Fs),
 	}
 }
 
 type Install struct {
+	transactional
+
 	name         string
 	plugin       string
 	organization string
 	repo         string
 	tmpPath      string
 	pluginPath   string
+	constraint   string
+	platform     types.Platform
+
+	allowUnsigned bool
+	keepVersions  int
+	dependency    bool
 
 	stateFile   state.File
 	repository  state.Repository
 	fs          afero.Fs
 	installer   Installer
+	verifier    signature.Verifier
+	cache       *cache.Cache
+	plan        *Plan
 	checksummer checksum.Checksummer
 }
 
@@ -70,16 +115,54 @@ func (i Install) Execute() error {
 		return err
 	}
 
-	vm := definition.Definition
+	vm, version, err := resolveVersion(i.name, definition.Definition, i.constraint)
+	if err != nil {
+		return err
+	}
+
+	vm, err = resolveArtifact(i.name, vm, i.platform)
+	if err != nil {
+		return err
+	}
+
+	format := vm.Format
+	if format == "" {
+		format = types.TarGzFormat
+	}
+	if format != types.BinaryFormat {
+		if _, err := archive.ParseFormat(format); err != nil {
+			return fmt.Errorf("%s is published as %s, which isn't a supported format", i.name, format)
+		}
+	}
+
+	if i.plan != nil {
+		i.planInstall(vm, definition.Commit, version)
+		return nil
+	}
 
-	archiveFile := fmt.Sprintf("%s.tar.gz", i.plugin)
+	archiveFile := fmt.Sprintf("%s.%s", i.plugin, format)
 	tmpPath := filepath.Join(i.tmpPath, i.organization, i.repo)
 	archiveFilePath := filepath.Join(tmpPath, archiveFile)
+	signaturePath := archiveFilePath + signature.Extension
 	workingDir := filepath.Join(tmpPath, i.plugin)
 
-	if err := i.installer.Download(vm.URL, archiveFilePath); err != nil {
-		// TODO sometimes these aren't cleaned up if we fail before cleanup step
-		return err
+	tx := i.transaction()
+	// Clean up anything left behind in our scratch space if we fail partway.
+	tx.OnRollback(func() error {
+		return i.fs.RemoveAll(workingDir)
+	})
+	tx.OnRollback(func() error {
+		return removeIfExists(i.fs, archiveFilePath)
+	})
+	tx.OnRollback(func() error {
+		return removeIfExists(i.fs, signaturePath)
+	})
+
+	cached := fetchCached(i.cache, vm.SHA256, archiveFilePath)
+	if !cached {
+		if err := i.installer.Download(vm.URL, archiveFilePath); err != nil {
+			return err
+		}
 	}
 
 	fmt.Printf("Calculating checksums...\n")
@@ -89,52 +172,147 @@ func (i Install) Execute() error {
 	}
 
 	fmt.Printf("Saw expected checksum value of %s\n", hash)
+	if !cached {
+		addToCache(i.cache, archiveFilePath, vm.URL)
+	}
 
-	// Create the directory we'll store the plugin sources in if it doesn't exist.
-	if _, err := i.fs.Stat(workingDir); errors.Is(err, fs.ErrNotExist) {
-		fmt.Printf("Creating sources directory...\n")
-		if err := i.fs.Mkdir(workingDir, perms.ReadWriteExecute); err != nil {
+	signer := ""
+	if vm.Signature == "" {
+		if err := allowUnsigned(i.name, i.allowUnsigned); err != nil {
 			return err
 		}
-	} else if err != nil {
-		return err
-	}
+	} else {
+		cachedSignature := fetchCachedSignature(i.cache, hash, signaturePath)
+		if !cachedSignature {
+			if err := i.installer.Download(vm.Signature, signaturePath); err != nil {
+				return err
+			}
+		}
 
-	fmt.Printf("Unpacking %s...\n", i.name)
-	if err := i.installer.Decompress(archiveFilePath, workingDir); err != nil {
-		return err
+		signer, err = verifySignature(i.fs, i.verifier, i.name, archiveFilePath, signaturePath)
+		if err != nil {
+			return err
+		}
+		if !cachedSignature {
+			addSignatureToCache(i.cache, hash, signaturePath)
+		}
 	}
 
-	if vm.InstallScript != "" {
-		args := strings.Split(vm.InstallScript, " ")
-		fmt.Printf("Running install script at %s...\n", vm.InstallScript)
-		if err := i.installer.Install(workingDir, args...); err != nil {
+	// The downloaded file is the binary itself unless it's an archive.
+	binaryPath := archiveFilePath
+	if format != types.BinaryFormat {
+		// Create the directory we'll store the plugin sources in if it doesn't exist.
+		if _, err := i.fs.Stat(workingDir); errors.Is(err, fs.ErrNotExist) {
+			fmt.Printf("Creating sources directory...\n")
+			if err := i.fs.Mkdir(workingDir, perms.ReadWriteExecute); err != nil {
+				return err
+			}
+		} else if err != nil {
 			return err
 		}
-	} else {
-		fmt.Printf("No install script found for %s.\n", i.name)
+
+		fmt.Printf("Unpacking %s...\n", i.name)
+		if err := i.installer.Decompress(archiveFilePath, workingDir); err != nil {
+			return err
+		}
+
+		if vm.InstallScript != "" {
+			args := strings.Split(vm.InstallScript, " ")
+			fmt.Printf("Running install script at %s...\n", vm.InstallScript)
+			if err := i.installer.Install(workingDir, args...); err != nil {
+				return err
+			}
+		} else {
+			fmt.Printf("No install script found for %s.\n", i.name)
+		}
+
+		binaryPath = filepath.Join(workingDir, vm.BinaryPath)
 	}
 
+	// Keep the binary we're replacing so it can be rolled back to, and which
+	// chains need the VM.
+	var (
+		previous   []state.PreviousVersion
+		chains     []string
+		dependency = i.dependency
+	)
+	if installed, ok := i.stateFile.InstallationRegistry[i.name]; ok {
+		previous = installed.Previous
+		chains = installed.Chains
+		dependency = installed.Dependency
+		if i.keepVersions > 0 {
+			previous, err = keepVersion(tx, i.fs, installed, i.pluginPath, installed.Previous, i.keepVersions)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	pluginBinaryPath := filepath.Join(i.pluginPath, vm.ID)
 	fmt.Printf("Moving binary %s into plugin directory...\n", vm.ID)
-	if err := i.fs.Rename(filepath.Join(workingDir, vm.BinaryPath), filepath.Join(i.pluginPath, vm.ID)); err != nil {
+	if err := moveBinary(tx, i.fs, binaryPath, pluginBinaryPath); err != nil {
 		return err
 	}
 
 	fmt.Printf("Cleaning up temporary files...\n")
-	if err := i.fs.Remove(filepath.Join(tmpPath, archiveFile)); err != nil {
+	if err := removeIfExists(i.fs, archiveFilePath); err != nil {
+		return err
+	}
+	if err := removeIfExists(i.fs, signaturePath); err != nil {
 		return err
 	}
 
-	if err := i.fs.RemoveAll(filepath.Join(tmpPath, i.plugin)); err != nil {
+	if err := i.fs.RemoveAll(workingDir); err != nil {
 		return err
 	}
 
 	fmt.Printf("Adding virtual machine %s to installation registry...\n", vm.ID)
-	i.stateFile.InstallationRegistry[i.name] = &state.InstallInfo{
-		ID:     vm.ID,
-		Commit: definition.Commit,
+	if _, err := register(i.stateFile, i.checksummer, &state.InstallInfo{
+		ID:         vm.ID,
+		Commit:     definition.Commit,
+		Version:    version,
+		Constraint: i.constraint,
+		Signer:     signer,
+		Origin: state.Origin{
+			Type:       state.RepositoryOrigin,
+			Repository: fmt.Sprintf("%s%s%s", i.organization, constant.AliasDelimiter, i.repo),
+			Definition: i.plugin,
+		},
+		Previous:   previous,
+		Chains:     chains,
+		Dependency: dependency,
+	}, pluginBinaryPath); err != nil {
+		return err
 	}
 
-	fmt.Printf("Successfully installed %s@%s in %s\n", i.name, definition.Commit, filepath.Join(i.pluginPath, vm.ID))
+	if version == "" {
+		version = definition.Commit
+	}
+	fmt.Printf("Successfully installed %s@%s in %s\n", i.name, version, filepath.Join(i.pluginPath, vm.ID))
 	return nil
 }
+
+// planInstall records the steps of installing vm in the plan.
+func (i Install) planInstall(vm types.VM, commit string, version string) {
+	i.plan.Download(vm.URL, fmt.Sprintf("sha256 %s", vm.SHA256))
+	if vm.Signature != "" {
+		i.plan.Download(vm.Signature, "signature")
+	}
+	if vm.InstallScript != "" {
+		i.plan.Run(vm.InstallScript, "install script")
+	}
+
+	if installed, ok := i.stateFile.InstallationRegistry[i.name]; ok && i.keepVersions > 0 {
+		kept, _, removed := keptVersions(installed, installed.Previous, i.keepVersions)
+		i.plan.Write(filepath.Join(i.pluginPath, filepath.FromSlash(kept.Path)), "kept for rollbacks")
+		for _, version := range removed {
+			i.plan.Remove(filepath.Join(i.pluginPath, filepath.FromSlash(version.Path)), "oldest kept version")
+		}
+	}
+
+	i.plan.Write(filepath.Join(i.pluginPath, vm.ID), "")
+	if version == "" {
+		version = commit
+	}
+	i.plan.Register(i.name, version)
+}
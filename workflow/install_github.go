@@ -6,56 +6,94 @@ package workflow
 import (
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/luxfi/filesystem/perms"
 	"github.com/spf13/afero"
+
+	"github.com/luxfi/lpm/cache"
+	"github.com/luxfi/lpm/checksum"
+	"github.com/luxfi/lpm/signature"
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/url"
 )
 
 var _ Workflow = &InstallGitHub{}
 
 // InstallGitHubConfig configures a GitHub release binary install.
 type InstallGitHubConfig struct {
-	Owner     string
-	Repo      string
-	Tag       string
-	VMID      string
-	Pattern   string
+	Owner   string
+	Repo    string
+	Tag     string
+	VMID    string
+	Pattern string
+	// Binary is the path of the binary inside of the release archive
+	Binary    string
 	OS        string
 	Arch      string
 	PluginDir string
-	Fs        afero.Fs
+	// RequireChecksum fails the install if the release doesn't publish a
+	// checksum of the asset
+	RequireChecksum bool
+	// AllowUnsigned installs releases without a detached signature
+	AllowUnsigned bool
+	StateFile     state.File
+	Fs            afero.Fs
+	Verifier      signature.Verifier
+	Client        url.Client
+	// Cache is checked for the release asset before downloading it
+	Cache *cache.Cache
+	// Plan records what the install would do instead of doing it
+	Plan *Plan
 }
 
 // InstallGitHub downloads a pre-compiled binary from GitHub releases.
 type InstallGitHub struct {
+	transactional
+
 	owner     string
 	repo      string
 	tag       string
 	vmid      string
 	pattern   string
+	binary    string
 	goos      string
 	goarch    string
 	pluginDir string
+	stateFile state.File
 	fs        afero.Fs
+	client    url.Client
+	cache     *cache.Cache
+	plan      *Plan
+
+	requireChecksum bool
+	allowUnsigned   bool
+	verifier        signature.Verifier
+	checksummer     checksum.Checksummer
 }
 
 // NewInstallGitHub creates a new GitHub release install workflow.
 func NewInstallGitHub(config InstallGitHubConfig) *InstallGitHub {
 	return &InstallGitHub{
-		owner:     config.Owner,
-		repo:      config.Repo,
-		tag:       config.Tag,
-		vmid:      config.VMID,
-		pattern:   config.Pattern,
-		goos:      config.OS,
-		goarch:    config.Arch,
-		pluginDir: config.PluginDir,
-		fs:        config.Fs,
+		owner:           config.Owner,
+		repo:            config.Repo,
+		tag:             config.Tag,
+		vmid:            config.VMID,
+		pattern:         config.Pattern,
+		binary:          config.Binary,
+		goos:            config.OS,
+		goarch:          config.Arch,
+		pluginDir:       config.PluginDir,
+		stateFile:       config.StateFile,
+		fs:              config.Fs,
+		client:          config.Client,
+		cache:           config.Cache,
+		plan:            config.Plan,
+		requireChecksum: config.RequireChecksum,
+		allowUnsigned:   config.AllowUnsigned,
+		verifier:        config.Verifier,
+		checksummer:     checksum.NewSHA256(config.Fs),
 	}
 }
 
@@ -98,29 +136,95 @@ func (g *InstallGitHub) Execute() error {
 		return err
 	}
 
-	fmt.Printf("Downloading %s (%d MB)...\n", asset.Name, asset.Size/(1024*1024))
+	if g.plan != nil {
+		g.plan.Download(asset.BrowserDownloadURL, fmt.Sprintf("%d MB", asset.Size/(1024*1024)))
+		if sigAsset := findSignatureAsset(release, asset); sigAsset != nil {
+			g.plan.Download(sigAsset.BrowserDownloadURL, "signature")
+		}
+		planBinary(g.plan, state.Origin{Type: state.GitHubOrigin, Owner: g.owner, Repo: g.repo}, g.pluginDir, vmid, release.TagName)
+		return nil
+	}
 
-	// Download to temp file
 	tmpFile := filepath.Join(os.TempDir(), fmt.Sprintf("lpm-%s-%s", g.repo, asset.Name))
-	if err := downloadFile(asset.BrowserDownloadURL, tmpFile); err != nil {
-		return fmt.Errorf("download failed: %w", err)
+	// Clean up the download even if it fails part way.
+	defer func() {
+		_ = g.fs.Remove(tmpFile)
+	}()
+
+	files := make([]releaseFile, 0, len(release.Assets))
+	for _, a := range release.Assets {
+		files = append(files, releaseFile{name: a.Name, url: a.BrowserDownloadURL})
+	}
+	expected, sumsFile, err := releaseChecksum(g.fs, files, asset.Name, tmpFile, g.client.Download)
+	if err != nil {
+		return err
+	}
+
+	// Download to temp file, unless it's already cached
+	cached := fetchCached(g.cache, expected, tmpFile)
+	if !cached {
+		fmt.Printf("Downloading %s (%d MB)...\n", asset.Name, asset.Size/(1024*1024))
+		if err := g.client.Download(asset.BrowserDownloadURL, tmpFile); err != nil {
+			return err
+		}
+	}
+
+	hash, err := verifyReleaseChecksum(g.checksummer, asset.Name, tmpFile, expected, sumsFile, g.requireChecksum)
+	if err != nil {
+		return err
+	}
+	// Only verified assets are cached, since they're looked up by the
+	// published checksum.
+	if !cached && hash != "" {
+		addToCache(g.cache, tmpFile, asset.BrowserDownloadURL)
 	}
-	defer os.Remove(tmpFile)
 
-	// Ensure plugin directory exists
-	if err := g.fs.MkdirAll(g.pluginDir, perms.ReadWriteExecute); err != nil {
-		return fmt.Errorf("failed to create plugin directory: %w", err)
+	signer := ""
+	if sigAsset := findSignatureAsset(release, asset); sigAsset == nil {
+		if err := allowUnsigned(asset.Name, g.allowUnsigned); err != nil {
+			return err
+		}
+	} else {
+		sigFile := tmpFile + signature.Extension
+		defer func() {
+			_ = g.fs.Remove(sigFile)
+		}()
+		if err := g.client.Download(sigAsset.BrowserDownloadURL, sigFile); err != nil {
+			return fmt.Errorf("signature download failed: %w", err)
+		}
+
+		signer, err = verifySignature(g.fs, g.verifier, asset.Name, tmpFile, sigFile)
+		if err != nil {
+			return err
+		}
 	}
 
-	// Move binary to plugin directory
+	unpacked, err := unpackRelease(g.fs, asset.Name, tmpFile, g.binary, g.repo, vmid)
+	if err != nil {
+		return err
+	}
+	defer unpacked.cleanup()
+
 	destPath := filepath.Join(g.pluginDir, vmid)
-	if err := copyFile(tmpFile, destPath); err != nil {
-		return fmt.Errorf("failed to install binary: %w", err)
+	if err := installBinary(g.transaction(), g.fs, unpacked.binaryPath, destPath); err != nil {
+		return err
 	}
 
-	// Make executable
-	if err := os.Chmod(destPath, 0o755); err != nil {
-		return fmt.Errorf("failed to set permissions: %w", err)
+	if _, err := register(g.stateFile, g.checksummer, &state.InstallInfo{
+		ID:      vmid,
+		Version: release.TagName,
+		Signer:  signer,
+		Origin: state.Origin{
+			Type:   state.GitHubOrigin,
+			Owner:  g.owner,
+			Repo:   g.repo,
+			Tag:    release.TagName,
+			URL:    asset.BrowserDownloadURL,
+			SHA256: hash,
+			Binary: unpacked.binary,
+		},
+	}, destPath); err != nil {
+		return err
 	}
 
 	fmt.Printf("Installed %s/%s %s\n", g.owner, g.repo, release.TagName)
@@ -138,19 +242,13 @@ func (g *InstallGitHub) getRelease() (*ghRelease, error) {
 		url = fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", g.owner, g.repo)
 	}
 
-	resp, err := http.Get(url) // #nosec G107 -- URL is constructed from user input for repo
+	body, err := g.client.Get(url, nil)
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitHub API returned %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("GitHub API request failed: %w", err)
 	}
 
 	var release ghRelease
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+	if err := json.Unmarshal(body, &release); err != nil {
 		return nil, fmt.Errorf("failed to parse release: %w", err)
 	}
 
@@ -165,6 +263,9 @@ func (g *InstallGitHub) findAsset(release *ghRelease) (*ghAsset, error) {
 	for _, pattern := range patterns {
 		for i := range release.Assets {
 			name := strings.ToLower(release.Assets[i].Name)
+			if isReleaseMetadata(name) {
+				continue
+			}
 			if strings.Contains(name, pattern) {
 				return &release.Assets[i], nil
 			}
@@ -180,6 +281,18 @@ func (g *InstallGitHub) findAsset(release *ghRelease) (*ghAsset, error) {
 	return nil, fmt.Errorf("no matching binary found for %s/%s (os=%s, arch=%s)", g.goos, g.goarch, g.goos, g.goarch)
 }
 
+// findSignatureAsset returns the detached signature of asset published in
+// release, if there is one.
+func findSignatureAsset(release *ghRelease, asset *ghAsset) *ghAsset {
+	for i := range release.Assets {
+		if release.Assets[i].Name == asset.Name+signature.Extension {
+			return &release.Assets[i]
+		}
+	}
+
+	return nil
+}
+
 func (g *InstallGitHub) buildPatterns() []string {
 	if g.pattern != "" {
 		// Use custom pattern with substitution
@@ -236,51 +349,3 @@ func resolveVMID(owner, repo string) (string, error) {
 	}
 	return id.String(), nil
 }
-
-// downloadFile downloads a URL to a local file.
-func downloadFile(url, dest string) error {
-	resp, err := http.Get(url) // #nosec G107 -- URL from GitHub API
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP %d", resp.StatusCode)
-	}
-
-	f, err := os.Create(dest)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	written, err := io.Copy(f, resp.Body)
-	if err != nil {
-		return err
-	}
-
-	fmt.Printf("Downloaded %d bytes\n", written)
-	return nil
-}
-
-// copyFile copies a file to a destination path.
-func copyFile(src, dst string) error {
-	in, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer in.Close()
-
-	out, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	if _, err := io.Copy(out, in); err != nil {
-		return err
-	}
-
-	return out.Close()
-}
@@ -0,0 +1,58 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/url"
+)
+
+func TestInstallGitHubDownloadFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	fs := afero.NewMemMapFs()
+	errDownload := errors.New("connection reset")
+
+	stateFile, err := state.New(t.TempDir())
+	require.NoError(t, err)
+
+	var downloaded string
+	client := url.NewMockClient(ctrl)
+	client.EXPECT().Get("https://api.github.com/repos/owner/repo/releases/tags/v1.0.0", nil).Return(
+		[]byte(`{"tag_name": "v1.0.0", "assets": [{"name": "vm-linux-amd64.tar.gz", "browser_download_url": "https://example.com/vm-linux-amd64.tar.gz"}]}`),
+		nil,
+	)
+	// The mock records the download's path before its url.
+	client.EXPECT().Download(gomock.Any(), "https://example.com/vm-linux-amd64.tar.gz").DoAndReturn(func(path string, _ string) error {
+		// The download fails after writing part of the file.
+		downloaded = path
+		require.NoError(t, afero.WriteFile(fs, path, []byte("partial"), perms.ReadWrite))
+		return errDownload
+	})
+
+	err = NewInstallGitHub(InstallGitHubConfig{
+		Owner:     "owner",
+		Repo:      "repo",
+		Tag:       "v1.0.0",
+		VMID:      "vmID",
+		OS:        "linux",
+		Arch:      "amd64",
+		PluginDir: "plugins",
+		StateFile: stateFile,
+		Fs:        fs,
+		Client:    client,
+	}).Execute()
+	require.ErrorIs(t, err, errDownload)
+
+	exists, err := afero.Exists(fs, downloaded)
+	require.NoError(t, err)
+	require.False(t, exists)
+}
@@ -6,47 +6,77 @@ package workflow
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"net/url"
+	neturl "net/url"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/luxfi/filesystem/perms"
 	"github.com/spf13/afero"
+
+	"github.com/luxfi/lpm/cache"
+	"github.com/luxfi/lpm/checksum"
+	"github.com/luxfi/lpm/signature"
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/url"
 )
 
 var _ Workflow = &InstallGitLab{}
 
 // InstallGitLabConfig configures a GitLab release binary install.
 type InstallGitLabConfig struct {
-	Owner     string
-	Repo      string
-	Tag       string
-	VMID      string
-	Pattern   string
+	Owner   string
+	Repo    string
+	Tag     string
+	VMID    string
+	Pattern string
+	// Binary is the path of the binary inside of the release archive
+	Binary    string
 	OS        string
 	Arch      string
 	PluginDir string
 	BaseURL   string // GitLab instance URL (default: https://gitlab.com)
 	Token     string // Private token for authentication
-	Fs        afero.Fs
+	// RequireChecksum fails the install if the release doesn't publish a
+	// checksum of the asset
+	RequireChecksum bool
+	// AllowUnsigned installs releases without a detached signature
+	AllowUnsigned bool
+	StateFile     state.File
+	Fs            afero.Fs
+	Verifier      signature.Verifier
+	Client        url.Client
+	// Cache is checked for the release asset before downloading it
+	Cache *cache.Cache
+	// Plan records what the install would do instead of doing it
+	Plan *Plan
 }
 
 // InstallGitLab downloads a pre-compiled binary from GitLab releases.
 type InstallGitLab struct {
+	transactional
+
 	owner     string
 	repo      string
 	tag       string
 	vmid      string
 	pattern   string
+	binary    string
 	goos      string
 	goarch    string
 	pluginDir string
 	baseURL   string
 	token     string
+	stateFile state.File
 	fs        afero.Fs
+	client    url.Client
+	cache     *cache.Cache
+	plan      *Plan
+
+	requireChecksum bool
+	allowUnsigned   bool
+	verifier        signature.Verifier
+	checksummer     checksum.Checksummer
 }
 
 // NewInstallGitLab creates a new GitLab release install workflow.
@@ -56,17 +86,26 @@ func NewInstallGitLab(config InstallGitLabConfig) *InstallGitLab {
 		baseURL = "https://gitlab.com"
 	}
 	return &InstallGitLab{
-		owner:     config.Owner,
-		repo:      config.Repo,
-		tag:       config.Tag,
-		vmid:      config.VMID,
-		pattern:   config.Pattern,
-		goos:      config.OS,
-		goarch:    config.Arch,
-		pluginDir: config.PluginDir,
-		baseURL:   strings.TrimRight(baseURL, "/"),
-		token:     config.Token,
-		fs:        config.Fs,
+		owner:           config.Owner,
+		repo:            config.Repo,
+		tag:             config.Tag,
+		vmid:            config.VMID,
+		pattern:         config.Pattern,
+		binary:          config.Binary,
+		goos:            config.OS,
+		goarch:          config.Arch,
+		pluginDir:       config.PluginDir,
+		baseURL:         strings.TrimRight(baseURL, "/"),
+		token:           config.Token,
+		stateFile:       config.StateFile,
+		fs:              config.Fs,
+		client:          config.Client,
+		cache:           config.Cache,
+		plan:            config.Plan,
+		requireChecksum: config.RequireChecksum,
+		allowUnsigned:   config.AllowUnsigned,
+		verifier:        config.Verifier,
+		checksummer:     checksum.NewSHA256(config.Fs),
 	}
 }
 
@@ -121,29 +160,96 @@ func (g *InstallGitLab) Execute() error {
 		return err
 	}
 
-	fmt.Printf("Downloading %s...\n", link.Name)
+	if g.plan != nil {
+		g.plan.Download(link.URL, "")
+		if sigLink := findSignatureLink(release, link); sigLink != nil {
+			g.plan.Download(sigLink.URL, "signature")
+		}
+		planBinary(g.plan, state.Origin{Type: state.GitLabOrigin, Owner: g.owner, Repo: g.repo}, g.pluginDir, vmid, release.TagName)
+		return nil
+	}
 
-	// Download to temp file
 	tmpFile := filepath.Join(os.TempDir(), fmt.Sprintf("lpm-%s-%s", g.repo, link.Name))
-	if err := g.downloadWithAuth(link.URL, tmpFile); err != nil {
-		return fmt.Errorf("download failed: %w", err)
+	// Clean up the download even if it fails part way.
+	defer func() {
+		_ = g.fs.Remove(tmpFile)
+	}()
+
+	files := make([]releaseFile, 0, len(release.Assets.Links))
+	for _, l := range release.Assets.Links {
+		files = append(files, releaseFile{name: l.Name, url: l.URL})
+	}
+	expected, sumsFile, err := releaseChecksum(g.fs, files, link.Name, tmpFile, g.downloadWithAuth)
+	if err != nil {
+		return err
 	}
-	defer os.Remove(tmpFile)
 
-	// Ensure plugin directory exists
-	if err := g.fs.MkdirAll(g.pluginDir, perms.ReadWriteExecute); err != nil {
-		return fmt.Errorf("failed to create plugin directory: %w", err)
+	// Download to temp file, unless it's already cached
+	cached := fetchCached(g.cache, expected, tmpFile)
+	if !cached {
+		fmt.Printf("Downloading %s...\n", link.Name)
+		if err := g.downloadWithAuth(link.URL, tmpFile); err != nil {
+			return err
+		}
+	}
+
+	hash, err := verifyReleaseChecksum(g.checksummer, link.Name, tmpFile, expected, sumsFile, g.requireChecksum)
+	if err != nil {
+		return err
+	}
+	// Only verified assets are cached, since they're looked up by the
+	// published checksum.
+	if !cached && hash != "" {
+		addToCache(g.cache, tmpFile, link.URL)
 	}
 
-	// Move binary to plugin directory
+	signer := ""
+	if sigLink := findSignatureLink(release, link); sigLink == nil {
+		if err := allowUnsigned(link.Name, g.allowUnsigned); err != nil {
+			return err
+		}
+	} else {
+		sigFile := tmpFile + signature.Extension
+		defer func() {
+			_ = g.fs.Remove(sigFile)
+		}()
+		if err := g.downloadWithAuth(sigLink.URL, sigFile); err != nil {
+			return fmt.Errorf("signature download failed: %w", err)
+		}
+
+		signer, err = verifySignature(g.fs, g.verifier, link.Name, tmpFile, sigFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	unpacked, err := unpackRelease(g.fs, link.Name, tmpFile, g.binary, g.repo, vmid)
+	if err != nil {
+		return err
+	}
+	defer unpacked.cleanup()
+
 	destPath := filepath.Join(g.pluginDir, vmid)
-	if err := copyFile(tmpFile, destPath); err != nil {
-		return fmt.Errorf("failed to install binary: %w", err)
+	if err := installBinary(g.transaction(), g.fs, unpacked.binaryPath, destPath); err != nil {
+		return err
 	}
 
-	// Make executable
-	if err := os.Chmod(destPath, 0o755); err != nil {
-		return fmt.Errorf("failed to set permissions: %w", err)
+	if _, err := register(g.stateFile, g.checksummer, &state.InstallInfo{
+		ID:      vmid,
+		Version: release.TagName,
+		Signer:  signer,
+		Origin: state.Origin{
+			Type:    state.GitLabOrigin,
+			Owner:   g.owner,
+			Repo:    g.repo,
+			Tag:     release.TagName,
+			BaseURL: g.baseURL,
+			URL:     link.URL,
+			SHA256:  hash,
+			Binary:  unpacked.binary,
+		},
+	}, destPath); err != nil {
+		return err
 	}
 
 	fmt.Printf("Installed %s/%s %s\n", g.owner, g.repo, release.TagName)
@@ -155,7 +261,7 @@ func (g *InstallGitLab) Execute() error {
 
 func (g *InstallGitLab) getRelease() (*glRelease, error) {
 	// GitLab requires URL-encoded project path
-	projectPath := url.PathEscape(fmt.Sprintf("%s/%s", g.owner, g.repo))
+	projectPath := neturl.PathEscape(fmt.Sprintf("%s/%s", g.owner, g.repo))
 
 	var apiURL string
 	if g.tag != "" {
@@ -165,29 +271,14 @@ func (g *InstallGitLab) getRelease() (*glRelease, error) {
 		apiURL = fmt.Sprintf("%s/api/v4/projects/%s/releases", g.baseURL, projectPath)
 	}
 
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	if g.token != "" {
-		req.Header.Set("PRIVATE-TOKEN", g.token)
-	}
-
-	resp, err := http.DefaultClient.Do(req)
+	body, err := g.client.Get(apiURL, g.header())
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GitLab API returned %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("GitLab API request failed: %w", err)
 	}
 
 	if g.tag != "" {
 		var release glRelease
-		if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		if err := json.Unmarshal(body, &release); err != nil {
 			return nil, fmt.Errorf("failed to parse release: %w", err)
 		}
 		return &release, nil
@@ -195,7 +286,7 @@ func (g *InstallGitLab) getRelease() (*glRelease, error) {
 
 	// For latest, decode array and take the first
 	var releases []glRelease
-	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+	if err := json.Unmarshal(body, &releases); err != nil {
 		return nil, fmt.Errorf("failed to parse releases: %w", err)
 	}
 	if len(releases) == 0 {
@@ -210,6 +301,9 @@ func (g *InstallGitLab) findAsset(release *glRelease) (*glLink, error) {
 	for _, pattern := range patterns {
 		for i := range release.Assets.Links {
 			name := strings.ToLower(release.Assets.Links[i].Name)
+			if isReleaseMetadata(name) {
+				continue
+			}
 			if strings.Contains(name, pattern) {
 				return &release.Assets.Links[i], nil
 			}
@@ -225,6 +319,18 @@ func (g *InstallGitLab) findAsset(release *glRelease) (*glLink, error) {
 	return nil, fmt.Errorf("no matching binary found for %s/%s (os=%s, arch=%s)", g.goos, g.goarch, g.goos, g.goarch)
 }
 
+// findSignatureLink returns the detached signature of link published in
+// release, if there is one.
+func findSignatureLink(release *glRelease, link *glLink) *glLink {
+	for i := range release.Assets.Links {
+		if release.Assets.Links[i].Name == link.Name+signature.Extension {
+			return &release.Assets.Links[i]
+		}
+	}
+
+	return nil
+}
+
 func (g *InstallGitLab) buildPatterns() []string {
 	if g.pattern != "" {
 		p := strings.ReplaceAll(g.pattern, "{os}", g.goos)
@@ -255,37 +361,17 @@ func (g *InstallGitLab) buildPatterns() []string {
 	return patterns
 }
 
-func (g *InstallGitLab) downloadWithAuth(dlURL, dest string) error {
-	req, err := http.NewRequest("GET", dlURL, nil)
-	if err != nil {
-		return err
-	}
-
+// header returns the headers authenticating requests to GitLab.
+func (g *InstallGitLab) header() http.Header {
+	header := http.Header{}
 	if g.token != "" {
-		req.Header.Set("PRIVATE-TOKEN", g.token)
-	}
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP %d", resp.StatusCode)
-	}
-
-	f, err := os.Create(dest)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	written, err := io.Copy(f, resp.Body)
-	if err != nil {
-		return err
+		header.Set("PRIVATE-TOKEN", g.token)
 	}
+	return header
+}
 
-	fmt.Printf("Downloaded %d bytes\n", written)
-	return nil
+// downloadWithAuth downloads a URL to a local file, authenticating with the
+// private token if there is one.
+func (g *InstallGitLab) downloadWithAuth(dlURL, dest string) error {
+	return g.client.DownloadWithHeader(dlURL, dest, g.header())
 }
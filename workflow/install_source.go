@@ -10,8 +10,10 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/luxfi/filesystem/perms"
 	"github.com/spf13/afero"
+
+	"github.com/luxfi/lpm/checksum"
+	"github.com/luxfi/lpm/state"
 )
 
 var _ Workflow = &InstallSource{}
@@ -27,11 +29,16 @@ type InstallSourceConfig struct {
 	OS          string
 	Arch        string
 	PluginDir   string
+	StateFile   state.File
 	Fs          afero.Fs
+	// Plan records what the install would do instead of doing it
+	Plan *Plan
 }
 
 // InstallSource clones, builds, and installs a VM from source.
 type InstallSource struct {
+	transactional
+
 	owner       string
 	repo        string
 	tag         string
@@ -41,7 +48,10 @@ type InstallSource struct {
 	goos        string
 	goarch      string
 	pluginDir   string
+	stateFile   state.File
 	fs          afero.Fs
+	plan        *Plan
+	checksummer checksum.Checksummer
 }
 
 // NewInstallSource creates a new source build install workflow.
@@ -56,7 +66,10 @@ func NewInstallSource(config InstallSourceConfig) *InstallSource {
 		goos:        config.OS,
 		goarch:      config.Arch,
 		pluginDir:   config.PluginDir,
+		stateFile:   config.StateFile,
 		fs:          config.Fs,
+		plan:        config.Plan,
+		checksummer: checksum.NewSHA256(config.Fs),
 	}
 }
 
@@ -72,6 +85,11 @@ func (s *InstallSource) Execute() error {
 		vmid = id
 	}
 
+	if s.plan != nil {
+		s.planInstall(vmid)
+		return nil
+	}
+
 	// Check Go is available
 	if _, err := exec.LookPath("go"); err != nil {
 		return fmt.Errorf("go toolchain not found: %w\nInstall Go from https://go.dev/dl/ or use 'lpm install-github' for pre-compiled binaries", err)
@@ -149,17 +167,22 @@ func (s *InstallSource) Execute() error {
 	}
 
 	// Install
-	if err := s.fs.MkdirAll(s.pluginDir, perms.ReadWriteExecute); err != nil {
-		return fmt.Errorf("failed to create plugin directory: %w", err)
-	}
-
 	destPath := filepath.Join(s.pluginDir, vmid)
-	if err := copyFile(fullBinaryPath, destPath); err != nil {
-		return fmt.Errorf("failed to install binary: %w", err)
+	if err := installBinary(s.transaction(), s.fs, fullBinaryPath, destPath); err != nil {
+		return err
 	}
 
-	if err := os.Chmod(destPath, 0o755); err != nil {
-		return fmt.Errorf("failed to set permissions: %w", err)
+	if _, err := register(s.stateFile, s.checksummer, &state.InstallInfo{
+		ID:      vmid,
+		Version: ref,
+		Origin: state.Origin{
+			Type:  state.SourceOrigin,
+			Owner: s.owner,
+			Repo:  s.repo,
+			Ref:   ref,
+		},
+	}, destPath); err != nil {
+		return err
 	}
 
 	fmt.Printf("Built and installed %s/%s\n", s.owner, s.repo)
@@ -206,3 +229,19 @@ func (s *InstallSource) findBinary(dir string) string {
 
 	return ""
 }
+
+// planInstall records the steps of building and installing vmid in the plan.
+func (s *InstallSource) planInstall(vmid string) {
+	ref := s.tag
+	if ref == "" {
+		ref = "main"
+	}
+	s.plan.Run(fmt.Sprintf("git clone https://github.com/%s/%s.git", s.owner, s.repo), ref)
+
+	buildCmd := s.buildScript
+	if buildCmd == "" {
+		buildCmd = "build command detected after cloning"
+	}
+	s.plan.Run(buildCmd, fmt.Sprintf("%s/%s", s.goos, s.goarch))
+	planBinary(s.plan, state.Origin{Type: state.SourceOrigin, Owner: s.owner, Repo: s.repo}, s.pluginDir, vmid, ref)
+}
@@ -4,6 +4,7 @@
 package workflow
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"path/filepath"
 	"testing"
@@ -14,7 +15,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/luxfi/lpm/cache"
 	"github.com/luxfi/lpm/checksum"
+	"github.com/luxfi/lpm/signature"
 	"github.com/luxfi/lpm/state"
 	"github.com/luxfi/lpm/types"
 )
@@ -54,9 +57,59 @@ func TestInstallExecute(t *testing.T) {
 	}
 	noInstallScriptVM := noInstallScriptDefinition.Definition
 
+	// The cache checks artifacts against their real checksum.
+	content := []byte("archive")
+	archiveHash := sha256.Sum256(content)
+	cachedDefinition := definition
+	cachedDefinition.Definition.SHA256 = fmt.Sprintf("%x", archiveHash)
+
+	signedDefinition := definition
+	signedDefinition.Definition.Signature = "www.website.com.minisig"
+
+	versionedDefinition := state.Definition[types.VM]{
+		Definition: types.VM{
+			ID:            "id",
+			Alias:         "alias",
+			InstallScript: "./path/to/install/script.sh",
+			BinaryPath:    "./path/to/binary",
+			Versions: []types.Version{
+				{Version: "1.0.0", URL: "www.website.com/1.0.0", SHA256: "666f6f626172"},
+				{Version: "1.1.0", URL: "www.website.com/1.1.0", SHA256: "666f6f626172"},
+				{Version: "2.0.0", URL: "www.website.com/2.0.0", SHA256: "666f6f626172"},
+			},
+		},
+		Commit: "commit",
+	}
+
+	artifactDefinition := state.Definition[types.VM]{
+		Definition: types.VM{
+			ID:            "id",
+			Alias:         "alias",
+			InstallScript: "./path/to/install/script.sh",
+			BinaryPath:    "./path/to/binary",
+			URL:           "www.website.com",
+			SHA256:        "666f6f626172",
+			Artifacts: map[string]types.Artifact{
+				"linux/amd64": {
+					URL:    "www.website.com/linux-amd64",
+					SHA256: "666f6f626172",
+					Format: types.BinaryFormat,
+				},
+				"darwin/arm64": {
+					URL:    "www.website.com/darwin-arm64",
+					SHA256: "666f6f626172",
+					Format: types.BinaryFormat,
+				},
+			},
+		},
+		Commit: "commit",
+	}
+
 	installPath := filepath.Join("tmpPath", "organization", "repo")
 	workingDir := filepath.Join("tmpPath", "organization", "repo", "plugin")
 	tarPath := filepath.Join(installPath, "plugin.tar.gz")
+	signaturePath := tarPath + signature.Extension
+	binaryPath := filepath.Join("pluginPath", vm.ID)
 	errWrong := fmt.Errorf("something went wrong")
 
 	type mocks struct {
@@ -64,12 +117,22 @@ func TestInstallExecute(t *testing.T) {
 		repository  *state.MockRepository
 		installer   *MockInstaller
 		checksummer *checksum.MockChecksummer
+		verifier    *signature.MockVerifier
+		cache       *cache.Cache
 		fs          afero.Fs
 	}
 	tests := []struct {
-		name    string
-		setup   func(mocks)
-		wantErr assert.ErrorAssertionFunc
+		name       string
+		constraint string
+		platform   types.Platform
+		// refuse to install unsigned artifacts
+		requireSignatures bool
+		setup             func(mocks)
+		wantErr           assert.ErrorAssertionFunc
+		// version recorded in the installation registry on success
+		wantVersion string
+		// whether the artifact is cached afterwards
+		wantCached bool
 	}{
 		{
 			name: "download fails",
@@ -138,10 +201,53 @@ func TestInstallExecute(t *testing.T) {
 					return afero.WriteFile(mocks.fs, filepath.Join(workingDir, vm.BinaryPath), nil, perms.ReadWrite)
 				})
 				mocks.installer.EXPECT().Install(workingDir, vm.InstallScript).Return(nil)
+				mocks.checksummer.EXPECT().Checksum(binaryPath).Return(hash)
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.Nil(t, err)
+			},
+		},
+		{
+			name: "downloaded artifact is cached",
+			setup: func(mocks mocks) {
+				mocks.repository.EXPECT().GetVM("plugin").Return(cachedDefinition, nil)
+				mocks.installer.EXPECT().Download(vm.URL, tarPath).Do(func(string, string) error {
+					return afero.WriteFile(mocks.fs, tarPath, content, perms.ReadWrite)
+				})
+				mocks.checksummer.EXPECT().Checksum(tarPath).Return(archiveHash[:])
+				mocks.installer.EXPECT().Decompress(tarPath, workingDir).Do(func(string, string) error {
+					return afero.WriteFile(mocks.fs, filepath.Join(workingDir, vm.BinaryPath), nil, perms.ReadWrite)
+				})
+				mocks.installer.EXPECT().Install(workingDir, vm.InstallScript).Return(nil)
+				mocks.checksummer.EXPECT().Checksum(binaryPath).Return(hash)
 			},
 			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
 				return assert.Nil(t, err)
 			},
+			wantCached: true,
+		},
+		{
+			name: "cached artifact isn't downloaded",
+			setup: func(mocks mocks) {
+				require.NoError(t, afero.WriteFile(mocks.fs, "download", content, perms.ReadWrite))
+				_, err := mocks.cache.Add("download", vm.URL)
+				require.NoError(t, err)
+
+				mocks.repository.EXPECT().GetVM("plugin").Return(cachedDefinition, nil)
+				mocks.checksummer.EXPECT().Checksum(tarPath).Return(archiveHash[:])
+				mocks.installer.EXPECT().Decompress(tarPath, workingDir).Do(func(string, string) error {
+					b, err := afero.ReadFile(mocks.fs, tarPath)
+					require.NoError(t, err)
+					require.Equal(t, content, b)
+					return afero.WriteFile(mocks.fs, filepath.Join(workingDir, vm.BinaryPath), nil, perms.ReadWrite)
+				})
+				mocks.installer.EXPECT().Install(workingDir, vm.InstallScript).Return(nil)
+				mocks.checksummer.EXPECT().Checksum(binaryPath).Return(hash)
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.Nil(t, err)
+			},
+			wantCached: true,
 		},
 		{
 			name: "happy case no install script",
@@ -154,6 +260,128 @@ func TestInstallExecute(t *testing.T) {
 				mocks.installer.EXPECT().Decompress(tarPath, workingDir).Do(func(string, string) error {
 					return afero.WriteFile(mocks.fs, filepath.Join(workingDir, noInstallScriptVM.BinaryPath), nil, perms.ReadWrite)
 				})
+				mocks.checksummer.EXPECT().Checksum(binaryPath).Return(hash)
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.Nil(t, err)
+			},
+		},
+		{
+			name:       "constraint installs latest matching version",
+			constraint: "^1.0",
+			setup: func(mocks mocks) {
+				mocks.repository.EXPECT().GetVM("plugin").Return(versionedDefinition, nil)
+				mocks.installer.EXPECT().Download("www.website.com/1.1.0", tarPath).Do(func(string, string) error {
+					return afero.WriteFile(mocks.fs, tarPath, nil, perms.ReadWrite)
+				})
+				mocks.checksummer.EXPECT().Checksum(tarPath).Return(hash)
+				mocks.installer.EXPECT().Decompress(tarPath, workingDir).Do(func(string, string) error {
+					return afero.WriteFile(mocks.fs, filepath.Join(workingDir, vm.BinaryPath), nil, perms.ReadWrite)
+				})
+				mocks.installer.EXPECT().Install(workingDir, vm.InstallScript).Return(nil)
+				mocks.checksummer.EXPECT().Checksum(binaryPath).Return(hash)
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.Nil(t, err)
+			},
+			wantVersion: "1.1.0",
+		},
+		{
+			name:       "no version satisfies constraint",
+			constraint: "^3",
+			setup: func(mocks mocks) {
+				mocks.repository.EXPECT().GetVM("plugin").Return(versionedDefinition, nil)
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorContains(t, err, "Available versions: 1.0.0, 1.1.0, 2.0.0")
+			},
+		},
+		{
+			name:       "constraint on definition without versions",
+			constraint: "1.0.0",
+			setup: func(mocks mocks) {
+				mocks.repository.EXPECT().GetVM("plugin").Return(definition, nil)
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.Error(t, err)
+			},
+		},
+		{
+			name:     "installs the artifact for the platform",
+			platform: types.Platform{OS: "linux", Arch: "amd64"},
+			setup: func(mocks mocks) {
+				downloadPath := filepath.Join(installPath, "plugin.binary")
+				mocks.repository.EXPECT().GetVM("plugin").Return(artifactDefinition, nil)
+				mocks.installer.EXPECT().Download("www.website.com/linux-amd64", downloadPath).Do(func(string, string) error {
+					return afero.WriteFile(mocks.fs, downloadPath, nil, perms.ReadWrite)
+				})
+				mocks.checksummer.EXPECT().Checksum(downloadPath).Return(hash)
+				mocks.checksummer.EXPECT().Checksum(binaryPath).Return(hash)
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.Nil(t, err)
+			},
+		},
+		{
+			name:     "no artifact for the platform",
+			platform: types.Platform{OS: "windows", Arch: "amd64"},
+			setup: func(mocks mocks) {
+				mocks.repository.EXPECT().GetVM("plugin").Return(artifactDefinition, nil)
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorContains(t, err, "Available platforms: darwin/arm64, linux/amd64")
+			},
+		},
+		{
+			name:              "unsigned artifact refused",
+			requireSignatures: true,
+			setup: func(mocks mocks) {
+				mocks.repository.EXPECT().GetVM("plugin").Return(definition, nil)
+				mocks.installer.EXPECT().Download(vm.URL, tarPath).Do(func(string, string) error {
+					return afero.WriteFile(mocks.fs, tarPath, nil, perms.ReadWrite)
+				})
+				mocks.checksummer.EXPECT().Checksum(tarPath).Return(hash)
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorIs(t, err, errUnsigned)
+			},
+		},
+		{
+			name:              "invalid signature refused",
+			requireSignatures: true,
+			setup: func(mocks mocks) {
+				mocks.repository.EXPECT().GetVM("plugin").Return(signedDefinition, nil)
+				mocks.installer.EXPECT().Download(vm.URL, tarPath).Do(func(string, string) error {
+					return afero.WriteFile(mocks.fs, tarPath, nil, perms.ReadWrite)
+				})
+				mocks.checksummer.EXPECT().Checksum(tarPath).Return(hash)
+				mocks.installer.EXPECT().Download(signedDefinition.Definition.Signature, signaturePath).Do(func(string, string) error {
+					return afero.WriteFile(mocks.fs, signaturePath, []byte("signature"), perms.ReadWrite)
+				})
+				mocks.verifier.EXPECT().Verify(gomock.Any(), []byte("signature")).Return(signature.Key{}, signature.ErrInvalidSignature)
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorIs(t, err, signature.ErrInvalidSignature)
+			},
+		},
+		{
+			name:              "happy case signed artifact",
+			requireSignatures: true,
+			setup: func(mocks mocks) {
+				mocks.repository.EXPECT().GetVM("plugin").Return(signedDefinition, nil)
+				mocks.installer.EXPECT().Download(vm.URL, tarPath).Do(func(string, string) error {
+					return afero.WriteFile(mocks.fs, tarPath, nil, perms.ReadWrite)
+				})
+				mocks.checksummer.EXPECT().Checksum(tarPath).Return(hash)
+				mocks.installer.EXPECT().Download(signedDefinition.Definition.Signature, signaturePath).Do(func(string, string) error {
+					return afero.WriteFile(mocks.fs, signaturePath, []byte("signature"), perms.ReadWrite)
+				})
+				mocks.verifier.EXPECT().Verify(gomock.Any(), []byte("signature")).Return(signature.Key{Name: "lux"}, nil)
+				mocks.installer.EXPECT().Decompress(tarPath, workingDir).Do(func(string, string) error {
+					return afero.WriteFile(mocks.fs, filepath.Join(workingDir, vm.BinaryPath), nil, perms.ReadWrite)
+				})
+				mocks.installer.EXPECT().Install(workingDir, vm.InstallScript).Return(nil)
+				mocks.checksummer.EXPECT().Checksum(binaryPath).Return(hash)
 			},
 			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
 				return assert.Nil(t, err)
@@ -172,6 +400,8 @@ func TestInstallExecute(t *testing.T) {
 			fs := afero.NewMemMapFs()
 			checksummer := checksum.NewMockChecksummer(ctrl)
 			repository := state.NewMockRepository(ctrl)
+			verifier := signature.NewMockVerifier(ctrl)
+			artifacts := cache.New(cache.Config{Fs: fs, Dir: "cache"})
 
 			test.setup(mocks{
 				stateFile:   stateFile,
@@ -179,25 +409,104 @@ func TestInstallExecute(t *testing.T) {
 				installer:   installer,
 				fs:          fs,
 				checksummer: checksummer,
+				verifier:    verifier,
+				cache:       artifacts,
 			})
 
 			wf := NewInstall(
 				InstallConfig{
-					Name:         "name",
-					Plugin:       "plugin",
-					Organization: "organization",
-					Repo:         "repo",
-					TmpPath:      "tmpPath",
-					PluginPath:   "pluginPath",
-					StateFile:    stateFile,
-					Repository:   repository,
-					Fs:           fs,
-					Installer:    installer,
+					Name:          "name",
+					Plugin:        "plugin",
+					Organization:  "organization",
+					Repo:          "repo",
+					TmpPath:       "tmpPath",
+					PluginPath:    "pluginPath",
+					Constraint:    test.constraint,
+					Platform:      test.platform,
+					AllowUnsigned: !test.requireSignatures,
+					StateFile:     stateFile,
+					Repository:    repository,
+					Fs:            fs,
+					Installer:     installer,
+					Verifier:      verifier,
+					Cache:         artifacts,
 				},
 			)
 			wf.checksummer = checksummer
 
-			test.wantErr(t, wf.Execute())
+			err = wf.Execute()
+			test.wantErr(t, err)
+			if err == nil {
+				require.Equal(t, test.wantVersion, stateFile.InstallationRegistry["organization/repo:plugin"].Version)
+			}
+
+			cached, err := artifacts.Fetch(cachedDefinition.Definition.SHA256, "fetched")
+			require.NoError(t, err)
+			require.Equal(t, test.wantCached, cached)
 		})
 	}
 }
+
+func TestInstallPlan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	definition := state.Definition[types.VM]{
+		Definition: types.VM{
+			ID:            "id",
+			InstallScript: "./install.sh",
+			BinaryPath:    "./binary",
+			URL:           "www.website.com",
+			SHA256:        "666f6f626172",
+			Signature:     "www.website.com.minisig",
+		},
+		Commit: "commit",
+	}
+
+	stateFile, err := state.New("stateFilePath")
+	require.NoError(t, err)
+	installed := &state.InstallInfo{
+		ID:      "id",
+		Version: "v2",
+		Previous: []state.PreviousVersion{
+			{Version: "v1", Path: ".versions/id/v1"},
+		},
+	}
+	stateFile.InstallationRegistry["organization/repo:plugin"] = installed
+
+	repository := state.NewMockRepository(ctrl)
+	repository.EXPECT().GetVM("plugin").Return(definition, nil)
+
+	fs := afero.NewMemMapFs()
+	plan := &Plan{}
+	wf := NewInstall(InstallConfig{
+		Name:         "organization/repo:plugin",
+		Plugin:       "plugin",
+		Organization: "organization",
+		Repo:         "repo",
+		TmpPath:      "tmpPath",
+		PluginPath:   "pluginPath",
+		KeepVersions: 1,
+		StateFile:    stateFile,
+		Repository:   repository,
+		Fs:           fs,
+		Installer:    NewMockInstaller(ctrl),
+		Plan:         plan,
+	})
+	require.NoError(t, wf.Execute())
+
+	require.Equal(t, []Step{
+		{Action: DownloadAction, Target: "www.website.com", Detail: "sha256 666f6f626172"},
+		{Action: DownloadAction, Target: "www.website.com.minisig", Detail: "signature"},
+		{Action: RunAction, Target: "./install.sh", Detail: "install script"},
+		{Action: WriteAction, Target: filepath.Join("pluginPath", ".versions", "id", "v2"), Detail: "kept for rollbacks"},
+		{Action: RemoveAction, Target: filepath.Join("pluginPath", ".versions", "id", "v1"), Detail: "oldest kept version"},
+		{Action: WriteAction, Target: filepath.Join("pluginPath", "id")},
+		{Action: RegisterAction, Target: "organization/repo:plugin", Detail: "commit"},
+	}, plan.Steps())
+
+	// Nothing was changed.
+	require.Equal(t, "v2", installed.Version)
+	entries, err := afero.ReadDir(fs, "/")
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
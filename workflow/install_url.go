@@ -6,81 +6,171 @@ package workflow
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 
-	"github.com/luxfi/filesystem/perms"
 	"github.com/spf13/afero"
 
+	"github.com/luxfi/lpm/cache"
 	"github.com/luxfi/lpm/checksum"
+	"github.com/luxfi/lpm/signature"
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/url"
 )
 
 var _ Workflow = &InstallURL{}
 
 // InstallURLConfig configures a direct URL binary install.
 type InstallURLConfig struct {
-	URL       string
-	VMID      string
-	SHA256    string
+	URL    string
+	VMID   string
+	SHA256 string
+	// Signature is the URL of a detached minisign signature of the binary
+	Signature string
+	// Binary is the path of the binary inside of the archive at URL
+	Binary    string
 	PluginDir string
-	Fs        afero.Fs
+	// AllowUnsigned installs the binary if Signature isn't set
+	AllowUnsigned bool
+	StateFile     state.File
+	Fs            afero.Fs
+	Verifier      signature.Verifier
+	Client        url.Client
+	// Cache is checked for the binary before downloading it if SHA256 is set
+	Cache *cache.Cache
+	// Plan records what the install would do instead of doing it
+	Plan *Plan
 }
 
 // InstallURL downloads a pre-compiled binary from a direct URL.
 type InstallURL struct {
+	transactional
+
 	url       string
 	vmid      string
 	sha256    string
+	signature string
+	binary    string
 	pluginDir string
+	stateFile state.File
 	fs        afero.Fs
+	client    url.Client
+	cache     *cache.Cache
+	plan      *Plan
+
+	allowUnsigned bool
+	verifier      signature.Verifier
+	checksummer   checksum.Checksummer
 }
 
 // NewInstallURL creates a new URL install workflow.
 func NewInstallURL(config InstallURLConfig) *InstallURL {
 	return &InstallURL{
-		url:       config.URL,
-		vmid:      config.VMID,
-		sha256:    config.SHA256,
-		pluginDir: config.PluginDir,
-		fs:        config.Fs,
+		url:           config.URL,
+		vmid:          config.VMID,
+		sha256:        config.SHA256,
+		signature:     config.Signature,
+		binary:        config.Binary,
+		pluginDir:     config.PluginDir,
+		stateFile:     config.StateFile,
+		fs:            config.Fs,
+		client:        config.Client,
+		cache:         config.Cache,
+		plan:          config.Plan,
+		allowUnsigned: config.AllowUnsigned,
+		verifier:      config.Verifier,
+		checksummer:   checksum.NewSHA256(config.Fs),
 	}
 }
 
 // Execute runs the URL install workflow.
 func (u *InstallURL) Execute() error {
-	fmt.Printf("Downloading plugin from %s...\n", u.url)
+	if u.plan != nil {
+		detail := ""
+		if u.sha256 != "" {
+			detail = fmt.Sprintf("sha256 %s", u.sha256)
+		}
+		u.plan.Download(u.url, detail)
+		if u.signature != "" {
+			u.plan.Download(u.signature, "signature")
+		}
+		planBinary(u.plan, state.Origin{Type: state.URLOrigin}, u.pluginDir, u.vmid, "")
+		return nil
+	}
 
-	// Download to temp file
 	tmpFile := filepath.Join(os.TempDir(), fmt.Sprintf("lpm-url-%s", u.vmid))
-	if err := downloadFile(u.url, tmpFile); err != nil {
-		return fmt.Errorf("download failed: %w", err)
+	// Clean up the download even if it fails part way.
+	defer func() {
+		_ = u.fs.Remove(tmpFile)
+	}()
+
+	// Download to temp file, unless it's already cached
+	cached := fetchCached(u.cache, u.sha256, tmpFile)
+	if !cached {
+		fmt.Printf("Downloading plugin from %s...\n", u.url)
+		if err := u.client.Download(u.url, tmpFile); err != nil {
+			return err
+		}
 	}
-	defer os.Remove(tmpFile)
 
 	// Verify checksum if provided
 	if u.sha256 != "" {
 		fmt.Printf("Verifying checksum...\n")
-		checksummer := checksum.NewSHA256(u.fs)
-		hash := fmt.Sprintf("%x", checksummer.Checksum(tmpFile))
+		hash := fmt.Sprintf("%x", u.checksummer.Checksum(tmpFile))
 		if hash != u.sha256 {
 			return fmt.Errorf("checksum mismatch: expected %s, got %s", u.sha256, hash)
 		}
 		fmt.Printf("Checksum verified: %s\n", hash)
+
+		if !cached {
+			addToCache(u.cache, tmpFile, u.url)
+		}
+	}
+
+	signer := ""
+	if u.signature == "" {
+		if err := allowUnsigned(u.url, u.allowUnsigned); err != nil {
+			return err
+		}
+	} else {
+		sigFile := tmpFile + signature.Extension
+		defer func() {
+			_ = u.fs.Remove(sigFile)
+		}()
+		if err := u.client.Download(u.signature, sigFile); err != nil {
+			return fmt.Errorf("signature download failed: %w", err)
+		}
+
+		var err error
+		signer, err = verifySignature(u.fs, u.verifier, u.url, tmpFile, sigFile)
+		if err != nil {
+			return err
+		}
 	}
 
-	// Ensure plugin directory exists
-	if err := u.fs.MkdirAll(u.pluginDir, perms.ReadWriteExecute); err != nil {
-		return fmt.Errorf("failed to create plugin directory: %w", err)
+	unpacked, err := unpackRelease(u.fs, path.Base(u.url), tmpFile, u.binary, u.vmid)
+	if err != nil {
+		return err
 	}
+	defer unpacked.cleanup()
 
-	// Move binary to plugin directory
 	destPath := filepath.Join(u.pluginDir, u.vmid)
-	if err := copyFile(tmpFile, destPath); err != nil {
-		return fmt.Errorf("failed to install binary: %w", err)
+	if err := installBinary(u.transaction(), u.fs, unpacked.binaryPath, destPath); err != nil {
+		return err
 	}
 
-	// Make executable
-	if err := os.Chmod(destPath, 0o755); err != nil {
-		return fmt.Errorf("failed to set permissions: %w", err)
+	if _, err := register(u.stateFile, u.checksummer, &state.InstallInfo{
+		ID:     u.vmid,
+		Signer: signer,
+		Origin: state.Origin{
+			Type:      state.URLOrigin,
+			URL:       u.url,
+			SHA256:    u.sha256,
+			Signature: u.signature,
+			Binary:    unpacked.binary,
+		},
+	}, destPath); err != nil {
+		return err
 	}
 
 	fmt.Printf("Installed plugin\n")
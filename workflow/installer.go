@@ -4,14 +4,20 @@
 package workflow
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 
 	"github.com/spf13/afero"
 
+	"github.com/luxfi/lpm/archive"
 	"github.com/luxfi/lpm/url"
 )
 
+// DefaultStripComponents is the number of leading path elements stripped from
+// VM archives, which conventionally wrap their sources in a single directory.
+const DefaultStripComponents = 1
+
 type Installer interface {
 	Download(url string, path string) error
 	Decompress(source string, dest string) error
@@ -25,23 +31,51 @@ var _ Installer = &VMInstaller{}
 type VMInstallerConfig struct {
 	Fs        afero.Fs
 	URLClient url.Client
+	// StripComponents is the number of leading path elements stripped from
+	// archive entries
+	StripComponents int
+	// MaxSize limits how many bytes are extracted from an archive. Defaults
+	// to archive.DefaultMaxSize.
+	MaxSize int64
 }
 
 func NewVMInstaller(config VMInstallerConfig) *VMInstaller {
+	maxSize := config.MaxSize
+	if maxSize == 0 {
+		maxSize = archive.DefaultMaxSize
+	}
+
 	return &VMInstaller{
-		fs:     config.Fs,
-		Client: config.URLClient,
+		fs:              config.Fs,
+		Client:          config.URLClient,
+		stripComponents: config.StripComponents,
+		maxSize:         maxSize,
 	}
 }
 
 type VMInstaller struct {
 	fs afero.Fs
 	url.Client
+
+	stripComponents int
+	maxSize         int64
 }
 
+// Decompress extracts the archive at source into dest. The archive's format
+// is detected from its name or contents.
 func (t VMInstaller) Decompress(source string, dest string) error {
-	cmd := exec.Command("tar", "xf", source, "-C", dest, "--strip-components", "1")
-	return cmd.Run()
+	format, ok, err := archive.Detect(t.fs, source)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", archive.ErrUnknownFormat, source)
+	}
+
+	return archive.Extract(t.fs, format, source, dest, archive.Options{
+		StripComponents: t.stripComponents,
+		MaxSize:         t.maxSize,
+	})
 }
 
 func (t VMInstaller) Install(workingDir string, args ...string) error {
@@ -4,13 +4,21 @@
 package workflow
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/luxfi/filesystem/perms"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/luxfi/lpm/archive"
 	"github.com/luxfi/lpm/url"
 )
 
@@ -78,3 +86,111 @@ func TestTmpInstaller_Download(t *testing.T) {
 		})
 	}
 }
+
+func TestVMInstaller_Decompress(t *testing.T) {
+	type entry struct {
+		name string
+		body string
+	}
+
+	tests := []struct {
+		name            string
+		entries         []entry
+		stripComponents int
+		maxSize         int64
+		wantFiles       map[string]string
+		wantErr         error
+	}{
+		{
+			name: "strips the top level directory",
+			entries: []entry{
+				{name: "spacesvm-v1.0.0/"},
+				{name: "spacesvm-v1.0.0/scripts/build.sh", body: "build"},
+				{name: "spacesvm-v1.0.0/main.go", body: "package main"},
+			},
+			stripComponents: 1,
+			wantFiles: map[string]string{
+				"scripts/build.sh": "build",
+				"main.go":          "package main",
+			},
+		},
+		{
+			name: "strips nothing",
+			entries: []entry{
+				{name: "spacesvm-v1.0.0/main.go", body: "package main"},
+			},
+			wantFiles: map[string]string{
+				"spacesvm-v1.0.0/main.go": "package main",
+			},
+		},
+		{
+			name: "parent directory traversal",
+			entries: []entry{
+				{name: "spacesvm-v1.0.0/../../evil", body: "evil"},
+			},
+			stripComponents: 1,
+			wantErr:         archive.ErrUnsafePath,
+		},
+		{
+			name: "absolute path",
+			entries: []entry{
+				{name: "/etc/evil", body: "evil"},
+			},
+			stripComponents: 1,
+			wantErr:         archive.ErrUnsafePath,
+		},
+		{
+			name: "too large",
+			entries: []entry{
+				{name: "spacesvm-v1.0.0/main.go", body: "package main"},
+				{name: "spacesvm-v1.0.0/big", body: strings.Repeat("0", 64)},
+			},
+			stripComponents: 1,
+			maxSize:         32,
+			wantErr:         archive.ErrTooLarge,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			gz := gzip.NewWriter(buf)
+			w := tar.NewWriter(gz)
+			for _, e := range test.entries {
+				header := &tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.body)), Typeflag: tar.TypeReg}
+				if strings.HasSuffix(e.name, "/") {
+					header.Typeflag = tar.TypeDir
+					header.Mode = 0o755
+				}
+				require.NoError(t, w.WriteHeader(header))
+				_, err := w.Write([]byte(e.body))
+				require.NoError(t, err)
+			}
+			require.NoError(t, w.Close())
+			require.NoError(t, gz.Close())
+
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "tmp/spacesvm.tar.gz", buf.Bytes(), perms.ReadWrite))
+			require.NoError(t, fs.MkdirAll("tmp/spacesvm", perms.ReadWriteExecute))
+
+			installer := NewVMInstaller(VMInstallerConfig{
+				Fs:              fs,
+				StripComponents: test.stripComponents,
+				MaxSize:         test.maxSize,
+			})
+
+			err := installer.Decompress("tmp/spacesvm.tar.gz", "tmp/spacesvm")
+			require.ErrorIs(t, err, test.wantErr)
+
+			for name, body := range test.wantFiles {
+				b, err := afero.ReadFile(fs, filepath.Join("tmp/spacesvm", name))
+				require.NoError(t, err)
+				require.Equal(t, body, string(b))
+			}
+
+			exists, err := afero.Exists(fs, "evil")
+			require.NoError(t, err)
+			require.False(t, exists)
+		})
+	}
+}
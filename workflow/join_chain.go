@@ -0,0 +1,221 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/luxfi/lpm/cache"
+	"github.com/luxfi/lpm/constant"
+	"github.com/luxfi/lpm/node"
+	"github.com/luxfi/lpm/signature"
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/types"
+	"github.com/luxfi/lpm/util"
+)
+
+var _ Workflow = &JoinChain{}
+
+// JoinChainConfig configures joining a chain.
+type JoinChainConfig struct {
+	Executor Executor
+	// Name is the fully qualified name of the chain's definition
+	Name       string
+	Network    string
+	ChainID    string
+	Definition state.Definition[types.Chain]
+
+	RepoFactory   state.RepositoryFactory
+	TmpPath       string
+	PluginPath    string
+	Platform      types.Platform
+	AllowUnsigned bool
+	KeepVersions  int
+	Installer     Installer
+	Verifier      signature.Verifier
+	Cache         *cache.Cache
+
+	// NodeConfig is the node config the chain is tracked in and its configs
+	// are written next to. It's required unless NoTrack is set.
+	NodeConfig *node.ConfigFile
+	// NoTrack joins the chain without adding it to the node's tracked chains
+	NoTrack   bool
+	StateFile state.File
+	Fs        afero.Fs
+	// Plan records what joining the chain would do instead of doing it
+	Plan *Plan
+}
+
+// NewJoinChain creates a new workflow that joins a chain.
+func NewJoinChain(config JoinChainConfig) *JoinChain {
+	return &JoinChain{
+		executor:      config.Executor,
+		name:          config.Name,
+		network:       config.Network,
+		chainID:       config.ChainID,
+		definition:    config.Definition,
+		repoFactory:   config.RepoFactory,
+		tmpPath:       config.TmpPath,
+		pluginPath:    config.PluginPath,
+		platform:      config.Platform,
+		allowUnsigned: config.AllowUnsigned,
+		keepVersions:  config.KeepVersions,
+		installer:     config.Installer,
+		verifier:      config.Verifier,
+		cache:         config.Cache,
+		nodeConfig:    config.NodeConfig,
+		noTrack:       config.NoTrack,
+		stateFile:     config.StateFile,
+		fs:            config.Fs,
+		plan:          config.Plan,
+	}
+}
+
+// JoinChain installs the VMs a chain needs, adds the chain to the node's
+// tracked chains and writes its configs. Every step is part of this
+// workflow's transaction, so a failure leaves nothing installed or tracked
+// for the chain.
+type JoinChain struct {
+	transactional
+
+	executor   Executor
+	name       string
+	network    string
+	chainID    string
+	definition state.Definition[types.Chain]
+
+	repoFactory   state.RepositoryFactory
+	tmpPath       string
+	pluginPath    string
+	platform      types.Platform
+	allowUnsigned bool
+	keepVersions  int
+	installer     Installer
+	verifier      signature.Verifier
+	cache         *cache.Cache
+
+	nodeConfig *node.ConfigFile
+	noTrack    bool
+	stateFile  state.File
+	fs         afero.Fs
+	plan       *Plan
+}
+
+func (j *JoinChain) Execute() error {
+	// The node never validates a chain it doesn't track, so don't install
+	// anything for a chain that can't be tracked.
+	if j.nodeConfig == nil && !j.noTrack {
+		return fmt.Errorf("no node config file to track chain %s in. Pass --node-config with the node's config file, "+
+			"or --no-track to join the chain without tracking it", j.chainID)
+	}
+
+	repoAlias, _ := util.ParseQualifiedName(j.name)
+
+	// TODO prompt user, add force flag
+	fmt.Printf("Installing virtual machines for chain %s.\n", j.chainID)
+	vms := make([]string, 0, len(j.definition.Definition.VMs))
+	for _, vm := range j.definition.Definition.VMs {
+		name, constraint := util.ParseVersionedName(strings.Join([]string{repoAlias, vm}, constant.QualifiedNameDelimiter))
+		if err := j.install(name, constraint); err != nil {
+			return err
+		}
+		vms = append(vms, name)
+	}
+
+	if err := j.trackChain(); err != nil {
+		return err
+	}
+
+	wf := NewApplyChainConfig(ApplyChainConfigConfig{
+		Name:       j.name,
+		Network:    j.network,
+		ChainID:    j.chainID,
+		Definition: j.definition,
+		VMs:        vms,
+		NodeConfig: j.nodeConfig,
+		StateFile:  j.stateFile,
+		Plan:       j.plan,
+	})
+	if err := j.executor.Execute(wf); err != nil {
+		return err
+	}
+
+	fmt.Printf("Finished installing virtual machines for chain %s.\n", j.chainID)
+	return nil
+}
+
+// install installs the VM name as a dependency of the chain, unless it's
+// already installed.
+func (j *JoinChain) install(name string, constraint string) error {
+	if installInfo, ok := j.stateFile.InstallationRegistry[name]; ok {
+		if constraint != "" && constraint != installInfo.Constraint {
+			fmt.Printf("VM %s is already installed. Use upgrade --vm %s%s%s to change its version. Skipping.\n",
+				name, name, constant.VersionDelimiter, constraint)
+			return nil
+		}
+		fmt.Printf("VM %s is already installed. Skipping.\n", name)
+		return nil
+	}
+
+	repoAlias, plugin := util.ParseQualifiedName(name)
+	organization, repo := util.ParseAlias(repoAlias)
+	repository, err := j.repoFactory.GetRepository(repoAlias)
+	if err != nil {
+		return err
+	}
+
+	return j.executor.Execute(NewInstall(InstallConfig{
+		Name:          name,
+		Plugin:        plugin,
+		Organization:  organization,
+		Repo:          repo,
+		TmpPath:       j.tmpPath,
+		PluginPath:    j.pluginPath,
+		Constraint:    constraint,
+		Platform:      j.platform,
+		AllowUnsigned: j.allowUnsigned,
+		KeepVersions:  j.keepVersions,
+		Dependency:    true,
+		StateFile:     j.stateFile,
+		Repository:    repository,
+		Fs:            j.fs,
+		Installer:     j.installer,
+		Verifier:      j.verifier,
+		Cache:         j.cache,
+		Plan:          j.plan,
+	}))
+}
+
+// trackChain adds the chain to the chains the node's config file tracks. The
+// node's admin API can't track chains, so the node tracks it once it's
+// restarted.
+func (j *JoinChain) trackChain() error {
+	if j.noTrack {
+		fmt.Printf("Not tracking chain %s. Add it to %s in the node's config for the node to validate it.\n", j.chainID, node.TrackChainsKey)
+		return nil
+	}
+	if j.plan != nil {
+		j.plan.Write(j.nodeConfig.Path(), fmt.Sprintf("adds %s to %s", j.chainID, node.TrackChainsKey))
+		return nil
+	}
+
+	fmt.Printf("Tracking chain %s...\n", j.chainID)
+	if err := j.transaction().Track(j.nodeConfig.Path()); err != nil {
+		return err
+	}
+	added, err := j.nodeConfig.TrackChain(j.chainID)
+	if err != nil {
+		return fmt.Errorf("failed to track chain %s in %s: %w", j.chainID, j.nodeConfig.Path(), err)
+	}
+	if !added {
+		fmt.Printf("Chain %s is already in %s in %s.\n", j.chainID, node.TrackChainsKey, j.nodeConfig.Path())
+		return nil
+	}
+
+	fmt.Printf("Added chain %s to %s in %s. Restart the node to start tracking it.\n", j.chainID, node.TrackChainsKey, j.nodeConfig.Path())
+	return nil
+}
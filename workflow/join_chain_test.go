@@ -0,0 +1,124 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/lpm/node"
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/types"
+)
+
+func TestJoinChainExecute(t *testing.T) {
+	const (
+		repoAlias = "organization/repository"
+		chain     = repoAlias + ":chain"
+		vm        = repoAlias + ":vm"
+		chainID   = "chainID"
+		nodePath  = "node/node.json"
+	)
+
+	tests := []struct {
+		name        string
+		installed   bool
+		noNode      bool
+		noTrack     bool
+		wantInstall bool
+		wantChains  []string
+		wantErr     string
+	}{
+		{
+			name:        "installs missing VMs as dependencies",
+			wantInstall: true,
+			wantChains:  []string{chainID},
+		},
+		{
+			name:       "skips installed VMs",
+			installed:  true,
+			wantChains: []string{chainID},
+		},
+		{
+			name:    "without node config",
+			noNode:  true,
+			wantErr: "no node config file to track chain chainID in",
+		},
+		{
+			name:        "without node config or tracking",
+			noNode:      true,
+			noTrack:     true,
+			wantInstall: true,
+		},
+		{
+			name:        "without tracking",
+			noTrack:     true,
+			wantInstall: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			fs := afero.NewMemMapFs()
+
+			stateFile, err := state.New(t.TempDir())
+			require.NoError(t, err)
+			if test.installed {
+				stateFile.InstallationRegistry[vm] = &state.InstallInfo{ID: "vmID"}
+			}
+
+			repoFactory := state.NewMockRepositoryFactory(ctrl)
+			executor := NewMockExecutor(ctrl)
+			if test.wantInstall {
+				repoFactory.EXPECT().GetRepository(repoAlias).Return(state.NewMockRepository(ctrl), nil)
+				executor.EXPECT().Execute(gomock.AssignableToTypeOf(&Install{})).DoAndReturn(func(wf Workflow) error {
+					install := wf.(*Install)
+					require.Equal(t, vm, install.name)
+					require.True(t, install.dependency)
+					return nil
+				})
+			}
+			if test.wantErr == "" {
+				executor.EXPECT().Execute(gomock.AssignableToTypeOf(&ApplyChainConfig{})).DoAndReturn(func(wf Workflow) error {
+					return wf.Execute()
+				})
+			}
+
+			var nodeConfig *node.ConfigFile
+			if !test.noNode {
+				nodeConfig = node.NewConfigFile(fs, nodePath)
+			}
+
+			err = NewJoinChain(JoinChainConfig{
+				Executor: executor,
+				Name:     chain,
+				Network:  "testnet",
+				ChainID:  chainID,
+				Definition: state.Definition[types.Chain]{
+					Definition: types.Chain{VMs: []string{"vm"}},
+				},
+				RepoFactory: repoFactory,
+				NodeConfig:  nodeConfig,
+				NoTrack:     test.noTrack,
+				StateFile:   stateFile,
+				Fs:          fs,
+			}).Execute()
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, []string{vm}, stateFile.ChainRegistry[chain].VMs)
+
+			chains, err := node.NewConfigFile(fs, nodePath).TrackedChains()
+			require.NoError(t, err)
+			require.Equal(t, test.wantChains, chains)
+		})
+	}
+}
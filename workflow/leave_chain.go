@@ -0,0 +1,184 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/luxfi/lpm/node"
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/util"
+)
+
+var _ Workflow = &LeaveChain{}
+
+// LeaveChainConfig configures leaving a joined chain.
+type LeaveChainConfig struct {
+	Executor Executor
+	// Name is the fully qualified name of the chain's definition
+	Name string
+	// NodeConfig is the node config the chain is untracked in and the chain
+	// config directory is read from. Neither is changed without one.
+	NodeConfig *node.ConfigFile
+	StateFile  state.File
+	Fs         afero.Fs
+	PluginPath string
+	// Plan records what leaving the chain would do instead of doing it
+	Plan *Plan
+}
+
+// NewLeaveChain creates a new workflow that leaves a joined chain.
+func NewLeaveChain(config LeaveChainConfig) *LeaveChain {
+	return &LeaveChain{
+		executor:   config.Executor,
+		name:       config.Name,
+		nodeConfig: config.NodeConfig,
+		stateFile:  config.StateFile,
+		fs:         config.Fs,
+		pluginPath: config.PluginPath,
+		plan:       config.Plan,
+	}
+}
+
+// LeaveChain removes a joined chain from the node's tracked chains, removes
+// the configs written for it and uninstalls the VMs that were installed for
+// it, unless another joined chain still needs them. Every step is part of this
+// workflow's transaction, so a failure leaves the chain joined.
+type LeaveChain struct {
+	transactional
+
+	executor   Executor
+	name       string
+	nodeConfig *node.ConfigFile
+	stateFile  state.File
+	fs         afero.Fs
+	pluginPath string
+	plan       *Plan
+}
+
+func (l *LeaveChain) Execute() error {
+	info, ok := l.stateFile.ChainRegistry[l.name]
+	if !ok {
+		return fmt.Errorf("chain %s isn't joined", l.name)
+	}
+
+	if err := l.untrackChain(info.ID); err != nil {
+		return err
+	}
+
+	if err := l.removeConfigs(info); err != nil {
+		return err
+	}
+
+	for _, vm := range info.VMs {
+		installed, ok := l.stateFile.InstallationRegistry[vm]
+		if !ok {
+			continue
+		}
+
+		// The VM is released before it's uninstalled, so the uninstall doesn't
+		// refuse it for the chain being left.
+		remaining := releaseVM(l.stateFile, vm, l.name)
+		switch {
+		case len(remaining) > 0:
+			fmt.Printf("Keeping %s, which is still needed by %s.\n", vm, strings.Join(remaining, ", "))
+		case !installed.Dependency:
+			fmt.Printf("Keeping %s, which was installed explicitly.\n", vm)
+		default:
+			repoAlias, plugin := util.ParseQualifiedName(vm)
+			wf := NewUninstall(UninstallConfig{
+				Name:       vm,
+				Plugin:     plugin,
+				RepoAlias:  repoAlias,
+				StateFile:  l.stateFile,
+				Fs:         l.fs,
+				PluginPath: l.pluginPath,
+				Plan:       l.plan,
+			})
+			if err := l.executor.Execute(wf); err != nil {
+				return err
+			}
+		}
+	}
+
+	if l.plan != nil {
+		l.plan.Unregister(l.name)
+		return nil
+	}
+
+	delete(l.stateFile.ChainRegistry, l.name)
+	fmt.Printf("Left chain %s.\n", l.name)
+	return nil
+}
+
+// untrackChain removes chainID from the chains the node's config file
+// tracks. The node keeps tracking it until it's restarted.
+func (l *LeaveChain) untrackChain(chainID string) error {
+	if l.nodeConfig == nil {
+		fmt.Printf("Warning - chain %s is still tracked. Pass --node-config to remove it from the node's config file, "+
+			"or remove it from %s in the node's config yourself.\n", chainID, node.TrackChainsKey)
+		return nil
+	}
+	if l.plan != nil {
+		l.plan.Write(l.nodeConfig.Path(), fmt.Sprintf("removes %s from %s", chainID, node.TrackChainsKey))
+		return nil
+	}
+
+	fmt.Printf("Untracking chain %s...\n", chainID)
+	if err := l.transaction().Track(l.nodeConfig.Path()); err != nil {
+		return err
+	}
+	removed, err := l.nodeConfig.UntrackChain(chainID)
+	if err != nil {
+		return fmt.Errorf("failed to untrack chain %s in %s: %w", chainID, l.nodeConfig.Path(), err)
+	}
+	if !removed {
+		fmt.Printf("Chain %s isn't in %s in %s.\n", chainID, node.TrackChainsKey, l.nodeConfig.Path())
+		return nil
+	}
+
+	fmt.Printf("Removed chain %s from %s in %s. Restart the node to stop tracking it.\n", chainID, node.TrackChainsKey, l.nodeConfig.Path())
+	return nil
+}
+
+// removeConfigs removes the files written to the node's chain config
+// directory when the chain was joined.
+func (l *LeaveChain) removeConfigs(info *state.ChainInfo) error {
+	if len(info.Configs) == 0 {
+		return nil
+	}
+	if l.nodeConfig == nil {
+		fmt.Printf("Warning - the configs of chain %s weren't removed. Pass --node-config to remove them from the node's chain config directory.\n", l.name)
+		return nil
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(info.Configs)) {
+		path, err := l.nodeConfig.ChainFilePath(info.ID, name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", l.nodeConfig.Path(), err)
+		}
+		if l.plan != nil {
+			l.plan.Remove(path, fmt.Sprintf("config of chain %s", l.name))
+			continue
+		}
+
+		if err := l.transaction().Track(path); err != nil {
+			return err
+		}
+		removed, err := l.nodeConfig.RemoveChainFile(info.ID, name)
+		if err != nil {
+			return fmt.Errorf("failed to remove %s of chain %s: %w", name, l.name, err)
+		}
+		if removed {
+			fmt.Printf("Removed %s of chain %s.\n", name, l.name)
+		}
+	}
+
+	return nil
+}
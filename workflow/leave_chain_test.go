@@ -0,0 +1,133 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/lpm/node"
+	"github.com/luxfi/lpm/state"
+)
+
+func TestLeaveChainExecute(t *testing.T) {
+	const (
+		chain    = "organization/repository:chain"
+		other    = "organization/repository:other"
+		vm       = "organization/repository:vm"
+		chainID  = "chainID"
+		vmID     = "vmID"
+		plugins  = "plugins"
+		nodePath = "node/node.json"
+	)
+	configPath := filepath.Join("node", "chains", chainID, node.ChainConfigFile)
+
+	tests := []struct {
+		name          string
+		joined        bool
+		installed     *state.InstallInfo
+		wantUninstall bool
+		wantChains    []string
+		wantErr       string
+	}{
+		{
+			name:    "not joined",
+			wantErr: "isn't joined",
+		},
+		{
+			name:   "uninstalls dependency",
+			joined: true,
+			installed: &state.InstallInfo{
+				ID:         vmID,
+				Chains:     []string{chain},
+				Dependency: true,
+			},
+			wantUninstall: true,
+		},
+		{
+			name:   "keeps dependency another chain needs",
+			joined: true,
+			installed: &state.InstallInfo{
+				ID:         vmID,
+				Chains:     []string{chain, other},
+				Dependency: true,
+			},
+			wantChains: []string{other},
+		},
+		{
+			name:   "keeps explicit install",
+			joined: true,
+			installed: &state.InstallInfo{
+				ID:     vmID,
+				Chains: []string{chain},
+			},
+			wantChains: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, configPath, []byte("{}"), 0o600))
+			require.NoError(t, afero.WriteFile(fs, filepath.Join(plugins, vmID), []byte("binary"), 0o700))
+
+			stateFile, err := state.New(t.TempDir())
+			require.NoError(t, err)
+			if test.joined {
+				stateFile.ChainRegistry[chain] = &state.ChainInfo{
+					ID:      chainID,
+					Configs: map[string]string{node.ChainConfigFile: "sha256"},
+					VMs:     []string{vm},
+				}
+			}
+			if test.installed != nil {
+				stateFile.InstallationRegistry[vm] = test.installed
+			}
+
+			executor := NewMockExecutor(ctrl)
+			if test.wantUninstall {
+				executor.EXPECT().Execute(gomock.AssignableToTypeOf(&Uninstall{})).DoAndReturn(func(wf Workflow) error {
+					return wf.Execute()
+				})
+			}
+
+			err = NewLeaveChain(LeaveChainConfig{
+				Executor:   executor,
+				Name:       chain,
+				NodeConfig: node.NewConfigFile(fs, nodePath),
+				StateFile:  stateFile,
+				Fs:         fs,
+				PluginPath: plugins,
+			}).Execute()
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NotContains(t, stateFile.ChainRegistry, chain)
+
+			// The chain's config is removed, and backed up.
+			exists, err := afero.Exists(fs, configPath)
+			require.NoError(t, err)
+			require.False(t, exists)
+			exists, err = afero.Exists(fs, configPath+".bak")
+			require.NoError(t, err)
+			require.True(t, exists)
+
+			exists, err = afero.Exists(fs, filepath.Join(plugins, vmID))
+			require.NoError(t, err)
+			require.Equal(t, !test.wantUninstall, exists)
+			if test.wantUninstall {
+				require.NotContains(t, stateFile.InstallationRegistry, vm)
+				return
+			}
+			require.Equal(t, test.wantChains, stateFile.InstallationRegistry[vm].Chains)
+		})
+	}
+}
@@ -11,6 +11,9 @@ import (
 	"github.com/luxfi/filesystem/perms"
 	"github.com/luxfi/ids"
 	"github.com/spf13/afero"
+
+	"github.com/luxfi/lpm/checksum"
+	"github.com/luxfi/lpm/state"
 )
 
 var _ Workflow = &Link{}
@@ -22,28 +25,36 @@ type LinkConfig struct {
 	Version    string
 	BinaryPath string
 	PluginDir  string
+	StateFile  state.File
 	Fs         afero.Fs
 }
 
 // Link creates a development symlink for a local VM binary
 type Link struct {
+	transactional
+
 	org        string
 	name       string
 	version    string
 	binaryPath string
 	pluginDir  string
+	stateFile  state.File
 	fs         afero.Fs
+
+	checksummer checksum.Checksummer
 }
 
 // NewLink creates a new Link workflow
 func NewLink(config LinkConfig) *Link {
 	return &Link{
-		org:        config.Org,
-		name:       config.Name,
-		version:    config.Version,
-		binaryPath: config.BinaryPath,
-		pluginDir:  config.PluginDir,
-		fs:         config.Fs,
+		org:         config.Org,
+		name:        config.Name,
+		version:     config.Version,
+		binaryPath:  config.BinaryPath,
+		pluginDir:   config.PluginDir,
+		stateFile:   config.StateFile,
+		fs:          config.Fs,
+		checksummer: checksum.NewSHA256(config.Fs),
 	}
 }
 
@@ -79,7 +90,7 @@ func (l *Link) Execute() error {
 	}
 
 	// Ensure plugins/current directory exists
-	currentDir := filepath.Join(l.pluginDir, "current")
+	currentDir := filepath.Join(l.pluginDir, state.LinkDir)
 	if err := l.fs.MkdirAll(currentDir, perms.ReadWriteExecute); err != nil {
 		return fmt.Errorf("failed to create plugins/current directory: %w", err)
 	}
@@ -87,11 +98,13 @@ func (l *Link) Execute() error {
 	// Create VMID symlink in plugins/current (node compatibility)
 	vmidPath := filepath.Join(currentDir, vmID.String())
 
+	if err := l.transaction().Track(vmidPath); err != nil {
+		return err
+	}
+
 	// Remove existing symlink if present
-	if _, err := l.fs.Stat(vmidPath); err == nil {
-		if err := l.fs.Remove(vmidPath); err != nil {
-			return fmt.Errorf("failed to remove existing symlink: %w", err)
-		}
+	if err := removeIfExists(l.fs, vmidPath); err != nil {
+		return fmt.Errorf("failed to remove existing symlink: %w", err)
 	}
 
 	// Create symlink using OS (afero doesn't support symlinks well)
@@ -99,6 +112,19 @@ func (l *Link) Execute() error {
 		return fmt.Errorf("failed to create symlink: %w", err)
 	}
 
+	if _, err := register(l.stateFile, l.checksummer, &state.InstallInfo{
+		ID:      vmID.String(),
+		Version: l.version,
+		Origin: state.Origin{
+			Type:  state.LinkOrigin,
+			Owner: l.org,
+			Repo:  l.name,
+			Path:  l.binaryPath,
+		},
+	}, vmidPath); err != nil {
+		return err
+	}
+
 	fmt.Printf("Plugin linked successfully:\n")
 	fmt.Printf("  Package:  %s/%s@%s\n", l.org, l.name, l.version)
 	fmt.Printf("  VM Name:  %s\n", vmName)
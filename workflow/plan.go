@@ -0,0 +1,95 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"path/filepath"
+
+	"github.com/luxfi/lpm/state"
+)
+
+// Actions a Step can take.
+const (
+	PullAction       = "pull"
+	DownloadAction   = "download"
+	RunAction        = "run"
+	WriteAction      = "write"
+	RemoveAction     = "remove"
+	RegisterAction   = "register"
+	UnregisterAction = "unregister"
+	CallAction       = "call"
+)
+
+// Step is something a workflow would do.
+type Step struct {
+	Action string `yaml:"action" json:"action"`
+	Target string `yaml:"target" json:"target"`
+	Detail string `yaml:"detail,omitempty" json:"detail,omitempty"`
+}
+
+// Plan records what workflows would do in a dry run. Workflows given a Plan
+// add their steps to it instead of taking them.
+type Plan struct {
+	steps []Step
+}
+
+// Pull records that the definitions of a repository would be fetched.
+func (p *Plan) Pull(alias string, url string) {
+	p.add(PullAction, alias, url)
+}
+
+// Download records that url would be downloaded.
+func (p *Plan) Download(url string, detail string) {
+	p.add(DownloadAction, url, detail)
+}
+
+// Run records that a command would be run.
+func (p *Plan) Run(command string, detail string) {
+	p.add(RunAction, command, detail)
+}
+
+// Write records that the file at path would be written.
+func (p *Plan) Write(path string, detail string) {
+	p.add(WriteAction, path, detail)
+}
+
+// Remove records that the file at path would be removed.
+func (p *Plan) Remove(path string, detail string) {
+	p.add(RemoveAction, path, detail)
+}
+
+// Register records that a VM or chain would be added to its registry.
+func (p *Plan) Register(name string, detail string) {
+	p.add(RegisterAction, name, detail)
+}
+
+// Unregister records that a VM or chain would be removed from its registry.
+func (p *Plan) Unregister(name string) {
+	p.add(UnregisterAction, name, "")
+}
+
+// Call records that a node API method would be called.
+func (p *Plan) Call(method string, detail string) {
+	p.add(CallAction, method, detail)
+}
+
+// Steps returns every step recorded so far, in order.
+func (p *Plan) Steps() []Step {
+	return p.steps
+}
+
+func (p *Plan) add(action string, target string, detail string) {
+	p.steps = append(p.steps, Step{
+		Action: action,
+		Target: target,
+		Detail: detail,
+	})
+}
+
+// planBinary records that the binary of vmid installed from origin would be
+// written to the plugin directory and registered.
+func planBinary(plan *Plan, origin state.Origin, pluginDir string, vmid string, version string) {
+	plan.Write(filepath.Join(pluginDir, vmid), "")
+	plan.Register(origin.RegistryName(vmid), version)
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/luxfi/lpm/checksum"
+	"github.com/luxfi/lpm/state"
+)
+
+// register records the binary installed at binaryPath in the installation
+// registry and returns the name it was recorded under. Other entries that
+// pointed at the same binary are replaced, since the binary was just
+// replaced, and their kept versions and joined chains move to the new entry.
+func register(
+	stateFile state.File,
+	checksummer checksum.Checksummer,
+	info *state.InstallInfo,
+	binaryPath string,
+) (string, error) {
+	hash := checksummer.Checksum(binaryPath)
+	if hash == nil {
+		return "", fmt.Errorf("failed to calculate checksum of %s", binaryPath)
+	}
+
+	info.SHA256 = fmt.Sprintf("%x", hash)
+	info.InstalledAt = time.Now().UTC()
+
+	name := info.Origin.RegistryName(info.ID)
+	for other, existing := range stateFile.InstallationRegistry {
+		if other != name && existing.BinaryPath("") == info.BinaryPath("") {
+			fmt.Printf("Replacing %s, which was installed with the same VMID.\n", other)
+			adopt(stateFile, info, name, other, existing)
+			delete(stateFile.InstallationRegistry, other)
+		}
+	}
+
+	stateFile.InstallationRegistry[name] = info
+	return name, nil
+}
+
+// adopt moves the kept versions and joined chains of existing, which was
+// installed as other, to info, which is registered as name.
+func adopt(stateFile state.File, info *state.InstallInfo, name string, other string, existing *state.InstallInfo) {
+	for _, version := range existing.Previous {
+		if !slices.ContainsFunc(info.Previous, func(kept state.PreviousVersion) bool {
+			return kept.Path == version.Path
+		}) {
+			info.Previous = append(info.Previous, version)
+		}
+	}
+
+	for _, chain := range existing.Chains {
+		if !slices.Contains(info.Chains, chain) {
+			info.Chains = append(info.Chains, chain)
+		}
+		// The chain now needs the VM under its new name.
+		if chainInfo, ok := stateFile.ChainRegistry[chain]; ok {
+			for i, vm := range chainInfo.VMs {
+				if vm == other {
+					chainInfo.VMs[i] = name
+				}
+			}
+		}
+	}
+	if len(existing.Chains) > 0 {
+		fmt.Printf("Chains %s now need %s.\n", strings.Join(existing.Chains, ", "), name)
+	}
+
+	// A VM that was installed explicitly stays that way.
+	info.Dependency = info.Dependency && existing.Dependency
+}
@@ -0,0 +1,90 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/lpm/checksum"
+	"github.com/luxfi/lpm/state"
+)
+
+func TestRegisterReplacesSameBinary(t *testing.T) {
+	const (
+		old   = "organization/repository:vm"
+		chain = "organization/repository:chain"
+	)
+
+	tests := []struct {
+		name           string
+		dependency     bool
+		oldDependency  bool
+		wantDependency bool
+	}{
+		{
+			name:          "explicit install replaces dependency",
+			oldDependency: true,
+		},
+		{
+			name:       "dependency install replaces explicit install",
+			dependency: true,
+		},
+		{
+			name:           "dependency replaces dependency",
+			dependency:     true,
+			oldDependency:  true,
+			wantDependency: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			stateFile, err := state.New(t.TempDir())
+			require.NoError(t, err)
+			stateFile.InstallationRegistry[old] = &state.InstallInfo{
+				ID:     "vmID",
+				Origin: state.Origin{Type: state.RepositoryOrigin, Repository: "organization/repository", Definition: "vm"},
+				Previous: []state.PreviousVersion{
+					{Version: "v1.0.0", Path: ".versions/vmID/v1.0.0"},
+					{Version: "v0.9.0", Path: ".versions/vmID/v0.9.0"},
+				},
+				Chains:     []string{chain},
+				Dependency: test.oldDependency,
+			}
+			stateFile.ChainRegistry[chain] = &state.ChainInfo{
+				ID:  "chainID",
+				VMs: []string{old},
+			}
+
+			checksummer := checksum.NewMockChecksummer(ctrl)
+			checksummer.EXPECT().Checksum("plugins/vmID").Return([]byte{0x01})
+
+			name, err := register(stateFile, checksummer, &state.InstallInfo{
+				ID:     "vmID",
+				Origin: state.Origin{Type: state.GitHubOrigin, Owner: "owner", Repo: "repo"},
+				Previous: []state.PreviousVersion{
+					{Version: "v1.0.0", Path: ".versions/vmID/v1.0.0"},
+				},
+				Dependency: test.dependency,
+			}, "plugins/vmID")
+			require.NoError(t, err)
+			require.Equal(t, "github:owner/repo", name)
+
+			require.NotContains(t, stateFile.InstallationRegistry, old)
+			installed := stateFile.InstallationRegistry[name]
+			require.Equal(t, []state.PreviousVersion{
+				{Version: "v1.0.0", Path: ".versions/vmID/v1.0.0"},
+				{Version: "v0.9.0", Path: ".versions/vmID/v0.9.0"},
+			}, installed.Previous)
+			require.Equal(t, []string{chain}, installed.Chains)
+			require.Equal(t, test.wantDependency, installed.Dependency)
+			require.Equal(t, []string{name}, stateFile.ChainRegistry[chain].VMs)
+		})
+	}
+}
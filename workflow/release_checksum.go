@@ -0,0 +1,121 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/luxfi/lpm/checksum"
+	"github.com/luxfi/lpm/signature"
+)
+
+var errNoChecksum = errors.New("no published checksum")
+
+// releaseFile is a file published in a GitHub or GitLab release.
+type releaseFile struct {
+	name string
+	url  string
+}
+
+// isReleaseMetadata returns whether the release file called name is a
+// signature or checksums file rather than an installable asset.
+func isReleaseMetadata(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, signature.Extension) ||
+		strings.HasSuffix(name, ".sha256") ||
+		strings.HasSuffix(name, ".sha256sum") ||
+		strings.Contains(name, "checksums") ||
+		strings.Contains(name, "sha256sums")
+}
+
+// findChecksumFiles returns the checksums files in release that may list
+// asset, most specific first.
+func findChecksumFiles(release []releaseFile, asset string) []releaseFile {
+	var perAsset, manifests []releaseFile
+	for _, file := range release {
+		name := strings.ToLower(file.name)
+		switch {
+		case file.name == asset || strings.HasSuffix(name, signature.Extension):
+			// Neither the asset itself nor signatures list checksums.
+		case name == strings.ToLower(asset)+".sha256" || name == strings.ToLower(asset)+".sha256sum":
+			perAsset = append(perAsset, file)
+		case strings.Contains(name, "checksums") || strings.Contains(name, "sha256sums"):
+			manifests = append(manifests, file)
+		}
+	}
+
+	return append(perAsset, manifests...)
+}
+
+// releaseChecksum returns the checksum of asset published in release and the
+// name of the checksums file it's published in. Checksums files are downloaded
+// next to path with download. If the release doesn't publish a checksum of
+// asset, an empty checksum is returned.
+func releaseChecksum(
+	fs afero.Fs,
+	release []releaseFile,
+	asset string,
+	path string,
+	download func(url string, dest string) error,
+) (string, string, error) {
+	for _, file := range findChecksumFiles(release, asset) {
+		dest := fmt.Sprintf("%s.%s", path, file.name)
+		if err := download(file.url, dest); err != nil {
+			return "", "", fmt.Errorf("failed to download %s: %w", file.name, err)
+		}
+
+		b, err := afero.ReadFile(fs, dest)
+		_ = fs.Remove(dest)
+		if err != nil {
+			return "", "", err
+		}
+
+		sums := checksum.ParseSums(b)
+		expected, ok := sums.Lookup(asset)
+		if !ok && strings.HasPrefix(strings.ToLower(file.name), strings.ToLower(asset)) {
+			// Per-asset checksums files may only contain the hash.
+			expected, ok = sums.Lookup("")
+		}
+		if ok {
+			return expected, file.name, nil
+		}
+	}
+
+	return "", "", nil
+}
+
+// verifyReleaseChecksum checks the asset downloaded to path against the
+// checksum expected, published in the release's checksums file sumsFile, and
+// returns its checksum. If the release doesn't publish a checksum of the asset
+// (expected is empty), an empty checksum is returned unless one is required.
+func verifyReleaseChecksum(
+	checksummer checksum.Checksummer,
+	asset string,
+	path string,
+	expected string,
+	sumsFile string,
+	required bool,
+) (string, error) {
+	if expected == "" {
+		if required {
+			return "", fmt.Errorf("%w for %s in the release", errNoChecksum, asset)
+		}
+
+		fmt.Printf("Warning - the release doesn't publish a checksum of %s. Installing it without verifying its checksum.\n", asset)
+		return "", nil
+	}
+
+	fmt.Printf("Verifying checksum against %s...\n", sumsFile)
+	hash := fmt.Sprintf("%x", checksummer.Checksum(path))
+	if hash != expected {
+		return "", fmt.Errorf("checksum of %s doesn't match %s. Expected %s but saw %s", asset, sumsFile, expected, hash)
+	}
+
+	fmt.Printf("Checksum verified: %s\n", hash)
+	return hash, nil
+}
@@ -0,0 +1,128 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/lpm/checksum"
+)
+
+func TestVerifyReleaseChecksum(t *testing.T) {
+	const (
+		asset = "evm-linux-amd64"
+		path  = "download"
+	)
+
+	binary := []byte("binary")
+	hash := fmt.Sprintf("%x", sha256.Sum256(binary))
+	wrongHash := strings.Repeat("0", 64)
+
+	tests := []struct {
+		name     string
+		release  map[string]string // file name to contents
+		required bool
+		wantHash string
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			name: "checksums manifest",
+			release: map[string]string{
+				asset:           "",
+				"checksums.txt": hash + "  " + asset + "\n" + wrongHash + "  evm-darwin-arm64\n",
+			},
+			wantHash: hash,
+			wantErr:  assert.NoError,
+		},
+		{
+			name: "per-asset checksum file with only the hash",
+			release: map[string]string{
+				asset:             "",
+				asset + ".sha256": hash + "\n",
+			},
+			wantHash: hash,
+			wantErr:  assert.NoError,
+		},
+		{
+			name: "per-asset checksum file takes precedence",
+			release: map[string]string{
+				asset:             "",
+				asset + ".sha256": hash + "  " + asset + "\n",
+				"checksums.txt":   wrongHash + "  " + asset + "\n",
+			},
+			wantHash: hash,
+			wantErr:  assert.NoError,
+		},
+		{
+			name: "checksum mismatch",
+			release: map[string]string{
+				asset:           "",
+				"checksums.txt": wrongHash + "  " + asset + "\n",
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorContains(t, err, "doesn't match checksums.txt")
+			},
+		},
+		{
+			name: "manifest doesn't list the asset",
+			release: map[string]string{
+				asset:           "",
+				"checksums.txt": hash + "  evm-darwin-arm64\n",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "no checksums published",
+			release: map[string]string{asset: ""},
+			wantErr: assert.NoError,
+		},
+		{
+			name:     "no checksums published but required",
+			release:  map[string]string{asset: ""},
+			required: true,
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorIs(t, err, errNoChecksum)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, path, binary, perms.ReadWrite))
+
+			files := make([]releaseFile, 0, len(test.release))
+			for name := range test.release {
+				files = append(files, releaseFile{name: name, url: name})
+			}
+			download := func(url string, dest string) error {
+				return afero.WriteFile(fs, dest, []byte(test.release[url]), perms.ReadWrite)
+			}
+
+			expected, sumsFile, err := releaseChecksum(fs, files, asset, path, download)
+			require.NoError(t, err)
+
+			got, err := verifyReleaseChecksum(checksum.NewSHA256(fs), asset, path, expected, sumsFile, test.required)
+			test.wantErr(t, err)
+			require.Equal(t, test.wantHash, got)
+		})
+	}
+}
+
+func TestIsReleaseMetadata(t *testing.T) {
+	for _, name := range []string{"checksums.txt", "SHA256SUMS", "evm-linux-amd64.sha256", "evm_1.0.0_checksums.txt", "evm-linux-amd64.minisig"} {
+		require.True(t, isReleaseMetadata(name), name)
+	}
+	for _, name := range []string{"evm-linux-amd64", "evm-linux-amd64.tar.gz"} {
+		require.False(t, isReleaseMetadata(name), name)
+	}
+}
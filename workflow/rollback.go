@@ -0,0 +1,128 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/luxfi/lpm/checksum"
+	"github.com/luxfi/lpm/state"
+)
+
+var _ Workflow = &Rollback{}
+
+// RollbackConfig configures a rollback to a previously installed binary.
+type RollbackConfig struct {
+	Name string
+	// To is the version or commit to roll back to. Empty rolls back to the
+	// most recently replaced binary.
+	To         string
+	PluginPath string
+	StateFile  state.File
+	Fs         afero.Fs
+}
+
+// NewRollback creates a new rollback workflow.
+func NewRollback(config RollbackConfig) *Rollback {
+	return &Rollback{
+		name:        config.Name,
+		to:          config.To,
+		pluginPath:  config.PluginPath,
+		stateFile:   config.StateFile,
+		fs:          config.Fs,
+		checksummer: checksum.NewSHA256(config.Fs),
+	}
+}
+
+// Rollback restores a binary kept when a VM was upgraded. The binary it
+// replaces is kept in turn, so the rollback can be undone the same way.
+type Rollback struct {
+	transactional
+
+	name        string
+	to          string
+	pluginPath  string
+	stateFile   state.File
+	fs          afero.Fs
+	checksummer checksum.Checksummer
+}
+
+func (r *Rollback) Execute() error {
+	info, ok := r.stateFile.InstallationRegistry[r.name]
+	if !ok {
+		return fmt.Errorf("%s is not installed", r.name)
+	}
+	if len(info.Previous) == 0 {
+		return fmt.Errorf("%s has no previous versions to roll back to", r.name)
+	}
+
+	i, err := r.find(info.Previous)
+	if err != nil {
+		return err
+	}
+	target := info.Previous[i]
+
+	targetPath := filepath.Join(r.pluginPath, filepath.FromSlash(target.Path))
+	if target.SHA256 != "" {
+		hash := fmt.Sprintf("%x", r.checksummer.Checksum(targetPath))
+		if hash != target.SHA256 {
+			return fmt.Errorf("kept binary %s doesn't match its checksum. Expected %s but saw %s", targetPath, target.SHA256, hash)
+		}
+	}
+
+	remaining := make([]state.PreviousVersion, 0, len(info.Previous)-1)
+	remaining = append(remaining, info.Previous[:i]...)
+	remaining = append(remaining, info.Previous[i+1:]...)
+
+	tx := r.transaction()
+	previous, err := keepVersion(tx, r.fs, info, r.pluginPath, remaining, len(info.Previous))
+	if err != nil {
+		return err
+	}
+
+	binaryPath := info.BinaryPath(r.pluginPath)
+	if err := tx.Track(binaryPath); err != nil {
+		return err
+	}
+	if err := tx.Track(targetPath); err != nil {
+		return err
+	}
+
+	fmt.Printf("Restoring %s@%s...\n", r.name, target.Label())
+	if err := r.fs.Rename(targetPath, binaryPath); err != nil {
+		return err
+	}
+
+	info.Version = target.Version
+	info.Commit = target.Commit
+	info.SHA256 = target.SHA256
+	info.Signer = target.Signer
+	info.InstalledAt = time.Now().UTC()
+	info.Previous = previous
+
+	fmt.Printf("Rolled back %s to %s. Upgrading it will install the latest version again.\n", r.name, target.Label())
+	return nil
+}
+
+// find returns the index of the previous version to roll back to.
+func (r *Rollback) find(previous []state.PreviousVersion) (int, error) {
+	if r.to == "" {
+		return 0, nil
+	}
+
+	labels := make([]string, 0, len(previous))
+	for i, version := range previous {
+		if version.Version == r.to || version.Commit == r.to {
+			return i, nil
+		}
+		labels = append(labels, version.Label())
+	}
+
+	return 0, fmt.Errorf("%s has no previous version %s. Available versions are %s", r.name, r.to, strings.Join(labels, ", "))
+}
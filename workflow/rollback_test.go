@@ -0,0 +1,208 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/lpm/state"
+)
+
+const (
+	rollbackName   = "organization/repository:vm"
+	rollbackPlugin = "plugins"
+)
+
+func sha256Of(content string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+}
+
+// installVersions installs current as vm's binary, with previous kept for
+// rollbacks.
+func installVersions(t *testing.T, fs afero.Fs, current string, previous ...string) *state.InstallInfo {
+	info := &state.InstallInfo{
+		ID:      "id",
+		Version: current,
+		SHA256:  sha256Of(current),
+	}
+	require.NoError(t, afero.WriteFile(fs, info.BinaryPath(rollbackPlugin), []byte(current), 0o755))
+
+	for _, version := range previous {
+		kept := state.PreviousVersion{
+			Version: version,
+			SHA256:  sha256Of(version),
+			Path:    path.Join(state.VersionsDir, "id", version),
+		}
+		require.NoError(t, afero.WriteFile(fs, filepath.Join(rollbackPlugin, kept.Path), []byte(version), 0o755))
+		info.Previous = append(info.Previous, kept)
+	}
+
+	return info
+}
+
+func labels(previous []state.PreviousVersion) []string {
+	labels := make([]string, 0, len(previous))
+	for _, version := range previous {
+		labels = append(labels, version.Label())
+	}
+	return labels
+}
+
+func TestKeepVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		previous []string
+		keep     int
+		want     []string
+		wantGone []string
+	}{
+		{
+			name:    "first upgrade",
+			current: "v1",
+			keep:    3,
+			want:    []string{"v1"},
+		},
+		{
+			name:     "keeps most recent first",
+			current:  "v3",
+			previous: []string{"v2", "v1"},
+			keep:     3,
+			want:     []string{"v3", "v2", "v1"},
+		},
+		{
+			name:     "deletes the oldest",
+			current:  "v4",
+			previous: []string{"v3", "v2", "v1"},
+			keep:     2,
+			want:     []string{"v4", "v3"},
+			wantGone: []string{"v2", "v1"},
+		},
+		{
+			name:     "same version is only kept once",
+			current:  "v1",
+			previous: []string{"v2", "v1"},
+			keep:     3,
+			want:     []string{"v1", "v2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			info := installVersions(t, fs, test.current, test.previous...)
+
+			previous, err := keepVersion(nopTransaction{}, fs, info, rollbackPlugin, info.Previous, test.keep)
+			require.NoError(t, err)
+			require.Equal(t, test.want, labels(previous))
+
+			// The installed binary is copied, not moved.
+			b, err := afero.ReadFile(fs, info.BinaryPath(rollbackPlugin))
+			require.NoError(t, err)
+			require.Equal(t, test.current, string(b))
+
+			b, err = afero.ReadFile(fs, filepath.Join(rollbackPlugin, previous[0].Path))
+			require.NoError(t, err)
+			require.Equal(t, test.current, string(b))
+			require.Equal(t, sha256Of(test.current), previous[0].SHA256)
+
+			for _, version := range test.wantGone {
+				exists, err := afero.Exists(fs, filepath.Join(rollbackPlugin, state.VersionsDir, "id", version))
+				require.NoError(t, err)
+				require.False(t, exists)
+			}
+		})
+	}
+}
+
+func TestRollbackExecute(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []string
+		to       string
+		// modify overwrites a kept binary
+		modify       string
+		wantVersion  string
+		wantPrevious []string
+		wantErr      string
+	}{
+		{
+			name:    "nothing to roll back to",
+			wantErr: "has no previous versions",
+		},
+		{
+			name:         "most recent version",
+			previous:     []string{"v2", "v1"},
+			wantVersion:  "v2",
+			wantPrevious: []string{"v3", "v1"},
+		},
+		{
+			name:         "specific version",
+			previous:     []string{"v2", "v1"},
+			to:           "v1",
+			wantVersion:  "v1",
+			wantPrevious: []string{"v3", "v2"},
+		},
+		{
+			name:     "unknown version",
+			previous: []string{"v2", "v1"},
+			to:       "v0",
+			wantErr:  "Available versions are v2, v1",
+		},
+		{
+			name:     "modified binary",
+			previous: []string{"v2", "v1"},
+			modify:   "v2",
+			wantErr:  "doesn't match its checksum",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			stateFile, err := state.New("stateFilePath")
+			require.NoError(t, err)
+
+			info := installVersions(t, fs, "v3", test.previous...)
+			stateFile.InstallationRegistry[rollbackName] = info
+			if test.modify != "" {
+				require.NoError(t, afero.WriteFile(fs, filepath.Join(rollbackPlugin, state.VersionsDir, "id", test.modify), []byte("modified"), 0o755))
+			}
+
+			wf := NewRollback(RollbackConfig{
+				Name:       rollbackName,
+				To:         test.to,
+				PluginPath: rollbackPlugin,
+				StateFile:  stateFile,
+				Fs:         fs,
+			})
+			err = wf.Execute()
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				require.Equal(t, "v3", info.Version)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, test.wantVersion, info.Version)
+			require.Equal(t, sha256Of(test.wantVersion), info.SHA256)
+			require.Equal(t, test.wantPrevious, labels(info.Previous))
+
+			b, err := afero.ReadFile(fs, info.BinaryPath(rollbackPlugin))
+			require.NoError(t, err)
+			require.Equal(t, test.wantVersion, string(b))
+
+			exists, err := afero.Exists(fs, filepath.Join(rollbackPlugin, state.VersionsDir, "id", test.wantVersion))
+			require.NoError(t, err)
+			require.False(t, exists)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/afero"
+
+	"github.com/luxfi/lpm/signature"
+)
+
+var errUnsigned = errors.New("isn't signed. Pass --allow-unsigned to install it anyway")
+
+// allowUnsigned returns an error unless unsigned artifacts may be installed.
+func allowUnsigned(name string, allowed bool) error {
+	if !allowed {
+		return fmt.Errorf("%s %w", name, errUnsigned)
+	}
+
+	fmt.Printf("Warning - %s isn't signed. Installing it anyway since unsigned artifacts are allowed.\n", name)
+	return nil
+}
+
+// verifySignature checks that the detached signature at sigPath is a valid
+// signature of the file at path by a trusted key and returns the ID of that
+// key.
+func verifySignature(fs afero.Fs, verifier signature.Verifier, name string, path string, sigPath string) (string, error) {
+	if verifier == nil {
+		return "", fmt.Errorf("%s is signed but there are no trusted keys to verify it with", name)
+	}
+
+	sig, err := afero.ReadFile(fs, sigPath)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := fs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	fmt.Printf("Verifying signature...\n")
+	key, err := verifier.Verify(f, sig)
+	if err != nil {
+		return "", fmt.Errorf("refusing to install %s: %w", name, err)
+	}
+
+	fmt.Printf("Verified signature by %s (%s)\n", key.Name, key.PublicKey.ID())
+	return key.PublicKey.ID(), nil
+}
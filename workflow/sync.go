@@ -0,0 +1,326 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/luxfi/lpm/cache"
+	"github.com/luxfi/lpm/checksum"
+	"github.com/luxfi/lpm/manifest"
+	"github.com/luxfi/lpm/signature"
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/types"
+	"github.com/luxfi/lpm/url"
+	"github.com/luxfi/lpm/util"
+)
+
+var _ Workflow = &Sync{}
+
+type SyncConfig struct {
+	Executor Executor
+	Manifest *manifest.Manifest
+
+	RepoFactory state.RepositoryFactory
+	StateFile   state.File
+
+	TmpPath    string
+	PluginPath string
+	Platform   types.Platform
+	// AllowUnsigned installs plugins that aren't signed
+	AllowUnsigned bool
+	Installer     Installer
+	Verifier      signature.Verifier
+	// Client downloads plugins installed from releases and URLs
+	Client url.Client
+	// Cache is checked for artifacts before downloading them
+	Cache *cache.Cache
+	Fs    afero.Fs
+}
+
+func NewSync(config SyncConfig) *Sync {
+	if config.Platform == (types.Platform{}) {
+		config.Platform = types.CurrentPlatform()
+	}
+
+	return &Sync{
+		executor:      config.Executor,
+		manifest:      config.Manifest,
+		repoFactory:   config.RepoFactory,
+		stateFile:     config.StateFile,
+		tmpPath:       config.TmpPath,
+		pluginPath:    config.PluginPath,
+		platform:      config.Platform,
+		allowUnsigned: config.AllowUnsigned,
+		installer:     config.Installer,
+		verifier:      config.Verifier,
+		client:        config.Client,
+		cache:         config.Cache,
+		fs:            config.Fs,
+		checksummer:   checksum.NewSHA256(config.Fs),
+	}
+}
+
+// Sync makes the installed plugins match a lockfile. Plugins that are missing
+// or differ from the lockfile are (re)installed and plugins that aren't in the
+// lockfile are uninstalled. Every change is made as part of this workflow's
+// transaction, so a failure leaves the plugins as they were.
+type Sync struct {
+	executor Executor
+	manifest *manifest.Manifest
+
+	repoFactory state.RepositoryFactory
+	stateFile   state.File
+
+	tmpPath    string
+	pluginPath string
+	platform   types.Platform
+
+	allowUnsigned bool
+
+	installer   Installer
+	verifier    signature.Verifier
+	client      url.Client
+	cache       *cache.Cache
+	fs          afero.Fs
+	checksummer checksum.Checksummer
+}
+
+func (s *Sync) Execute() error {
+	changed := 0
+	listed := make(map[string]struct{}, len(s.manifest.Plugins))
+
+	for _, plugin := range s.manifest.Plugins {
+		listed[plugin.Name] = struct{}{}
+
+		installed, ok := s.stateFile.InstallationRegistry[plugin.Name]
+		if ok && plugin.Matches(installed) && s.intact(installed) {
+			installed.Constraint = plugin.Constraint
+			continue
+		}
+
+		if plugin.Origin.Type == state.LinkOrigin {
+			fmt.Printf("Skipping %s, which is a development link. Use lpm link to create it on this machine.\n", plugin.Name)
+			continue
+		}
+
+		wf, err := s.install(plugin)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			fmt.Printf("Reinstalling %s to match the lockfile...\n", plugin.Name)
+		} else {
+			fmt.Printf("Installing %s...\n", plugin.Name)
+		}
+		if err := s.executor.Execute(wf); err != nil {
+			return fmt.Errorf("failed to sync %s: %w", plugin.Name, err)
+		}
+
+		if err := s.verify(plugin); err != nil {
+			return err
+		}
+		changed++
+	}
+
+	unlisted := make([]string, 0)
+	for _, name := range slices.Sorted(maps.Keys(s.stateFile.InstallationRegistry)) {
+		if _, ok := listed[name]; !ok {
+			unlisted = append(unlisted, name)
+		}
+	}
+
+	for _, name := range unlisted {
+		if chains := s.stateFile.InstallationRegistry[name].Chains; len(chains) > 0 {
+			fmt.Printf("Keeping %s, which isn't in the lockfile but is needed by joined chains %s.\n", name, strings.Join(chains, ", "))
+			continue
+		}
+
+		fmt.Printf("Uninstalling %s, which isn't in the lockfile...\n", name)
+		repoAlias, plugin := util.ParseQualifiedName(name)
+		if err := s.executor.Execute(NewUninstall(UninstallConfig{
+			Name:       name,
+			Plugin:     plugin,
+			RepoAlias:  repoAlias,
+			StateFile:  s.stateFile,
+			Fs:         s.fs,
+			PluginPath: s.pluginPath,
+		})); err != nil {
+			return fmt.Errorf("failed to sync %s: %w", name, err)
+		}
+		changed++
+	}
+
+	if changed == 0 {
+		fmt.Printf("All plugins are already in sync.\n")
+		return nil
+	}
+
+	fmt.Printf("Synced %d plugins.\n", changed)
+	return nil
+}
+
+// intact returns whether the binary of an install is still the one that was
+// installed.
+func (s *Sync) intact(installed *state.InstallInfo) bool {
+	hash := s.checksummer.Checksum(installed.BinaryPath(s.pluginPath))
+	return hash != nil && fmt.Sprintf("%x", hash) == installed.SHA256
+}
+
+// install returns the workflow that installs plugin from where it was
+// originally installed from.
+func (s *Sync) install(plugin manifest.Plugin) (Workflow, error) {
+	origin := plugin.Origin
+	switch origin.Type {
+	case state.RepositoryOrigin:
+		repository, err := s.repoFactory.GetRepository(origin.Repository)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find repository %s for %s: %w", origin.Repository, plugin.Name, err)
+		}
+
+		constraint := ""
+		if plugin.Version != "" {
+			constraint = "=" + plugin.Version
+		} else {
+			// Unversioned plugins are installed from the definition on disk, so
+			// it has to be the one that was locked.
+			definition, err := repository.GetVM(origin.Definition)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the definition of %s: %w", plugin.Name, err)
+			}
+			if definition.Commit != plugin.Commit {
+				return nil, definitionChanged(plugin, definition.Commit)
+			}
+		}
+
+		organization, repo := util.ParseAlias(origin.Repository)
+		return NewInstall(InstallConfig{
+			Name:          plugin.Name,
+			Plugin:        origin.Definition,
+			Organization:  organization,
+			Repo:          repo,
+			TmpPath:       s.tmpPath,
+			PluginPath:    s.pluginPath,
+			Constraint:    constraint,
+			Platform:      s.platform,
+			AllowUnsigned: s.allowUnsigned,
+			StateFile:     s.stateFile,
+			Repository:    repository,
+			Fs:            s.fs,
+			Installer:     s.installer,
+			Verifier:      s.verifier,
+			Cache:         s.cache,
+		}), nil
+	case state.GitHubOrigin:
+		return NewInstallGitHub(InstallGitHubConfig{
+			Owner:         origin.Owner,
+			Repo:          origin.Repo,
+			Tag:           origin.Tag,
+			VMID:          plugin.ID,
+			Pattern:       path.Base(origin.URL),
+			Binary:        origin.Binary,
+			OS:            s.platform.OS,
+			Arch:          s.platform.Arch,
+			PluginDir:     s.pluginPath,
+			AllowUnsigned: s.allowUnsigned,
+			StateFile:     s.stateFile,
+			Fs:            s.fs,
+			Verifier:      s.verifier,
+			Client:        s.client,
+			Cache:         s.cache,
+			// The release published a checksum when it was locked.
+			RequireChecksum: origin.SHA256 != "",
+		}), nil
+	case state.GitLabOrigin:
+		return NewInstallGitLab(InstallGitLabConfig{
+			Owner:         origin.Owner,
+			Repo:          origin.Repo,
+			Tag:           origin.Tag,
+			VMID:          plugin.ID,
+			Pattern:       path.Base(origin.URL),
+			Binary:        origin.Binary,
+			OS:            s.platform.OS,
+			Arch:          s.platform.Arch,
+			PluginDir:     s.pluginPath,
+			BaseURL:       origin.BaseURL,
+			AllowUnsigned: s.allowUnsigned,
+			StateFile:     s.stateFile,
+			Fs:            s.fs,
+			Verifier:      s.verifier,
+			Client:        s.client,
+			Cache:         s.cache,
+			// The release published a checksum when it was locked.
+			RequireChecksum: origin.SHA256 != "",
+		}), nil
+	case state.URLOrigin:
+		return NewInstallURL(InstallURLConfig{
+			URL:           origin.URL,
+			VMID:          plugin.ID,
+			SHA256:        origin.SHA256,
+			Signature:     origin.Signature,
+			Binary:        origin.Binary,
+			PluginDir:     s.pluginPath,
+			AllowUnsigned: s.allowUnsigned,
+			StateFile:     s.stateFile,
+			Fs:            s.fs,
+			Verifier:      s.verifier,
+			Client:        s.client,
+			Cache:         s.cache,
+		}), nil
+	case state.SourceOrigin:
+		return NewInstallSource(InstallSourceConfig{
+			Owner:     origin.Owner,
+			Repo:      origin.Repo,
+			Tag:       origin.Ref,
+			VMID:      plugin.ID,
+			OS:        s.platform.OS,
+			Arch:      s.platform.Arch,
+			PluginDir: s.pluginPath,
+			StateFile: s.stateFile,
+			Fs:        s.fs,
+		}), nil
+	default:
+		return nil, fmt.Errorf("%s was installed from %s, which can't be synced", plugin.Name, origin.Type)
+	}
+}
+
+// verify checks that what was just installed is exactly what the lockfile
+// pins.
+func (s *Sync) verify(plugin manifest.Plugin) error {
+	installed, ok := s.stateFile.InstallationRegistry[plugin.Name]
+	if !ok {
+		return fmt.Errorf("%s wasn't installed under the name in the lockfile", plugin.Name)
+	}
+
+	if plugin.Origin.Type == state.RepositoryOrigin && plugin.Version == "" && installed.Commit != plugin.Commit {
+		return definitionChanged(plugin, installed.Commit)
+	}
+
+	if plugin.SHA256 != "" && installed.SHA256 != plugin.SHA256 {
+		return fmt.Errorf("installed %s has checksum %s but the lockfile expects %s", plugin.Name, installed.SHA256, plugin.SHA256)
+	}
+
+	// Keep the constraint from the lockfile rather than the exact version we
+	// had to install to match it.
+	installed.Constraint = plugin.Constraint
+	return nil
+}
+
+// definitionChanged returns the error for a definition of plugin that is at
+// commit instead of the commit that was locked.
+func definitionChanged(plugin manifest.Plugin, commit string) error {
+	return fmt.Errorf(
+		"the definition of %s changed since the lockfile was written (commit %s, expected %s). Run lpm lock to update the lockfile",
+		plugin.Name,
+		commit,
+		plugin.Commit,
+	)
+}
@@ -0,0 +1,237 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/luxfi/filesystem/perms"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/lpm/manifest"
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/types"
+)
+
+func TestSyncExecute(t *testing.T) {
+	const (
+		name       = "organization/repository:vm"
+		unlisted   = "organization/repository:other"
+		pluginPath = "pluginPath"
+	)
+
+	binary := []byte("binary")
+	hash := fmt.Sprintf("%x", sha256.Sum256(binary))
+	errWrong := fmt.Errorf("something went wrong")
+
+	origin := state.Origin{
+		Type:       state.RepositoryOrigin,
+		Repository: "organization/repository",
+		Definition: "vm",
+	}
+	plugin := manifest.Plugin{
+		Name:   name,
+		ID:     "id",
+		Commit: "commit",
+		SHA256: hash,
+		Origin: origin,
+	}
+	installed := func() *state.InstallInfo {
+		return &state.InstallInfo{
+			ID:     plugin.ID,
+			Commit: plugin.Commit,
+			SHA256: plugin.SHA256,
+			Origin: origin,
+		}
+	}
+
+	// repository returns a repository whose definition of the plugin is at
+	// commit.
+	repository := func(ctrl *gomock.Controller, commit string) state.Repository {
+		repository := state.NewMockRepository(ctrl)
+		repository.EXPECT().GetVM("vm").Return(state.Definition[types.VM]{Commit: commit}, nil)
+		return repository
+	}
+
+	type mocks struct {
+		stateFile   state.File
+		executor    *MockExecutor
+		repoFactory *state.MockRepositoryFactory
+		fs          afero.Fs
+		ctrl        *gomock.Controller
+	}
+	tests := []struct {
+		name    string
+		plugins []manifest.Plugin
+		setup   func(mocks)
+		wantErr assert.ErrorAssertionFunc
+		// plugins expected in the installation registry afterwards
+		wantInstalled []string
+	}{
+		{
+			name:    "already in sync",
+			plugins: []manifest.Plugin{plugin},
+			setup: func(mocks mocks) {
+				mocks.stateFile.InstallationRegistry[name] = installed()
+				require.NoError(t, afero.WriteFile(mocks.fs, filepath.Join(pluginPath, "id"), binary, perms.ReadWrite))
+			},
+			wantErr:       assert.NoError,
+			wantInstalled: []string{name},
+		},
+		{
+			name:    "installs missing plugins",
+			plugins: []manifest.Plugin{plugin},
+			setup: func(mocks mocks) {
+				mocks.repoFactory.EXPECT().GetRepository("organization/repository").Return(repository(mocks.ctrl, "commit"), nil)
+				mocks.executor.EXPECT().Execute(gomock.AssignableToTypeOf(&Install{})).DoAndReturn(func(Workflow) error {
+					mocks.stateFile.InstallationRegistry[name] = installed()
+					return nil
+				})
+			},
+			wantErr:       assert.NoError,
+			wantInstalled: []string{name},
+		},
+		{
+			name:    "reinstalls modified binaries",
+			plugins: []manifest.Plugin{plugin},
+			setup: func(mocks mocks) {
+				mocks.stateFile.InstallationRegistry[name] = installed()
+				require.NoError(t, afero.WriteFile(mocks.fs, filepath.Join(pluginPath, "id"), []byte("modified"), perms.ReadWrite))
+
+				mocks.repoFactory.EXPECT().GetRepository("organization/repository").Return(repository(mocks.ctrl, "commit"), nil)
+				mocks.executor.EXPECT().Execute(gomock.AssignableToTypeOf(&Install{})).Return(nil)
+			},
+			wantErr:       assert.NoError,
+			wantInstalled: []string{name},
+		},
+		{
+			name: "uninstalls unlisted plugins",
+			setup: func(mocks mocks) {
+				mocks.stateFile.InstallationRegistry[unlisted] = installed()
+				mocks.executor.EXPECT().Execute(gomock.AssignableToTypeOf(&Uninstall{})).DoAndReturn(func(Workflow) error {
+					delete(mocks.stateFile.InstallationRegistry, unlisted)
+					return nil
+				})
+			},
+			wantErr:       assert.NoError,
+			wantInstalled: []string{},
+		},
+		{
+			name:    "install fails",
+			plugins: []manifest.Plugin{plugin},
+			setup: func(mocks mocks) {
+				mocks.repoFactory.EXPECT().GetRepository("organization/repository").Return(repository(mocks.ctrl, "commit"), nil)
+				mocks.executor.EXPECT().Execute(gomock.Any()).Return(errWrong)
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorIs(t, err, errWrong)
+			},
+			wantInstalled: []string{},
+		},
+		{
+			name:    "installed binary doesn't match the lockfile",
+			plugins: []manifest.Plugin{plugin},
+			setup: func(mocks mocks) {
+				mocks.repoFactory.EXPECT().GetRepository("organization/repository").Return(repository(mocks.ctrl, "commit"), nil)
+				mocks.executor.EXPECT().Execute(gomock.Any()).DoAndReturn(func(Workflow) error {
+					info := installed()
+					info.SHA256 = "other"
+					mocks.stateFile.InstallationRegistry[name] = info
+					return nil
+				})
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorContains(t, err, "the lockfile expects")
+			},
+			wantInstalled: []string{name},
+		},
+		{
+			name:    "definition changed since the lockfile was written",
+			plugins: []manifest.Plugin{plugin},
+			setup: func(mocks mocks) {
+				// Nothing is installed from a definition that wasn't locked.
+				mocks.repoFactory.EXPECT().GetRepository("organization/repository").Return(repository(mocks.ctrl, "newer"), nil)
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorContains(t, err, "changed since the lockfile was written (commit newer, expected commit)")
+			},
+			wantInstalled: []string{},
+		},
+		{
+			name:    "installed definition differs from the lockfile",
+			plugins: []manifest.Plugin{plugin},
+			setup: func(mocks mocks) {
+				mocks.repoFactory.EXPECT().GetRepository("organization/repository").Return(repository(mocks.ctrl, "commit"), nil)
+				mocks.executor.EXPECT().Execute(gomock.Any()).DoAndReturn(func(Workflow) error {
+					info := installed()
+					info.Commit = "newer"
+					mocks.stateFile.InstallationRegistry[name] = info
+					return nil
+				})
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorContains(t, err, "changed since the lockfile was written")
+			},
+			wantInstalled: []string{name},
+		},
+		{
+			name: "keeps unlisted plugins joined chains need",
+			setup: func(mocks mocks) {
+				info := installed()
+				info.Dependency = true
+				info.Chains = []string{"organization/repository:chain"}
+				mocks.stateFile.InstallationRegistry[unlisted] = info
+			},
+			wantErr:       assert.NoError,
+			wantInstalled: []string{unlisted},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			stateFile, err := state.New(t.TempDir())
+			require.NoError(t, err)
+
+			executor := NewMockExecutor(ctrl)
+			repoFactory := state.NewMockRepositoryFactory(ctrl)
+			fs := afero.NewMemMapFs()
+
+			test.setup(mocks{
+				stateFile:   stateFile,
+				executor:    executor,
+				repoFactory: repoFactory,
+				fs:          fs,
+				ctrl:        ctrl,
+			})
+
+			wf := NewSync(SyncConfig{
+				Executor: executor,
+				Manifest: &manifest.Manifest{
+					Version: manifest.Version,
+					Plugins: test.plugins,
+				},
+				RepoFactory: repoFactory,
+				StateFile:   stateFile,
+				TmpPath:     "tmpPath",
+				PluginPath:  pluginPath,
+				Fs:          fs,
+			})
+			test.wantErr(t, wf.Execute())
+
+			got := make([]string, 0)
+			for name := range stateFile.InstallationRegistry {
+				got = append(got, name)
+			}
+			require.ElementsMatch(t, test.wantInstalled, got)
+		})
+	}
+}
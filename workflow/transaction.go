@@ -0,0 +1,50 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+var _ Transaction = nopTransaction{}
+
+// Transaction records the side effects of a workflow so that they can be
+// undone if the workflow fails.
+type Transaction interface {
+	// Track snapshots the file at path so that it's restored to its current
+	// contents (or removed, if it doesn't exist yet) on rollback.
+	Track(path string) error
+	// OnRollback registers a compensating action to run on rollback.
+	// Actions are run in the reverse order they were registered in.
+	OnRollback(action func() error)
+}
+
+// Transactional is implemented by workflows that record their side effects
+// in a Transaction. Executors call Begin before Execute.
+type Transactional interface {
+	Workflow
+	Begin(tx Transaction)
+}
+
+// transactional can be embedded by workflows to implement Transactional.
+// Workflows run outside an executor get a no-op transaction.
+type transactional struct {
+	tx Transaction
+}
+
+func (t *transactional) Begin(tx Transaction) {
+	t.tx = tx
+}
+
+func (t transactional) transaction() Transaction {
+	if t.tx == nil {
+		return nopTransaction{}
+	}
+
+	return t.tx
+}
+
+type nopTransaction struct{}
+
+func (nopTransaction) Track(string) error {
+	return nil
+}
+
+func (nopTransaction) OnRollback(func() error) {}
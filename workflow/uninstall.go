@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io/fs"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/afero"
 
@@ -24,6 +25,8 @@ func NewUninstall(config UninstallConfig) *Uninstall {
 		stateFile:  config.StateFile,
 		fs:         config.Fs,
 		pluginPath: config.PluginPath,
+		force:      config.Force,
+		plan:       config.Plan,
 	}
 }
 
@@ -34,15 +37,23 @@ type UninstallConfig struct {
 	StateFile  state.File
 	Fs         afero.Fs
 	PluginPath string
+	// Force uninstalls the VM even while joined chains still need it
+	Force bool
+	// Plan records what the uninstall would do instead of doing it
+	Plan *Plan
 }
 
 type Uninstall struct {
+	transactional
+
 	name       string
 	plugin     string
 	repoAlias  string
 	stateFile  state.File
 	fs         afero.Fs
 	pluginPath string
+	force      bool
+	plan       *Plan
 }
 
 func (u Uninstall) Execute() error {
@@ -52,10 +63,30 @@ func (u Uninstall) Execute() error {
 		return nil
 	}
 
-	vmPath := filepath.Join(u.pluginPath, installInfo.ID)
+	if len(installInfo.Chains) > 0 {
+		chains := strings.Join(installInfo.Chains, ", ")
+		if !u.force {
+			return fmt.Errorf("VM %s is needed by joined chains %s. Leave them first, or pass --force to uninstall it anyway", u.name, chains)
+		}
+		fmt.Printf("Warning - uninstalling %s, which is still needed by %s.\n", u.name, chains)
+	}
+
+	vmPath := installInfo.BinaryPath(u.pluginPath)
+	if u.plan != nil {
+		u.plan.Remove(vmPath, "")
+		for _, version := range installInfo.Previous {
+			u.plan.Remove(filepath.Join(u.pluginPath, filepath.FromSlash(version.Path)), "kept for rollbacks")
+		}
+		u.plan.Unregister(u.name)
+		return nil
+	}
 
 	switch _, err := u.fs.Stat(vmPath); err {
 	case nil:
+		if err := u.transaction().Track(vmPath); err != nil {
+			return err
+		}
+
 		fmt.Printf("Deleting %s...\n", vmPath)
 		if err := u.fs.Remove(vmPath); err != nil {
 			return err
@@ -68,6 +99,12 @@ func (u Uninstall) Execute() error {
 		}
 	}
 
+	for _, version := range installInfo.Previous {
+		if err := removeVersion(u.transaction(), u.fs, u.pluginPath, version); err != nil {
+			return err
+		}
+	}
+
 	delete(u.stateFile.InstallationRegistry, u.name)
 	fmt.Printf("Successfully uninstalled %s.\n", u.name)
 
@@ -38,9 +38,11 @@ func TestUninstallExecute(t *testing.T) {
 		stateFile state.File
 	}
 	tests := []struct {
-		name    string
-		setup   func(mocks)
-		wantErr assert.ErrorAssertionFunc
+		name          string
+		setup         func(mocks)
+		force         bool
+		wantErr       assert.ErrorAssertionFunc
+		wantInstalled bool
 	}{
 		{
 			name: "vm already uninstalled",
@@ -62,6 +64,34 @@ func TestUninstallExecute(t *testing.T) {
 				return assert.Nil(t, err)
 			},
 		},
+		{
+			name: "needed by joined chains",
+			setup: func(mocks mocks) {
+				mocks.stateFile.InstallationRegistry[name] = &state.InstallInfo{
+					ID:     vm.GetID(),
+					Commit: definition.Commit,
+					Chains: []string{"organization/repository:chain"},
+				}
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorContains(t, err, "VM organization/repository:vm is needed by joined chains organization/repository:chain")
+			},
+			wantInstalled: true,
+		},
+		{
+			name: "needed by joined chains with force",
+			setup: func(mocks mocks) {
+				mocks.stateFile.InstallationRegistry[name] = &state.InstallInfo{
+					ID:     vm.GetID(),
+					Commit: definition.Commit,
+					Chains: []string{"organization/repository:chain"},
+				}
+			},
+			force: true,
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.Nil(t, err)
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -80,10 +110,12 @@ func TestUninstallExecute(t *testing.T) {
 					RepoAlias: "organization/repository",
 					StateFile: stateFile,
 					Fs:        afero.NewMemMapFs(),
+					Force:     test.force,
 				},
 			)
 
 			test.wantErr(t, wf.Execute())
+			require.Equal(t, test.wantInstalled, stateFile.InstallationRegistry[name] != nil)
 		})
 	}
 }
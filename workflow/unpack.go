@@ -0,0 +1,78 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+
+	"github.com/luxfi/lpm/archive"
+)
+
+// unpackedRelease is a release asset ready to be installed.
+type unpackedRelease struct {
+	// binaryPath is the path of the binary to install
+	binaryPath string
+	// binary is the path of the binary inside of the archive, if the asset
+	// was one
+	binary string
+	// cleanup removes anything extracted from the asset
+	cleanup func()
+}
+
+// unpackRelease locates the binary in the release asset called name that was
+// downloaded to path. Raw executables are installed as-is. Archives are
+// extracted next to path and the binary is taken from them, either from the
+// path binary or from a file matching names.
+func unpackRelease(fs afero.Fs, name string, path string, binary string, names ...string) (*unpackedRelease, error) {
+	format, ok := archive.FromName(name)
+	if !ok {
+		var err error
+		format, ok, err = archive.Detect(fs, path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !ok {
+		if binary != "" {
+			return nil, fmt.Errorf("%s is not an archive so --binary can't be used", name)
+		}
+		return &unpackedRelease{binaryPath: path, cleanup: func() {}}, nil
+	}
+
+	dir, err := afero.TempDir(fs, filepath.Dir(path), filepath.Base(path)+"-")
+	if err != nil {
+		return nil, err
+	}
+	cleanup := func() {
+		_ = fs.RemoveAll(dir)
+	}
+
+	fmt.Printf("Extracting %s archive %s...\n", format, name)
+	if err := archive.Extract(fs, format, path, dir, archive.Options{MaxSize: archive.DefaultMaxSize}); err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+
+	binaryPath, err := archive.FindBinary(fs, dir, binary, names...)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	rel, err := filepath.Rel(dir, binaryPath)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	fmt.Printf("Found binary %s in %s\n", filepath.ToSlash(rel), name)
+
+	return &unpackedRelease{
+		binaryPath: binaryPath,
+		binary:     filepath.ToSlash(rel),
+		cleanup:    cleanup,
+	}, nil
+}
@@ -5,12 +5,15 @@ package workflow
 
 import (
 	"fmt"
+	"maps"
 	"path/filepath"
+	"slices"
 
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/spf13/afero"
 
 	"github.com/luxfi/lpm/git"
+	"github.com/luxfi/lpm/search"
 	"github.com/luxfi/lpm/state"
 	"github.com/luxfi/lpm/util"
 )
@@ -23,11 +26,17 @@ type UpdateConfig struct {
 	PluginPath       string
 	Installer        Installer
 	RepositoriesPath string
+	IndexPath        string
 	Auth             http.BasicAuth
 	RepoFactory      state.RepositoryFactory
 	Fs               afero.Fs
 	StateFile        state.File
 	Git              git.Factory
+	// Offline rebuilds the search index from the repositories on disk
+	// instead of fetching their latest definitions
+	Offline bool
+	// Plan records what the update would do instead of doing it
+	Plan *Plan
 }
 
 func NewUpdate(config UpdateConfig) *Update {
@@ -37,11 +46,14 @@ func NewUpdate(config UpdateConfig) *Update {
 		pluginPath:       config.PluginPath,
 		installer:        config.Installer,
 		repositoriesPath: config.RepositoriesPath,
+		indexPath:        config.IndexPath,
 		auth:             config.Auth,
 		repoFactory:      config.RepoFactory,
 		fs:               config.Fs,
 		stateFile:        config.StateFile,
 		git:              config.Git,
+		offline:          config.Offline,
+		plan:             config.Plan,
 	}
 }
 
@@ -52,13 +64,44 @@ type Update struct {
 	tmpPath          string
 	pluginPath       string
 	repositoriesPath string
+	indexPath        string
 	repoFactory      state.RepositoryFactory
 	fs               afero.Fs
 	git              git.Factory
 	stateFile        state.File
+	offline          bool
+	plan             *Plan
 }
 
 func (u Update) Execute() error {
+	if u.plan != nil {
+		if !u.offline {
+			for _, alias := range slices.Sorted(maps.Keys(u.stateFile.Sources)) {
+				u.plan.Pull(alias, u.stateFile.Sources[alias].URL)
+			}
+		}
+		u.plan.Write(u.indexPath, "search index")
+		return nil
+	}
+
+	if u.offline {
+		fmt.Printf("Offline, using the definitions already on disk.\n")
+	} else if err := u.pull(); err != nil {
+		return err
+	}
+
+	// Rebuild the search index from the definitions we just pulled so
+	// searching doesn't need to touch every repository.
+	index, err := search.Build(u.fs, u.repositoriesPath)
+	if err != nil {
+		return fmt.Errorf("failed to build search index: %w", err)
+	}
+
+	return index.Save(u.fs, u.indexPath)
+}
+
+// pull fetches the latest definitions of every repository.
+func (u Update) pull() error {
 	updated := 0
 
 	fmt.Printf("Checking for updates...\n")
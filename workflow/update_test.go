@@ -28,6 +28,7 @@ func TestUpdateExecute(t *testing.T) {
 		tmpPath          = "tmpPath"
 		pluginPath       = "pluginPath"
 		repositoriesPath = "repositoriesPath"
+		indexPath        = "indexPath"
 	)
 
 	var (
@@ -69,6 +70,7 @@ func TestUpdateExecute(t *testing.T) {
 	}
 	tests := []struct {
 		name    string
+		offline bool
 		setup   func(mocks)
 		wantErr assert.ErrorAssertionFunc
 	}{
@@ -104,6 +106,16 @@ func TestUpdateExecute(t *testing.T) {
 				return assert.Equal(t, nil, err)
 			},
 		},
+		{
+			name:    "offline doesn't fetch repositories",
+			offline: true,
+			setup: func(mocks mocks) {
+				mocks.stateFile.Sources[alias] = outdated
+			},
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.NoError(t, err)
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -136,13 +148,21 @@ func TestUpdateExecute(t *testing.T) {
 					PluginPath:       pluginPath,
 					Installer:        installer,
 					RepositoriesPath: repositoriesPath,
+					IndexPath:        indexPath,
 					Auth:             auth,
 					Git:              git,
 					RepoFactory:      repoFactory,
 					Fs:               fs,
+					Offline:          test.offline,
 				},
 			)
-			test.wantErr(t, wf.Execute())
+			err = wf.Execute()
+			test.wantErr(t, err)
+			if err == nil {
+				exists, err := afero.Exists(fs, indexPath)
+				require.NoError(t, err)
+				require.True(t, exists)
+			}
 		})
 	}
 }
@@ -4,12 +4,21 @@
 package workflow
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"os"
+	"slices"
 
 	"github.com/spf13/afero"
 
+	"github.com/luxfi/lpm/cache"
 	"github.com/luxfi/lpm/git"
+	"github.com/luxfi/lpm/node"
+	"github.com/luxfi/lpm/signature"
 	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/types"
+	"github.com/luxfi/lpm/util"
 )
 
 type UpgradeConfig struct {
@@ -20,21 +29,40 @@ type UpgradeConfig struct {
 
 	TmpPath    string
 	PluginPath string
-	Installer  Installer
-	Git        git.Factory
-	Fs         afero.Fs
+	Platform   types.Platform
+	// AllowUnsigned installs upgrades that aren't signed
+	AllowUnsigned bool
+	// KeepVersions is how many replaced binaries are kept to roll back to
+	KeepVersions int
+	Installer    Installer
+	Verifier     signature.Verifier
+	Git          git.Factory
+	// Cache is checked for artifacts before downloading them
+	Cache *cache.Cache
+	// NodeConfig is where the configs of joined chains are re-applied
+	NodeConfig *node.ConfigFile
+	// Plan records what the upgrade would do instead of doing it
+	Plan *Plan
+	Fs   afero.Fs
 }
 
 func NewUpgrade(config UpgradeConfig) *Upgrade {
 	return &Upgrade{
-		executor:    config.Executor,
-		repoFactory: config.RepoFactory,
-		tmpPath:     config.TmpPath,
-		pluginPath:  config.PluginPath,
-		installer:   config.Installer,
-		stateFile:   config.StateFile,
-		git:         config.Git,
-		fs:          config.Fs,
+		executor:      config.Executor,
+		repoFactory:   config.RepoFactory,
+		tmpPath:       config.TmpPath,
+		pluginPath:    config.PluginPath,
+		platform:      config.Platform,
+		allowUnsigned: config.AllowUnsigned,
+		keepVersions:  config.KeepVersions,
+		installer:     config.Installer,
+		verifier:      config.Verifier,
+		stateFile:     config.StateFile,
+		git:           config.Git,
+		cache:         config.Cache,
+		nodeConfig:    config.NodeConfig,
+		plan:          config.Plan,
+		fs:            config.Fs,
 	}
 }
 
@@ -46,26 +74,52 @@ type Upgrade struct {
 
 	tmpPath    string
 	pluginPath string
-
-	installer Installer
-	git       git.Factory
-	fs        afero.Fs
+	platform   types.Platform
+
+	allowUnsigned bool
+	keepVersions  int
+
+	installer  Installer
+	verifier   signature.Verifier
+	git        git.Factory
+	cache      *cache.Cache
+	nodeConfig *node.ConfigFile
+	plan       *Plan
+	fs         afero.Fs
 }
 
 func (u *Upgrade) Execute() error {
 	upgraded := false
 
-	for name := range u.stateFile.InstallationRegistry {
+	// Upgrades change the registry, so the VMs to upgrade are read first.
+	for _, name := range slices.Sorted(maps.Keys(u.stateFile.InstallationRegistry)) {
+		installInfo, ok := u.stateFile.InstallationRegistry[name]
+		if !ok {
+			// Replaced by an earlier upgrade with the same VMID
+			continue
+		}
+		// Only VMs from a plugin repository have definitions to upgrade
+		// against.
+		if installInfo.Origin.Type != state.RepositoryOrigin {
+			continue
+		}
+
 		wf := NewUpgradeVM(UpgradeVMConfig{
-			Executor:    u.executor,
-			FullVMName:  name,
-			RepoFactory: u.repoFactory,
-			StateFile:   u.stateFile,
-			TmpPath:     u.tmpPath,
-			PluginPath:  u.pluginPath,
-			Installer:   u.installer,
-			Git:         u.git,
-			Fs:          u.fs,
+			Executor:      u.executor,
+			FullVMName:    name,
+			RepoFactory:   u.repoFactory,
+			StateFile:     u.stateFile,
+			TmpPath:       u.tmpPath,
+			PluginPath:    u.pluginPath,
+			Platform:      u.platform,
+			AllowUnsigned: u.allowUnsigned,
+			KeepVersions:  u.keepVersions,
+			Installer:     u.installer,
+			Verifier:      u.verifier,
+			Git:           u.git,
+			Cache:         u.cache,
+			Plan:          u.plan,
+			Fs:            u.fs,
 		})
 
 		if err := u.executor.Execute(wf); err == ErrAlreadyUpdated {
@@ -77,6 +131,15 @@ func (u *Upgrade) Execute() error {
 		upgraded = true
 	}
 
+	for _, name := range slices.Sorted(maps.Keys(u.stateFile.ChainRegistry)) {
+		reapplied, err := u.reapplyChainConfig(name, u.stateFile.ChainRegistry[name])
+		if err != nil {
+			return err
+		}
+
+		upgraded = upgraded || reapplied
+	}
+
 	if !upgraded {
 		fmt.Printf("No changes detected.\n")
 		return nil
@@ -84,3 +147,50 @@ func (u *Upgrade) Execute() error {
 
 	return nil
 }
+
+// reapplyChainConfig writes the configs of a joined chain again if its
+// definition changed since they were written. Chains whose repository or
+// definition is gone are skipped, so they don't hold up the other upgrades.
+func (u *Upgrade) reapplyChainConfig(name string, info *state.ChainInfo) (bool, error) {
+	repoAlias, chainName := util.ParseQualifiedName(name)
+	repository, err := u.repoFactory.GetRepository(repoAlias)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("Warning - repository %s of chain %s is no longer downloaded. Skipping its configs.\n", repoAlias, name)
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	definition, err := repository.GetChain(chainName)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("Warning - chain %s is no longer defined in its repository. Skipping its configs. "+
+			"Use leave-chain to leave it.\n", name)
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if definition.Commit == info.Commit {
+		return false, nil
+	}
+
+	chainID, ok := definition.Definition.GetID(info.Network)
+	if !ok {
+		fmt.Printf("Warning - chain %s no longer has an ID on %s. Skipping its configs.\n", name, info.Network)
+		return false, nil
+	}
+
+	fmt.Printf("Definition of chain %s changed from %s to %s. Re-applying its configs...\n", name, info.Commit, definition.Commit)
+	wf := NewApplyChainConfig(ApplyChainConfigConfig{
+		Name:       name,
+		Network:    info.Network,
+		ChainID:    chainID,
+		Definition: definition,
+		NodeConfig: u.nodeConfig,
+		StateFile:  u.stateFile,
+		Plan:       u.plan,
+	})
+	if err := u.executor.Execute(wf); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/types"
+)
+
+func TestUpgradeReapplyChainConfig(t *testing.T) {
+	const (
+		repoAlias = "organization/repository"
+		chain     = repoAlias + ":chain"
+	)
+	notExist := &os.PathError{Op: "open", Path: "chains/chain.yaml", Err: os.ErrNotExist}
+
+	tests := []struct {
+		name        string
+		repoErr     error
+		definition  state.Definition[types.Chain]
+		chainErr    error
+		wantReapply bool
+		wantErr     string
+	}{
+		{
+			name:    "repository deleted",
+			repoErr: notExist,
+		},
+		{
+			name:     "definition deleted",
+			chainErr: notExist,
+		},
+		{
+			name:     "definition unreadable",
+			chainErr: errors.New("yaml: invalid"),
+			wantErr:  "yaml: invalid",
+		},
+		{
+			name: "definition unchanged",
+			definition: state.Definition[types.Chain]{
+				Definition: types.Chain{ID: map[string]string{"testnet": "chainID"}},
+				Commit:     "commit",
+			},
+		},
+		{
+			name: "definition changed",
+			definition: state.Definition[types.Chain]{
+				Definition: types.Chain{ID: map[string]string{"testnet": "chainID"}},
+				Commit:     "newCommit",
+			},
+			wantReapply: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			stateFile, err := state.New(t.TempDir())
+			require.NoError(t, err)
+			stateFile.ChainRegistry[chain] = &state.ChainInfo{
+				ID:      "chainID",
+				Network: "testnet",
+				Commit:  "commit",
+			}
+
+			repository := state.NewMockRepository(ctrl)
+			repoFactory := state.NewMockRepositoryFactory(ctrl)
+			if test.repoErr != nil {
+				repoFactory.EXPECT().GetRepository(repoAlias).Return(nil, test.repoErr)
+			} else {
+				repoFactory.EXPECT().GetRepository(repoAlias).Return(repository, nil)
+				repository.EXPECT().GetChain("chain").Return(test.definition, test.chainErr)
+			}
+
+			executor := NewMockExecutor(ctrl)
+			if test.wantReapply {
+				executor.EXPECT().Execute(gomock.AssignableToTypeOf(&ApplyChainConfig{})).Return(nil)
+			}
+
+			err = NewUpgrade(UpgradeConfig{
+				Executor:    executor,
+				RepoFactory: repoFactory,
+				StateFile:   stateFile,
+			}).Execute()
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Contains(t, stateFile.ChainRegistry, chain)
+		})
+	}
+}
@@ -10,8 +10,11 @@ import (
 
 	"github.com/spf13/afero"
 
+	"github.com/luxfi/lpm/cache"
 	"github.com/luxfi/lpm/git"
+	"github.com/luxfi/lpm/signature"
 	"github.com/luxfi/lpm/state"
+	"github.com/luxfi/lpm/types"
 	"github.com/luxfi/lpm/util"
 )
 
@@ -20,35 +23,60 @@ var ErrAlreadyUpdated = errors.New("already up-to-date")
 type UpgradeVMConfig struct {
 	Executor Executor
 
-	FullVMName  string
-	RepoFactory state.RepositoryFactory
-	StateFile   state.File
+	FullVMName string
+	// Constraint replaces the version constraint the VM was installed with.
+	// Empty keeps the existing constraint.
+	Constraint string
+	Platform   types.Platform
+	// AllowUnsigned installs upgrades that aren't signed
+	AllowUnsigned bool
+	// KeepVersions is how many replaced binaries are kept to roll back to
+	KeepVersions int
+	RepoFactory  state.RepositoryFactory
+	StateFile    state.File
 
 	TmpPath    string
 	PluginPath string
 	Installer  Installer
+	Verifier   signature.Verifier
 	Fs         afero.Fs
 	Git        git.Factory
+	// Cache is checked for artifacts before downloading them
+	Cache *cache.Cache
+	// Plan records what the upgrade would do instead of doing it
+	Plan *Plan
 }
 
 func NewUpgradeVM(config UpgradeVMConfig) *UpgradeVM {
 	return &UpgradeVM{
-		executor:    config.Executor,
-		fullVMName:  config.FullVMName,
-		repoFactory: config.RepoFactory,
-		stateFile:   config.StateFile,
-		tmpPath:     config.TmpPath,
-		pluginPath:  config.PluginPath,
-		installer:   config.Installer,
-		fs:          config.Fs,
-		git:         config.Git,
+		executor:      config.Executor,
+		fullVMName:    config.FullVMName,
+		constraint:    config.Constraint,
+		platform:      config.Platform,
+		allowUnsigned: config.AllowUnsigned,
+		keepVersions:  config.KeepVersions,
+		repoFactory:   config.RepoFactory,
+		stateFile:     config.StateFile,
+		tmpPath:       config.TmpPath,
+		pluginPath:    config.PluginPath,
+		installer:     config.Installer,
+		verifier:      config.Verifier,
+		fs:            config.Fs,
+		git:           config.Git,
+		cache:         config.Cache,
+		plan:          config.Plan,
 	}
 }
 
 type UpgradeVM struct {
 	fullVMName string
+	constraint string
+	platform   types.Platform
 	executor   Executor
 
+	allowUnsigned bool
+	keepVersions  int
+
 	repoFactory state.RepositoryFactory
 
 	stateFile state.File
@@ -57,12 +85,26 @@ type UpgradeVM struct {
 	pluginPath string
 
 	installer Installer
+	verifier  signature.Verifier
 	fs        afero.Fs
 	git       git.Factory
+	cache     *cache.Cache
+	plan      *Plan
 }
 
 func (u *UpgradeVM) Execute() error {
-	installInfo := u.stateFile.InstallationRegistry[u.fullVMName]
+	installInfo, ok := u.stateFile.InstallationRegistry[u.fullVMName]
+	if !ok {
+		return fmt.Errorf("%s is not installed", u.fullVMName)
+	}
+
+	if installInfo.Origin.Type != state.RepositoryOrigin {
+		return fmt.Errorf(
+			"%s was installed from %s and can only be upgraded by reinstalling it",
+			u.fullVMName,
+			installInfo.Origin.Type,
+		)
+	}
 
 	repoAlias, vmName := util.ParseQualifiedName(u.fullVMName)
 	organization, repo := util.ParseAlias(repoAlias)
@@ -78,39 +120,75 @@ func (u *UpgradeVM) Execute() error {
 		return err
 	}
 
-	if _, err := repository.GetVM(vmName); err != nil {
+	definition, err := repository.GetVM(vmName)
+	if err != nil {
 		fmt.Printf("Warning - found a vm while upgrading %s which is no "+
 			"longer registered in a repository. You should uninstall this VM to "+
 			"avoid noisy logs. Skipping...\n", u.fullVMName)
 		return nil
 	}
 
-	latest, err := u.git.GetLastModified(repository.GetPath(), fmt.Sprintf("vms/%s.%s", vmName, "yaml"))
+	constraint := installInfo.Constraint
+	if u.constraint != "" {
+		constraint = u.constraint
+	}
+
+	_, version, err := resolveVersion(u.fullVMName, definition.Definition, constraint)
 	if err != nil {
 		return err
 	}
 
-	if installInfo.Commit == latest {
-		return ErrAlreadyUpdated
+	previous, latest := installInfo.Version, version
+	if version == "" {
+		// Definitions without versions are upgraded whenever they change.
+		previous = installInfo.Commit
+		latest, err = u.git.GetLastModified(repository.GetPath(), fmt.Sprintf("vms/%s.%s", vmName, "yaml"))
+		if err != nil {
+			return err
+		}
 	}
 
+	if previous == latest {
+		if constraint == installInfo.Constraint {
+			return ErrAlreadyUpdated
+		}
+
+		fmt.Printf("%s@%s already satisfies %s.\n", u.fullVMName, latest, constraint)
+		if u.plan != nil {
+			u.plan.Register(u.fullVMName, fmt.Sprintf("constraint %s", constraint))
+			return nil
+		}
+		installInfo.Constraint = constraint
+		return nil
+	}
+
+	if previous == "" {
+		previous = installInfo.Commit
+	}
 	fmt.Printf(
 		"Detected an upgrade for %s from %s to %s\n",
 		u.fullVMName,
-		installInfo.Commit,
+		previous,
 		latest,
 	)
 	wf := NewInstall(InstallConfig{
-		Name:         u.fullVMName,
-		Plugin:       vmName,
-		Organization: organization,
-		Repo:         repo,
-		TmpPath:      u.tmpPath,
-		PluginPath:   u.pluginPath,
-		StateFile:    u.stateFile,
-		Repository:   repository,
-		Installer:    u.installer,
-		Fs:           u.fs,
+		Name:          u.fullVMName,
+		Plugin:        vmName,
+		Organization:  organization,
+		Repo:          repo,
+		TmpPath:       u.tmpPath,
+		PluginPath:    u.pluginPath,
+		Constraint:    constraint,
+		Platform:      u.platform,
+		AllowUnsigned: u.allowUnsigned,
+		KeepVersions:  u.keepVersions,
+		StateFile:     u.stateFile,
+		Repository:    repository,
+		Installer:     u.installer,
+		Verifier:      u.verifier,
+		Cache:         u.cache,
+		Plan:          u.plan,
+		Fs:            u.fs,
 	})
 
 	fmt.Printf(
@@ -0,0 +1,84 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/luxfi/lpm/constant"
+	"github.com/luxfi/lpm/semver"
+	"github.com/luxfi/lpm/types"
+)
+
+// resolveVersion returns the definition of the latest released version of vm
+// that satisfies constraint, along with that version. Definitions without
+// released versions are returned as-is with an empty version.
+func resolveVersion(name string, vm types.VM, constraint string) (types.VM, string, error) {
+	if len(vm.Versions) == 0 {
+		if constraint != "" {
+			return types.VM{}, "", fmt.Errorf("%s doesn't publish any versions, so it can't be installed with version constraint %s", name, constraint)
+		}
+		return vm, "", nil
+	}
+
+	parsed, err := semver.ParseConstraint(constraint)
+	if err != nil {
+		return types.VM{}, "", err
+	}
+
+	var (
+		latest   *types.Version
+		latestV  semver.Version
+		versions = make([]string, 0, len(vm.Versions))
+	)
+	for i, release := range vm.Versions {
+		v, err := semver.Parse(release.Version)
+		if err != nil {
+			fmt.Printf("Skipping invalid version %s of %s: %s\n", release.Version, name, err)
+			continue
+		}
+		versions = append(versions, release.Version)
+
+		if !parsed.Check(v) {
+			continue
+		}
+		if latest == nil || v.Compare(latestV) > 0 {
+			latest = &vm.Versions[i]
+			latestV = v
+		}
+	}
+
+	if latest == nil {
+		return types.VM{}, "", fmt.Errorf(
+			"no version of %s satisfies %s. Available versions: %s",
+			name,
+			parsed,
+			strings.Join(versions, ", "),
+		)
+	}
+
+	resolved := vm
+	resolved.URL = latest.URL
+	resolved.SHA256 = latest.SHA256
+	resolved.Signature = latest.Signature
+	resolved.Format = latest.Format
+	resolved.Artifacts = latest.Artifacts
+	if latest.InstallScript != "" {
+		resolved.InstallScript = latest.InstallScript
+	}
+	if latest.BinaryPath != "" {
+		resolved.BinaryPath = latest.BinaryPath
+	}
+
+	return resolved, latest.Version, nil
+}
+
+// versionedName returns name followed by version, if there is one.
+func versionedName(name string, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + constant.VersionDelimiter + version
+}
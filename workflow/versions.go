@@ -0,0 +1,107 @@
+// Copyright (C) 2019-2025, Lux Partners Limited. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/luxfi/lpm/state"
+)
+
+// labelReplacer makes a version or commit safe to use as a file name.
+var labelReplacer = strings.NewReplacer("/", "_", "\\", "_", "..", "_")
+
+// keepVersion copies the binary installed for info into the versions
+// directory so that it can be rolled back to, and returns previous with it
+// added in front. Only the keep most recent versions are kept, older ones are
+// deleted. The installed binary is left in place so it can be replaced
+// atomically.
+func keepVersion(
+	tx Transaction,
+	fs afero.Fs,
+	info *state.InstallInfo,
+	pluginPath string,
+	previous []state.PreviousVersion,
+	keep int,
+) ([]state.PreviousVersion, error) {
+	binaryPath := info.BinaryPath(pluginPath)
+	if _, err := fs.Stat(binaryPath); errors.Is(err, iofs.ErrNotExist) {
+		fmt.Printf("Warning - %s doesn't exist, so it can't be kept for rollbacks.\n", binaryPath)
+		return previous, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	kept, versions, removed := keptVersions(info, previous, keep)
+	dest := filepath.Join(pluginPath, filepath.FromSlash(kept.Path))
+	if err := prepareBinary(tx, fs, dest); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Keeping %s@%s for rollbacks...\n", info.ID, path.Base(kept.Path))
+	if err := replaceBinary(fs, binaryPath, dest); err != nil {
+		return nil, fmt.Errorf("failed to keep %s: %w", binaryPath, err)
+	}
+
+	for _, version := range removed {
+		if err := removeVersion(tx, fs, pluginPath, version); err != nil {
+			return nil, err
+		}
+	}
+
+	return versions, nil
+}
+
+// keptVersions returns where the binary installed for info is kept, the
+// versions that are kept with it most recent first, and the versions that are
+// deleted to only keep the keep most recent ones.
+func keptVersions(
+	info *state.InstallInfo,
+	previous []state.PreviousVersion,
+	keep int,
+) (state.PreviousVersion, []state.PreviousVersion, []state.PreviousVersion) {
+	kept := state.PreviousVersion{
+		Version:     info.Version,
+		Commit:      info.Commit,
+		SHA256:      info.SHA256,
+		Signer:      info.Signer,
+		InstalledAt: info.InstalledAt,
+	}
+	label := kept.Label()
+	if label == "" {
+		label = info.SHA256
+	}
+	kept.Path = path.Join(state.VersionsDir, info.ID, labelReplacer.Replace(label))
+
+	versions := []state.PreviousVersion{kept}
+	for _, version := range previous {
+		// The same version was kept again, so it's overwritten.
+		if version.Path != kept.Path {
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) <= keep {
+		return kept, versions, nil
+	}
+
+	return kept, versions[:keep], versions[keep:]
+}
+
+// removeVersion deletes a kept binary. It's restored if the workflow fails.
+func removeVersion(tx Transaction, fs afero.Fs, pluginPath string, version state.PreviousVersion) error {
+	versionPath := filepath.Join(pluginPath, filepath.FromSlash(version.Path))
+	if err := tx.Track(versionPath); err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleting %s...\n", versionPath)
+	return removeIfExists(fs, versionPath)
+}